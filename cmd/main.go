@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,9 +15,14 @@ import (
 	"github.com/Payphone-Digital/gateway/internal/router"
 	"github.com/Payphone-Digital/gateway/internal/service"
 	"github.com/Payphone-Digital/gateway/pkg/database"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/mirror"
+	"github.com/Payphone-Digital/gateway/pkg/outlier"
 	"github.com/Payphone-Digital/gateway/pkg/redis"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
 	"github.com/Payphone-Digital/gateway/pkg/routing"
+	"github.com/Payphone-Digital/gateway/pkg/traffic"
 	"go.uber.org/zap"
 )
 
@@ -57,7 +63,7 @@ func main() {
 	defer database.CloseDB(db)
 
 	// Run auto migrations
-	if err := database.AutoMigrate(db); err != nil {
+	if err := database.AutoMigrate(db, config.Database.NotifyEnabled); err != nil {
 		logger.GetLogger().Fatal("Failed to run database migrations", zap.Error(err))
 	}
 	logger.GetLogger().Info("Database migrated successfully")
@@ -101,6 +107,15 @@ func main() {
 	// Initialize Route Registry
 	registry := routing.NewRouteRegistry(logger.GetLogger())
 
+	prefixRewrites, err := routing.ParsePrefixRules(strings.Join(config.Routing.PrefixRewrites, ","))
+	if err != nil {
+		logger.GetLogger().Fatal("Invalid ROUTE_PREFIX_REWRITES", zap.Error(err))
+	}
+	registry.SetPathRules(routing.PathRules{
+		Reserved: config.Routing.ReservedPrefixes,
+		Rewrites: prefixRewrites,
+	})
+
 	// Load all routes from database at startup
 	logger.GetLogger().Info("Initializing route registry")
 	refresher := routing.NewRefresher(registry, integrasiService, logger.GetLogger())
@@ -120,8 +135,63 @@ func main() {
 		)
 	}
 
+	// Propagate route/cache invalidations to the other gateway instances
+	busCtx, busCancel := context.WithCancel(context.Background())
+	defer busCancel()
+	invalidationBus := routing.NewInvalidationBus(
+		redisClient,
+		refresher,
+		cacheService,
+		config.Redis.InvalidationChannel,
+		config.Redis.ReconcileInterval,
+		logger.GetLogger(),
+	)
+	invalidationBus.Start(busCtx)
+
+	// Hot reload configs written directly to the database (bypassing the admin API)
+	if config.Database.NotifyEnabled {
+		routing.NewDBListener(
+			config.DatabaseConnectionString(),
+			database.ConfigChangeChannel,
+			refresher,
+			config.Database.NotifyDebounce,
+			logger.GetLogger(),
+		).Start(busCtx)
+	}
+
+	// Per-variant status and latency of routes splitting traffic between upstreams
+	trafficStats := traffic.NewStats()
+
+	// Mirrored copies of requests to shadow upstreams, with their comparison to the primary response
+	shadow := mirror.NewShadow(config.Routing.MirrorMaxInFlight, config.Routing.MirrorTimeout)
+
+	// Pooled clients, per-upstream circuit breakers, health monitoring, outlier ejection, retry budget and
+	// response header policy for all proxied traffic
+	executorConfig := integrasi.DefaultExecutorConfig()
+	executorConfig.OutlierConfig = outlier.Config{
+		ConsecutiveErrors:   config.Upstream.OutlierConsecutiveErrors,
+		Interval:            config.Upstream.OutlierInterval,
+		BaseEjectionTime:    config.Upstream.OutlierBaseEjectionTime,
+		MaxEjectionTime:     config.Upstream.OutlierMaxEjectionTime,
+		MaxEjectionPercent:  config.Upstream.OutlierMaxEjectionPercent,
+		SuccessRateMinHosts: config.Upstream.OutlierSuccessRateMinHosts,
+		SuccessRateMinCalls: config.Upstream.OutlierSuccessRateMinCalls,
+		SuccessRateStdev:    config.Upstream.OutlierSuccessRateStdev,
+	}
+	executorConfig.RetryBudget = retry.BudgetConfig{
+		Percent:      config.Upstream.RetryBudgetPercent,
+		MinPerSecond: config.Upstream.RetryBudgetMinPerSecond,
+		Window:       config.Upstream.RetryBudgetWindow,
+	}
+	executorConfig.ResponseHeaders = integrasi.HeaderPolicy{
+		Allow: config.Upstream.ResponseHeadersAllow,
+		Deny:  config.Upstream.ResponseHeadersDeny,
+	}
+	executor := integrasi.NewExecutor(executorConfig, logger.GetLogger())
+	defer executor.Close()
+
 	// Handlers
-	integrasiHandler := handler.NewAPIConfigHandler(integrasiService, refresher, cacheService)
+	integrasiHandler := handler.NewAPIConfigHandler(integrasiService, refresher, cacheService, invalidationBus, trafficStats, shadow, executor)
 	userHandler := handler.NewUserHandler(userService)
 	authHandler := handler.NewAuthHandler(userService)
 	healthHandler := handler.NewHealthHandler(db, redisClient)
@@ -129,7 +199,7 @@ func main() {
 	// Initialize middleware
 	validationMiddleware := middleware.NewValidationMiddleware()
 	jwtMiddleware := middleware.NewJWTMiddleware(jwtService, userRepo)
-	dynamicURIMiddleware := middleware.NewDynamicURIMiddleware(registry, cacheService, jwtMiddleware)
+	dynamicURIMiddleware := middleware.NewDynamicURIMiddleware(registry, cacheService, jwtMiddleware, trafficStats, shadow, executor, config.Routing.RedirectHosts)
 
 	r := router.NewRouter(
 		db,
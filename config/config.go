@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,6 +16,8 @@ type Config struct {
 	Redis     RedisConfig
 	JWT       JWTConfig
 	RateLimit RateLimitConfig
+	Routing   RoutingConfig
+	Upstream  UpstreamConfig
 }
 
 type AppConfig struct {
@@ -36,6 +39,10 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+
+	// LISTEN/NOTIFY driven hot reload of configs
+	NotifyEnabled  bool          `mapstructure:"notify_enabled"`
+	NotifyDebounce time.Duration `mapstructure:"notify_debounce"`
 }
 
 type JWTConfig struct {
@@ -57,6 +64,44 @@ type RedisConfig struct {
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
 	PoolTimeout  time.Duration `mapstructure:"pool_timeout"`
+
+	// Cluster-wide route/cache invalidation
+	InvalidationChannel string        `mapstructure:"invalidation_channel"`
+	ReconcileInterval   time.Duration `mapstructure:"reconcile_interval"`
+}
+
+type RoutingConfig struct {
+	// Path prefixes served by the gateway itself, never matched against API configs
+	ReservedPrefixes []string `mapstructure:"reserved_prefixes"`
+	// "strip=>add" rules tried in order when a path doesn't match as is
+	PrefixRewrites []string `mapstructure:"prefix_rewrites"`
+	// Mirrored requests sent at once across all routes, further ones are dropped
+	MirrorMaxInFlight int `mapstructure:"mirror_max_in_flight"`
+	// Time a mirrored request gets to complete
+	MirrorTimeout time.Duration `mapstructure:"mirror_timeout"`
+	// Hosts redirect routes may send clients to when their location is built from request values
+	RedirectHosts []string `mapstructure:"redirect_hosts"`
+}
+
+type UpstreamConfig struct {
+	// Passive outlier detection over the targets of a URL config
+	OutlierConsecutiveErrors   int           `mapstructure:"outlier_consecutive_errors"`
+	OutlierInterval            time.Duration `mapstructure:"outlier_interval"`
+	OutlierBaseEjectionTime    time.Duration `mapstructure:"outlier_base_ejection_time"`
+	OutlierMaxEjectionTime     time.Duration `mapstructure:"outlier_max_ejection_time"`
+	OutlierMaxEjectionPercent  int           `mapstructure:"outlier_max_ejection_percent"`
+	OutlierSuccessRateMinHosts int           `mapstructure:"outlier_success_rate_min_hosts"`
+	OutlierSuccessRateMinCalls int           `mapstructure:"outlier_success_rate_min_calls"`
+	OutlierSuccessRateStdev    float64       `mapstructure:"outlier_success_rate_stdev"`
+
+	// Retries allowed across all upstreams, as a share of requests over a sliding window
+	RetryBudgetPercent      float64       `mapstructure:"retry_budget_percent"`
+	RetryBudgetMinPerSecond int           `mapstructure:"retry_budget_min_per_second"`
+	RetryBudgetWindow       time.Duration `mapstructure:"retry_budget_window"`
+
+	// Upstream response headers passed on to clients, URL configs add their own lists
+	ResponseHeadersAllow []string `mapstructure:"response_headers_allow"` // empty = all
+	ResponseHeadersDeny  []string `mapstructure:"response_headers_deny"`
 }
 
 type RateLimitConfig struct {
@@ -90,6 +135,8 @@ func LoadConfig() (*Config, error) {
 			MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 200),
 			ConnMaxLifetime: getEnvAsDuration("DB_CONN_MAX_LIFETIME", 2*time.Hour),
 			ConnMaxIdleTime: getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", 15*time.Minute),
+			NotifyEnabled:   getEnvAsBool("DB_NOTIFY_ENABLED", false),
+			NotifyDebounce:  getEnvAsDuration("DB_NOTIFY_DEBOUNCE", 500*time.Millisecond),
 		},
 		Redis: RedisConfig{
 			Host:         getEnv("REDIS_HOST", "localhost"),
@@ -103,6 +150,9 @@ func LoadConfig() (*Config, error) {
 			ReadTimeout:  getEnvAsDuration("REDIS_READ_TIMEOUT", 3*time.Second),
 			WriteTimeout: getEnvAsDuration("REDIS_WRITE_TIMEOUT", 3*time.Second),
 			PoolTimeout:  getEnvAsDuration("REDIS_POOL_TIMEOUT", 4*time.Second),
+
+			InvalidationChannel: getEnv("REDIS_INVALIDATION_CHANNEL", "gateway:route-changes"),
+			ReconcileInterval:   getEnvAsDuration("ROUTE_RECONCILE_INTERVAL", 5*time.Minute),
 		},
 		JWT: JWTConfig{
 			Secret:           getEnv("JWT_SECRET", "default_secret_key_change_in_production"),
@@ -114,6 +164,30 @@ func LoadConfig() (*Config, error) {
 			Request:  getEnvAsInt("RATE_LIMIT_MAX_REQUEST", 5),
 			Duration: getEnvAsInt("RATE_LIMIT_DURATION", 60),
 		},
+		Routing: RoutingConfig{
+			ReservedPrefixes:  getEnvAsList("ROUTE_RESERVED_PREFIXES", []string{"/api/v1/", "/api/health", "/health"}),
+			PrefixRewrites:    getEnvAsList("ROUTE_PREFIX_REWRITES", []string{"/api=>"}),
+			MirrorMaxInFlight: getEnvAsInt("ROUTE_MIRROR_MAX_IN_FLIGHT", 100),
+			MirrorTimeout:     getEnvAsDuration("ROUTE_MIRROR_TIMEOUT", 30*time.Second),
+			RedirectHosts:     getEnvAsList("ROUTE_REDIRECT_HOSTS", nil),
+		},
+		Upstream: UpstreamConfig{
+			OutlierConsecutiveErrors:   getEnvAsInt("UPSTREAM_OUTLIER_CONSECUTIVE_ERRORS", 5),
+			OutlierInterval:            getEnvAsDuration("UPSTREAM_OUTLIER_INTERVAL", 10*time.Second),
+			OutlierBaseEjectionTime:    getEnvAsDuration("UPSTREAM_OUTLIER_BASE_EJECTION_TIME", 30*time.Second),
+			OutlierMaxEjectionTime:     getEnvAsDuration("UPSTREAM_OUTLIER_MAX_EJECTION_TIME", 5*time.Minute),
+			OutlierMaxEjectionPercent:  getEnvAsInt("UPSTREAM_OUTLIER_MAX_EJECTION_PERCENT", 50),
+			OutlierSuccessRateMinHosts: getEnvAsInt("UPSTREAM_OUTLIER_SUCCESS_RATE_MIN_HOSTS", 3),
+			OutlierSuccessRateMinCalls: getEnvAsInt("UPSTREAM_OUTLIER_SUCCESS_RATE_MIN_CALLS", 20),
+			OutlierSuccessRateStdev:    getEnvAsFloat("UPSTREAM_OUTLIER_SUCCESS_RATE_STDEV", 1.9),
+
+			RetryBudgetPercent:      getEnvAsFloat("UPSTREAM_RETRY_BUDGET_PERCENT", 20),
+			RetryBudgetMinPerSecond: getEnvAsInt("UPSTREAM_RETRY_BUDGET_MIN_PER_SECOND", 10),
+			RetryBudgetWindow:       getEnvAsDuration("UPSTREAM_RETRY_BUDGET_WINDOW", 10*time.Second),
+
+			ResponseHeadersAllow: getEnvAsList("UPSTREAM_RESPONSE_HEADERS_ALLOW", nil),
+			ResponseHeadersDeny:  getEnvAsList("UPSTREAM_RESPONSE_HEADERS_DENY", []string{"Server", "X-Powered-By"}),
+		},
 	}
 
 	return config, nil
@@ -152,6 +226,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		boolValue, err := strconv.ParseBool(value)
@@ -170,3 +253,18 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
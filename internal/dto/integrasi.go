@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type Variable struct {
 	Value              interface{}            `json:"value"`
 	Encoding           string                 `json:"encoding"`
@@ -11,11 +13,70 @@ type Variable struct {
 }
 
 // Start API Config (Path Config)
+
+// MaintenanceWindow is a recurring weekly window during which a route answers with its maintenance response
+// End earlier than Start spans midnight, e.g. 23:00-01:00
+type MaintenanceWindow struct {
+	Days     []string `json:"days,omitempty"`     // mon, tue, wed, thu, fri, sat, sun; empty = every day
+	Start    string   `json:"start"`              // HH:MM
+	End      string   `json:"end"`                // HH:MM
+	Timezone string   `json:"timezone,omitempty"` // IANA name like "Asia/Jakarta", default UTC
+}
+
+// Route types, proxy routes forward to the URL config upstream, the others are answered by the gateway
+const (
+	RouteTypeProxy    = "proxy"
+	RouteTypeStatic   = "static"
+	RouteTypeRedirect = "redirect"
+	RouteTypeMock     = "mock"
+)
+
+// MockResponse is an example payload returned by a mock route
+type MockResponse struct {
+	Name    string            `json:"name"`              // Selected with the X-Mock-Response header, by name or status
+	Status  int               `json:"status"`            // Default 200
+	Headers map[string]string `json:"headers,omitempty"` // Sent as is
+	Body    interface{}       `json:"body"`              // Any JSON value
+}
+
+// RouteVariant is an alternative upstream receiving part of a route's traffic, e.g. a canary
+type RouteVariant struct {
+	Name        string             `json:"name"`
+	URLConfigID uint               `json:"url_config_id"`
+	Weight      int                `json:"weight"`               // Percent of traffic, the route's own upstream gets the rest
+	URL         string             `json:"url,omitempty"`        // Complete URL = variant URLConfig.URL + URI (responses only)
+	URLConfig   *URLConfigResponse `json:"url_config,omitempty"` // Responses only
+}
+
+// RouteMirror sends a copy of a share of a route's requests to another upstream, responses are discarded
+type RouteMirror struct {
+	URLConfigID  uint               `json:"url_config_id"`
+	Percent      int                `json:"percent"`                 // Share of requests mirrored, 0-100
+	Compare      bool               `json:"compare,omitempty"`       // Compare status and body with the primary response
+	IgnoreFields []string           `json:"ignore_fields,omitempty"` // JSON paths left out of the comparison, e.g. "data.created_at"
+	URL          string             `json:"url,omitempty"`           // Complete URL = mirror URLConfig.URL + URI (responses only)
+	URLConfig    *URLConfigResponse `json:"url_config,omitempty"`    // Responses only
+}
+
+// RouteHedge sends a second copy of a slow idempotent request to another target of the route's upstream,
+// the first response wins and the other copy is cancelled
+type RouteHedge struct {
+	DelayMs    int     `json:"delay_ms,omitempty"`    // Wait before the second copy, 0 = the route's observed latency percentile
+	Percentile int     `json:"percentile,omitempty"`  // Latency percentile used when delay_ms is 0, default 95
+	MaxPercent float64 `json:"max_percent,omitempty"` // Share of requests hedged at most, default 10
+}
+
+// UpstreamTarget is one instance behind a URL config, requests are spread over the targets by its load balancing strategy
+type UpstreamTarget struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight,omitempty"` // Used by weighted, least_connections, random and consistent_hash, default 1
+}
+
 type APIConfigRequest struct {
 	Path         string                 `json:"path" validate:"required"`   // Dynamic path like "/users", "/products"
 	Method       string                 `json:"method" validate:"required"` // HTTP method like "GET", "POST" or gRPC method like "GetUser"
-	URLConfigID  uint                   `json:"url_config_id" validate:"required"`
-	URI          string                 `json:"uri"` // Optional untuk HTTP, kosong untuk gRPC
+	URLConfigID  uint                   `json:"url_config_id"`              // Required for proxy routes
+	URI          string                 `json:"uri"`                        // Optional untuk HTTP, kosong untuk gRPC
 	Headers      map[string]string      `json:"headers"`
 	QueryParams  map[string]string      `json:"query_params"`
 	Body         map[string]interface{} `json:"body"`
@@ -39,6 +100,41 @@ type APIConfigRequest struct {
 	// Priority
 	Priority int `json:"priority"`
 
+	// Match Predicates
+	MatchHost    string            `json:"match_host"`    // Host pattern like "api.partner.com" or "*.partner.com"
+	MatchHeaders map[string]string `json:"match_headers"` // Required headers, empty value = header must be present
+	MatchQuery   map[string]string `json:"match_query"`   // Required query params, empty value = param must be present
+
+	// Schedule (enforced by the route registry at match time)
+	ActiveFrom          *time.Time          `json:"active_from,omitempty"`          // Not served before this instant
+	ActiveUntil         *time.Time          `json:"active_until,omitempty"`         // Not served from this instant on
+	MaintenanceSchedule []MaintenanceWindow `json:"maintenance_schedule,omitempty"` // Recurring maintenance windows
+	MaintenanceStatus   int                 `json:"maintenance_status,omitempty"`   // Status while unavailable, default 503
+	MaintenanceBody     interface{}         `json:"maintenance_body,omitempty"`     // JSON body while unavailable
+
+	// Route Type
+	RouteType        string            `json:"route_type"`                  // proxy (default), static, redirect, mock
+	ResponseStatus   int               `json:"response_status,omitempty"`   // Static status, default 200
+	ResponseHeaders  map[string]string `json:"response_headers,omitempty"`  // Static headers, values may use {{var}}
+	ResponseBody     interface{}       `json:"response_body,omitempty"`     // Static JSON body, strings may use {{var}}
+	RedirectStatus   int               `json:"redirect_status,omitempty"`   // 301, 302, 307 or 308, default 302
+	RedirectLocation string            `json:"redirect_location,omitempty"` // Location header, may use {{var}}
+	MockResponses    []MockResponse    `json:"mock_responses,omitempty"`    // The first one is the default
+
+	// Traffic Split (proxy routes only)
+	Variants            []RouteVariant `json:"variants,omitempty"`
+	SplitKey            string         `json:"split_key,omitempty"`             // header:<name>, cookie:<name>, query:<name>, user or ip; empty = random per request
+	SplitOverrideHeader string         `json:"split_override_header,omitempty"` // Header forcing a variant by name, default X-Route-Variant
+
+	// Traffic Mirror (proxy routes only)
+	Mirror *RouteMirror `json:"mirror,omitempty"`
+
+	// Hedging (proxy routes only)
+	Hedge *RouteHedge `json:"hedge,omitempty"`
+
+	// Streaming (HTTP proxy routes only): bodies are copied as is, no caching or manipulation
+	Stream bool `json:"stream"`
+
 	// Authentication Configuration
 	AuthType         string `json:"auth_type"`                     // none, jwt, basic, apikey, gateway
 	AuthRequired     bool   `json:"auth_required"`                 // Whether authentication is required
@@ -106,6 +202,41 @@ type APIConfigResponse struct {
 	// Priority
 	Priority int `json:"priority"`
 
+	// Match Predicates
+	MatchHost    string            `json:"match_host"`    // Host pattern like "api.partner.com" or "*.partner.com"
+	MatchHeaders map[string]string `json:"match_headers"` // Required headers, empty value = header must be present
+	MatchQuery   map[string]string `json:"match_query"`   // Required query params, empty value = param must be present
+
+	// Schedule (enforced by the route registry at match time)
+	ActiveFrom          *time.Time          `json:"active_from,omitempty"`          // Not served before this instant
+	ActiveUntil         *time.Time          `json:"active_until,omitempty"`         // Not served from this instant on
+	MaintenanceSchedule []MaintenanceWindow `json:"maintenance_schedule,omitempty"` // Recurring maintenance windows
+	MaintenanceStatus   int                 `json:"maintenance_status,omitempty"`   // Status while unavailable, default 503
+	MaintenanceBody     interface{}         `json:"maintenance_body,omitempty"`     // JSON body while unavailable
+
+	// Route Type
+	RouteType        string            `json:"route_type"`                  // proxy (default), static, redirect, mock
+	ResponseStatus   int               `json:"response_status,omitempty"`   // Static status, default 200
+	ResponseHeaders  map[string]string `json:"response_headers,omitempty"`  // Static headers, values may use {{var}}
+	ResponseBody     interface{}       `json:"response_body,omitempty"`     // Static JSON body, strings may use {{var}}
+	RedirectStatus   int               `json:"redirect_status,omitempty"`   // 301, 302, 307 or 308, default 302
+	RedirectLocation string            `json:"redirect_location,omitempty"` // Location header, may use {{var}}
+	MockResponses    []MockResponse    `json:"mock_responses,omitempty"`    // The first one is the default
+
+	// Traffic Split (proxy routes only)
+	Variants            []RouteVariant `json:"variants,omitempty"`
+	SplitKey            string         `json:"split_key,omitempty"`             // header:<name>, cookie:<name>, query:<name>, user or ip; empty = random per request
+	SplitOverrideHeader string         `json:"split_override_header,omitempty"` // Header forcing a variant by name, default X-Route-Variant
+
+	// Traffic Mirror (proxy routes only)
+	Mirror *RouteMirror `json:"mirror,omitempty"`
+
+	// Hedging (proxy routes only)
+	Hedge *RouteHedge `json:"hedge,omitempty"`
+
+	// Streaming (HTTP proxy routes only): bodies are copied as is, no caching or manipulation
+	Stream bool `json:"stream"`
+
 	// Authentication Configuration
 	AuthType         string             `json:"auth_type"`
 	AuthRequired     bool               `json:"auth_required"`
@@ -195,6 +326,9 @@ type URLConfigRequest struct {
 	ProtoFile   string `json:"proto_file,omitempty"`
 	TLSEnabled  bool   `json:"tls_enabled"`
 
+	// Public base path routes are mounted under (e.g. "/pay")
+	BasePath string `json:"base_path"`
+
 	// Connection Pool Settings
 	MaxConnections     int `json:"max_connections"`
 	MinIdleConnections int `json:"min_idle_connections"`
@@ -207,13 +341,32 @@ type URLConfigRequest struct {
 	HealthCheckInterval int    `json:"health_check_interval"`
 
 	// Circuit Breaker Settings
-	CircuitBreakerEnabled   bool   `json:"circuit_breaker_enabled"`
-	CircuitBreakerThreshold int    `json:"circuit_breaker_threshold"`
-	CircuitBreakerTimeout   int    `json:"circuit_breaker_timeout"`
-	RetryOnStatusCodes      string `json:"retry_on_status_codes"`
+	CircuitBreakerEnabled    bool   `json:"circuit_breaker_enabled"`
+	CircuitBreakerThreshold  int    `json:"circuit_breaker_threshold"`
+	CircuitBreakerTimeout    int    `json:"circuit_breaker_timeout"`
+	RetryOnStatusCodes       string `json:"retry_on_status_codes"`
+	RetryOnErrors            string `json:"retry_on_errors"`
+	RetryPerTryTimeoutMillis int    `json:"retry_per_try_timeout_ms"`
+
+	// Sliding Window Circuit Breaker
+	CircuitBreakerScope          string  `json:"circuit_breaker_scope" validate:"omitempty,oneof=upstream route"`
+	CircuitBreakerWindowType     string  `json:"circuit_breaker_window_type" validate:"omitempty,oneof=consecutive count time"`
+	CircuitBreakerWindowSize     int     `json:"circuit_breaker_window_size"`
+	CircuitBreakerMinimumCalls   int     `json:"circuit_breaker_minimum_calls"`
+	CircuitBreakerFailureRate    float64 `json:"circuit_breaker_failure_rate" validate:"gte=0,lte=100"`
+	CircuitBreakerSlowCallRate   float64 `json:"circuit_breaker_slow_call_rate" validate:"gte=0,lte=100"`
+	CircuitBreakerSlowCallMillis int     `json:"circuit_breaker_slow_call_ms"`
 
 	// Load Balancing
-	LoadBalancingStrategy string `json:"load_balancing_strategy"`
+	// Instances requests are spread over, empty = url only; strategy defaults to round_robin
+	Targets               []UpstreamTarget `json:"targets"`
+	LoadBalancingStrategy string           `json:"load_balancing_strategy" validate:"omitempty,oneof=round_robin weighted least_connections random consistent_hash"`
+	HashKey               string           `json:"hash_key"` // consistent_hash key: header:<name>, cookie:<name>, query:<name>, user or ip
+
+	// Response Headers
+	// Comma-separated upstream response headers passed on (empty = all) and dropped, X-Internal-* matches a prefix
+	ResponseHeadersAllow string `json:"response_headers_allow"`
+	ResponseHeadersDeny  string `json:"response_headers_deny"`
 
 	// Upstream Authentication
 	AuthType     string `json:"auth_type"`
@@ -238,6 +391,9 @@ type URLConfigResponse struct {
 	ProtoFile   string `json:"proto_file,omitempty"`
 	TLSEnabled  bool   `json:"tls_enabled"`
 
+	// Public base path routes are mounted under (e.g. "/pay")
+	BasePath string `json:"base_path"`
+
 	// Connection Pool Settings
 	MaxConnections     int `json:"max_connections"`
 	MinIdleConnections int `json:"min_idle_connections"`
@@ -250,21 +406,38 @@ type URLConfigResponse struct {
 	HealthCheckInterval int    `json:"health_check_interval"`
 
 	// Circuit Breaker Settings
-	CircuitBreakerEnabled   bool   `json:"circuit_breaker_enabled"`
-	CircuitBreakerThreshold int    `json:"circuit_breaker_threshold"`
-	CircuitBreakerTimeout   int    `json:"circuit_breaker_timeout"`
-	RetryOnStatusCodes      string `json:"retry_on_status_codes"`
+	CircuitBreakerEnabled    bool   `json:"circuit_breaker_enabled"`
+	CircuitBreakerThreshold  int    `json:"circuit_breaker_threshold"`
+	CircuitBreakerTimeout    int    `json:"circuit_breaker_timeout"`
+	RetryOnStatusCodes       string `json:"retry_on_status_codes"`
+	RetryOnErrors            string `json:"retry_on_errors"`
+	RetryPerTryTimeoutMillis int    `json:"retry_per_try_timeout_ms"`
+
+	// Sliding Window Circuit Breaker
+	CircuitBreakerScope          string  `json:"circuit_breaker_scope"`
+	CircuitBreakerWindowType     string  `json:"circuit_breaker_window_type"`
+	CircuitBreakerWindowSize     int     `json:"circuit_breaker_window_size"`
+	CircuitBreakerMinimumCalls   int     `json:"circuit_breaker_minimum_calls"`
+	CircuitBreakerFailureRate    float64 `json:"circuit_breaker_failure_rate"`
+	CircuitBreakerSlowCallRate   float64 `json:"circuit_breaker_slow_call_rate"`
+	CircuitBreakerSlowCallMillis int     `json:"circuit_breaker_slow_call_ms"`
 
 	// Load Balancing
-	LoadBalancingStrategy string `json:"load_balancing_strategy"`
+	Targets               []UpstreamTarget `json:"targets,omitempty"`
+	LoadBalancingStrategy string           `json:"load_balancing_strategy"`
+	HashKey               string           `json:"hash_key,omitempty"`
 
-	// Upstream Authentication
+	// Response Headers
+	ResponseHeadersAllow string `json:"response_headers_allow,omitempty"`
+	ResponseHeadersDeny  string `json:"response_headers_deny,omitempty"`
+
+	// Upstream Authentication, the credentials are used by the executor and never written to a response
 	AuthType     string `json:"auth_type"`
 	AuthUsername string `json:"auth_username,omitempty"`
-	AuthPassword string `json:"auth_password,omitempty"`
-	AuthToken    string `json:"auth_token,omitempty"`
-	AuthKey      string `json:"auth_key,omitempty"`
-	AuthValue    string `json:"auth_value,omitempty"`
+	AuthPassword string `json:"-"`
+	AuthToken    string `json:"-"`
+	AuthKey      string `json:"-"`
+	AuthValue    string `json:"-"`
 	AuthAddTo    string `json:"auth_add_to,omitempty"`
 }
 
@@ -284,3 +457,226 @@ type URLConfigFilter struct {
 	Protocol string `query:"protocol"`  // Filter by protocol (http/grpc)
 	IsActive *bool  `query:"is_active"` // Filter by active status (nil = no filter)
 }
+
+// RouteIssue describes a route involved in a conflict or shadowing finding
+type RouteIssue struct {
+	ID       uint   `json:"id,omitempty"`
+	Path     string `json:"path"`
+	Method   string `json:"method"`
+	Priority int    `json:"priority"`
+	Reason   string `json:"reason"`
+}
+
+// PathConfigValidationResponse is the dry-run result of validating an APIConfigRequest
+type PathConfigValidationResponse struct {
+	Valid               bool         `json:"valid"`
+	Conflicts           []RouteIssue `json:"conflicts"`            // Config cannot be registered as is
+	ShadowedBy          []RouteIssue `json:"shadowed_by"`          // Existing routes making this config unreachable
+	Shadows             []RouteIssue `json:"shadows"`              // Existing routes this config would make unreachable
+	UndeclaredVariables []string     `json:"undeclared_variables"` // {{var}} referenced but not declared in Variables
+	UnknownURLConfigID  bool         `json:"unknown_url_config_id"`
+}
+
+// RouteTraceStep is one decision taken while walking the route trie
+type RouteTraceStep struct {
+	Depth    int    `json:"depth"`
+	Segment  string `json:"segment"`  // Request segment ("" at the end of the path)
+	Pattern  string `json:"pattern"`  // Trie segment compared against
+	Kind     string `json:"kind"`     // static, param, wildcard, catch-all, end
+	Decision string `json:"decision"` // matched, rejected by constraint, route found, ...
+}
+
+// RouteCandidate is a config reached by the path and serving the request method
+type RouteCandidate struct {
+	ID                uint   `json:"id"`
+	Path              string `json:"path"`
+	Method            string `json:"method"`
+	Priority          int    `json:"priority"`
+	PredicatesMatched bool   `json:"predicates_matched"`
+	Available         bool   `json:"available"` // Inside its active window and not in maintenance
+	Selected          bool   `json:"selected"`
+}
+
+// RouteMatchAttempt is one lookup of a request path in the route registry
+type RouteMatchAttempt struct {
+	Path       string            `json:"path"`
+	Steps      []RouteTraceStep  `json:"steps"`
+	Candidates []RouteCandidate  `json:"candidates"`
+	Methods    []string          `json:"available_methods"`
+	URIParams  map[string]string `json:"uri_params,omitempty"`
+	Result     string            `json:"result"` // matched, route not found, method not allowed
+}
+
+// RouteExplainResponse explains how a request would be routed
+type RouteExplainResponse struct {
+	Method        string                 `json:"method"`
+	Path          string                 `json:"path"`
+	Host          string                 `json:"host,omitempty"`
+	Reserved      bool                   `json:"reserved"` // Served by the gateway's own routes, never matched against configs
+	Matched       bool                   `json:"matched"`
+	Status        int                    `json:"status"`                   // 200 when matched, otherwise the status the gateway would answer with (0 when reserved)
+	RewrittenPath string                 `json:"rewritten_path,omitempty"` // Path produced by the prefix rule that matched
+	MatchedPath   string                 `json:"matched_path,omitempty"`   // Route pattern including the URL config base path
+	Allow         []string               `json:"allow,omitempty"`          // Allow header sent with 405 and automatic OPTIONS responses
+	Attempts      []RouteMatchAttempt    `json:"attempts"`
+	URIParams     map[string]string      `json:"uri_params,omitempty"`
+	Config        *APIConfigResponse     `json:"config,omitempty"` // Secrets are redacted
+	Upstream      *RouteExplainUpstream  `json:"upstream,omitempty"`
+	Auth          *RouteExplainAuth      `json:"auth,omitempty"`
+	Cache         *RouteExplainCache     `json:"cache,omitempty"`
+	Schedule      *RouteExplainSchedule  `json:"schedule,omitempty"`
+	RateLimit     *RouteExplainRateLimit `json:"rate_limit,omitempty"`
+}
+
+// RouteExplainSchedule reports whether the matched route is served right now
+type RouteExplainSchedule struct {
+	Available           bool                `json:"available"`
+	Reason              string              `json:"reason,omitempty"` // not yet active, expired, maintenance
+	RetryAfter          *time.Time          `json:"retry_after,omitempty"`
+	ActiveFrom          *time.Time          `json:"active_from,omitempty"`
+	ActiveUntil         *time.Time          `json:"active_until,omitempty"`
+	MaintenanceSchedule []MaintenanceWindow `json:"maintenance_schedule,omitempty"`
+}
+
+// RouteExplainUpstream is the upstream a matched request would be forwarded to
+type RouteExplainUpstream struct {
+	Protocol    string `json:"protocol"`
+	URLTemplate string `json:"url_template"`
+	URL         string `json:"url"` // Path params substituted, other variables left as {{name}}
+	Timeout     int    `json:"timeout"`
+	MaxRetries  int    `json:"max_retries"`
+	RetryDelay  int    `json:"retry_delay"`
+	Active      bool   `json:"active"`
+}
+
+// RouteExplainAuth is the authentication applied to a matched request
+type RouteExplainAuth struct {
+	GatewayAdmin bool     `json:"gateway_admin"` // IsAdmin routes require a gateway JWT
+	Required     bool     `json:"required"`
+	Types        []string `json:"types"`
+	APIKeyHeader string   `json:"api_key_header,omitempty"`
+}
+
+// RouteExplainCache is the response caching applied to a matched request
+// Proxied responses are cached while Redis is enabled, unless the route streams or the request opts out
+type RouteExplainCache struct {
+	Available bool `json:"available"` // Redis cache is enabled
+	Enabled   bool `json:"enabled"`   // Responses of the route are cached
+	TTL       int  `json:"ttl"`       // Seconds a 200 response is cached for
+}
+
+// RouteExplainRateLimit is the rate limiting applied to a matched request
+// Only the gateway's own /api/v1 endpoints are rate limited, never the dynamic routes
+type RouteExplainRateLimit struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Start Traffic Split
+
+// RouteVariantsResponse reports how a route's traffic is split and how each variant performs
+// Stats are kept in memory by the instance answering, since its start or the last promote/rollback
+type RouteVariantsResponse struct {
+	ConfigID            uint                `json:"config_id"`
+	Path                string              `json:"path"`
+	Method              string              `json:"method"`
+	SplitKey            string              `json:"split_key,omitempty"`
+	SplitOverrideHeader string              `json:"split_override_header"`
+	Instance            string              `json:"instance"` // The instance whose stats these are
+	Scope               string              `json:"scope"`    // Always "instance", other instances count their own
+	Variants            []RouteVariantStats `json:"variants"` // The primary upstream first
+}
+
+// RouteVariantStats is one variant's share of traffic and its observed status and latency
+type RouteVariantStats struct {
+	Name         string           `json:"name"`
+	URLConfigID  uint             `json:"url_config_id"`
+	Weight       int              `json:"weight"`
+	Active       bool             `json:"active"` // Inactive URL configs get no traffic, their weight goes to primary
+	Requests     int64            `json:"requests"`
+	Errors       int64            `json:"errors"` // 5xx and requests without a response
+	ErrorRate    float64          `json:"error_rate"`
+	Statuses     map[string]int64 `json:"statuses"` // "0" counts requests without a response
+	LatencyAvgMs float64          `json:"latency_avg_ms"`
+	LatencyP50Ms float64          `json:"latency_p50_ms"`
+	LatencyP95Ms float64          `json:"latency_p95_ms"`
+	LatencyP99Ms float64          `json:"latency_p99_ms"`
+	LatencyMaxMs float64          `json:"latency_max_ms"`
+	Since        *time.Time       `json:"since,omitempty"`
+}
+
+// PromoteVariantRequest names the variant whose upstream becomes the route's own
+type PromoteVariantRequest struct {
+	Variant string `json:"variant" validate:"required"`
+}
+
+// End Traffic Split
+
+// Start Traffic Mirror
+
+// RouteMirrorResponse reports the mirrored traffic of a route
+// Counts are kept in memory by the instance answering, since its start or the last mirror change
+type RouteMirrorResponse struct {
+	ConfigID         uint                `json:"config_id"`
+	Path             string              `json:"path"`
+	Method           string              `json:"method"`
+	Mirror           *RouteMirror        `json:"mirror,omitempty"` // Empty when the route isn't mirrored
+	Sent             int64               `json:"sent"`
+	Failed           int64               `json:"failed"`
+	Dropped          int64               `json:"dropped"` // Skipped because too many mirrored requests were in flight
+	Compared         int64               `json:"compared"`
+	StatusMismatches int64               `json:"status_mismatches"`
+	BodyMismatches   int64               `json:"body_mismatches"`
+	Recent           []RouteMirrorResult `json:"recent"` // Latest mismatches and failures, newest first
+	Since            *time.Time          `json:"since,omitempty"`
+}
+
+// RouteMirrorResult is a mirrored request that failed or didn't match the primary response
+type RouteMirrorResult struct {
+	At            time.Time `json:"at"`
+	PrimaryStatus int       `json:"primary_status,omitempty"`
+	MirrorStatus  int       `json:"mirror_status"`
+	LatencyMs     float64   `json:"latency_ms"`
+	Error         string    `json:"error,omitempty"`
+	Differences   []string  `json:"differences,omitempty"` // Differing JSON paths, "body" for non-JSON bodies
+}
+
+// End Traffic Mirror
+
+// Start Circuit Breaker
+
+// CircuitBreakerControlRequest forces a breaker open or closed, or releases it back to its own state
+// Breakers are named "#<url_config_id> <address>", "#<url_config_id> <address> <METHOD> <path>" for route scoped ones
+type CircuitBreakerControlRequest struct {
+	Name   string `json:"name" validate:"required"`
+	Action string `json:"action" validate:"required,oneof=force_open force_close release"`
+}
+
+// CircuitBreakerControlResponse is a breaker after an operator controlled it
+// Breakers live in each instance's memory, so the override only applies on the instance that answered
+type CircuitBreakerControlResponse struct {
+	Instance string                 `json:"instance"`
+	Scope    string                 `json:"scope"` // Always "instance"
+	Breaker  map[string]interface{} `json:"breaker"`
+}
+
+// End Circuit Breaker
+
+// Start Hedge
+
+// RouteHedgeResponse reports the hedging of a route
+// Counts are kept in memory by the instance answering, since its start or the last hedge change
+type RouteHedgeResponse struct {
+	ConfigID  uint        `json:"config_id"`
+	Path      string      `json:"path"`
+	Method    string      `json:"method"`
+	Hedge     *RouteHedge `json:"hedge,omitempty"` // Empty when the route isn't hedged
+	Requests  int64       `json:"requests"`
+	Hedged    int64       `json:"hedged"`
+	HedgeWins int64       `json:"hedge_wins"` // Hedged requests the second copy answered first
+	Capped    int64       `json:"capped"`     // Slow requests not hedged because of max_percent
+	Samples   int         `json:"samples"`    // Latencies the percentile delay is taken from
+	DelayMs   float64     `json:"delay_ms"`   // Current delay, 0 while there are too few samples
+	Since     *time.Time  `json:"since,omitempty"`
+}
+
+// End Hedge
@@ -2,15 +2,23 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Payphone-Digital/gateway/internal/constants"
 	"github.com/Payphone-Digital/gateway/internal/dto"
 	"github.com/Payphone-Digital/gateway/internal/service"
+	"github.com/Payphone-Digital/gateway/pkg/hedge"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/mirror"
 	"github.com/Payphone-Digital/gateway/pkg/routing"
+	"github.com/Payphone-Digital/gateway/pkg/traffic"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -19,15 +27,27 @@ type APIConfigHandler struct {
 	integrasiService *service.APIConfigService
 	routeRefresher   *routing.Refresher
 	cacheService     *service.CacheService
+	invalidationBus  *routing.InvalidationBus
+	trafficStats     *traffic.Stats
+	shadow           *mirror.Shadow
+	executor         *integrasi.Executor
 }
 
 // NewAPIConfigHandler creates a new API config handler
 // routeRefresher is optional - if nil, routes will not be auto-refreshed on CRUD
-func NewAPIConfigHandler(service *service.APIConfigService, routeRefresher *routing.Refresher, cacheService *service.CacheService) *APIConfigHandler {
+// invalidationBus is optional - if nil, changes are not propagated to other instances
+// trafficStats is optional - if nil, variant stats are reported empty
+// shadow is optional - if nil, mirror reports are empty
+// executor is optional - if nil, there are no circuit breakers to report or control
+func NewAPIConfigHandler(service *service.APIConfigService, routeRefresher *routing.Refresher, cacheService *service.CacheService, invalidationBus *routing.InvalidationBus, trafficStats *traffic.Stats, shadow *mirror.Shadow, executor *integrasi.Executor) *APIConfigHandler {
 	return &APIConfigHandler{
 		integrasiService: service,
 		routeRefresher:   routeRefresher,
 		cacheService:     cacheService,
+		invalidationBus:  invalidationBus,
+		trafficStats:     trafficStats,
+		shadow:           shadow,
+		executor:         executor,
 	}
 }
 
@@ -112,6 +132,11 @@ func (h *APIConfigHandler) CreateConfig(c *gin.Context) {
 					zap.String("path", req.Path),
 				)
 			}
+
+			// Notify other instances
+			if h.invalidationBus != nil {
+				_ = h.invalidationBus.PublishRouteChanged(refreshCtx, req.Path, req.Method, "", "")
+			}
 		}()
 	}
 
@@ -342,6 +367,14 @@ func (h *APIConfigHandler) UpdateConfig(c *gin.Context) {
 		return
 	}
 
+	// Mirror comparisons and hedge latencies of the previous settings no longer apply
+	if h.shadow != nil {
+		h.shadow.Reset(uint(id))
+	}
+	if h.executor != nil {
+		h.executor.ResetHedge(uint(id))
+	}
+
 	// Fetch updated config to ensure we have the correct path/slug for refresh
 	// Use ID to get the source of truth from DB, avoiding prefix mismatches (e.g. /api/ vs /)
 	updatedConfig, _, err := h.integrasiService.GetByIDConfig(ctx, uint(id))
@@ -403,6 +436,11 @@ func (h *APIConfigHandler) UpdateConfig(c *gin.Context) {
 				)
 			}
 		}
+
+		// 3. Notify other instances
+		if h.invalidationBus != nil {
+			_ = h.invalidationBus.PublishRouteChanged(refreshCtx, refreshPath, refreshMethod, oldPath, oldMethod)
+		}
 	}()
 
 	c.JSON(status, constants.BuildSuccessResponse("Update successful"))
@@ -499,6 +537,9 @@ func (h *APIConfigHandler) DeleteConfig(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
+	// Path and method of the deleted config, to reload its route
+	existing, _, _ := h.integrasiService.GetByIDConfig(ctx, uint(id))
+
 	status, err := h.integrasiService.DeleteConfig(ctx, uint(id))
 	if err != nil {
 		logger.GetLogger().Error("Failed to delete API config",
@@ -523,12 +564,12 @@ func (h *APIConfigHandler) DeleteConfig(c *gin.Context) {
 		zap.String("client_ip", clientIP),
 	)
 
-	// Refresh route registry if refresher is available (full refresh to remove deleted route)
-	if h.routeRefresher != nil {
+	// Reload the deleted config's route, other configs may still share its path and method
+	if h.routeRefresher != nil && existing != nil {
 		go func() {
 			refreshCtx, refreshCancel := context.WithTimeout(context.Background(), 15*time.Second)
 			defer refreshCancel()
-			if err := h.routeRefresher.Refresh(refreshCtx); err != nil {
+			if err := h.routeRefresher.RefreshSingle(refreshCtx, existing.Path, existing.Method); err != nil {
 				logger.GetLogger().Warn("Failed to refresh route registry after delete",
 					zap.Uint("config_id", uint(id)),
 					zap.Error(err),
@@ -538,6 +579,21 @@ func (h *APIConfigHandler) DeleteConfig(c *gin.Context) {
 					zap.Uint("config_id", uint(id)),
 				)
 			}
+
+			// Drop cached responses of the deleted route
+			if h.cacheService != nil {
+				if err := h.cacheService.InvalidateCache(refreshCtx, existing.Path); err != nil {
+					logger.GetLogger().Warn("Failed to invalidate cache after delete",
+						zap.String("path", existing.Path),
+						zap.Error(err),
+					)
+				}
+			}
+
+			// Notify other instances
+			if h.invalidationBus != nil {
+				_ = h.invalidationBus.PublishRouteRemoved(refreshCtx, existing.Path, existing.Method)
+			}
 		}()
 	}
 
@@ -696,6 +752,641 @@ func (h *APIConfigHandler) DeleteConfig(c *gin.Context) {
 
 // End Delete
 
+// Start Validate
+
+// ValidateConfig dry-runs an APIConfigRequest against the live route registry without saving it
+// Optional ?id= excludes the stored version of a config being edited
+func (h *APIConfigHandler) ValidateConfig(c *gin.Context) {
+	clientIP := c.ClientIP()
+
+	var req dto.APIConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.GetLogger().Warn("Invalid JSON in validate config request",
+			zap.String("client_ip", clientIP),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	var excludeID uint
+	if idParam := c.Query("id"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid ID", ""))
+			return
+		}
+		excludeID = uint(id)
+	}
+
+	logger.GetLogger().Info("Validate API config request",
+		zap.String("path", req.Path),
+		zap.String("method", req.Method),
+		zap.Uint("exclude_id", excludeID),
+		zap.String("client_ip", clientIP),
+	)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	undeclared, unknownURLConfig, err := h.integrasiService.ValidateConfigRequest(ctx, req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			c.JSON(http.StatusRequestTimeout, constants.BuildErrorResponse("Request timeout", "Operation took too long"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, constants.BuildErrorResponse("Validate failed", err.Error()))
+		return
+	}
+
+	resp := dto.PathConfigValidationResponse{
+		Conflicts:           []dto.RouteIssue{},
+		ShadowedBy:          []dto.RouteIssue{},
+		Shadows:             []dto.RouteIssue{},
+		UndeclaredVariables: undeclared,
+		UnknownURLConfigID:  unknownURLConfig,
+	}
+
+	// Simulate the insertion on a clone of the live trie
+	if h.routeRefresher != nil {
+		candidate := &dto.APIConfigResponse{
+			ID:           excludeID,
+			Path:         req.Path,
+			Method:       req.Method,
+			URLConfigID:  req.URLConfigID,
+			URI:          req.URI,
+			Priority:     req.Priority,
+			MatchHost:    req.MatchHost,
+			MatchHeaders: req.MatchHeaders,
+			MatchQuery:   req.MatchQuery,
+		}
+		// The route is matched under its URL config's base path
+		if !unknownURLConfig {
+			if urlConfig, _, err := h.integrasiService.GetByIDURLConfig(ctx, req.URLConfigID); err == nil {
+				candidate.URLConfig = *urlConfig
+			}
+		}
+		conflicts, shadowedBy, shadows := h.routeRefresher.Registry().AnalyzeRoute(candidate, excludeID)
+		resp.Conflicts = append(resp.Conflicts, conflicts...)
+		resp.ShadowedBy = append(resp.ShadowedBy, shadowedBy...)
+		resp.Shadows = append(resp.Shadows, shadows...)
+	}
+
+	resp.Valid = len(resp.Conflicts) == 0 &&
+		len(resp.ShadowedBy) == 0 &&
+		len(resp.UndeclaredVariables) == 0 &&
+		!resp.UnknownURLConfigID
+
+	logger.GetLogger().Info("API config validated",
+		zap.String("path", req.Path),
+		zap.String("method", req.Method),
+		zap.Bool("valid", resp.Valid),
+		zap.Int("conflicts", len(resp.Conflicts)),
+		zap.Int("shadowed_by", len(resp.ShadowedBy)),
+		zap.Int("shadows", len(resp.Shadows)),
+		zap.String("client_ip", clientIP),
+	)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// End Validate
+
+// Start Explain
+
+// ExplainRoute shows how the dynamic URI middleware would route ?method=&path=
+// It walks the route registry segment by segment, including the prefix rewrite rules
+func (h *APIConfigHandler) ExplainRoute(c *gin.Context) {
+	clientIP := c.ClientIP()
+	method := strings.ToUpper(c.Query("method"))
+	path := c.Query("path")
+	host := c.Query("host")
+
+	if method == "" || path == "" {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid request", "method and path are required"))
+		return
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if h.routeRefresher == nil {
+		c.JSON(http.StatusServiceUnavailable, constants.BuildErrorResponse("Route registry unavailable", ""))
+		return
+	}
+
+	logger.GetLogger().Info("Explain route request",
+		zap.String("method", method),
+		zap.String("path", path),
+		zap.String("client_ip", clientIP),
+	)
+
+	// Query string embedded in path is used for match_query predicates
+	var query url.Values
+	if idx := strings.Index(path, "?"); idx >= 0 {
+		query, _ = url.ParseQuery(path[idx+1:])
+		path = path[:idx]
+	}
+
+	// Header predicates are tested against ?header=Name:Value, never against the caller's own headers
+	headers := make(http.Header)
+	for _, header := range c.QueryArray("header") {
+		name, value, _ := strings.Cut(header, ":")
+		if name = strings.TrimSpace(name); name != "" {
+			headers.Add(name, strings.TrimSpace(value))
+		}
+	}
+
+	routeReq := &routing.RouteRequest{
+		Path:    path,
+		Method:  method,
+		Host:    host,
+		Headers: headers,
+		Query:   query,
+	}
+
+	registry := h.routeRefresher.Registry()
+	resp := dto.RouteExplainResponse{
+		Method:   method,
+		Path:     path,
+		Host:     host,
+		Attempts: []dto.RouteMatchAttempt{},
+	}
+
+	if registry.IsReserved(path) {
+		resp.Reserved = true
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	// The outcome is the dynamic URI middleware's own, the traced attempts show how each path was matched
+	config, uriParams, resolvedPath, err := registry.Resolve(routeReq)
+	if resolvedPath != path {
+		resp.RewrittenPath = resolvedPath
+	}
+	for _, candidatePath := range registry.Rewrites(path) {
+		candidateReq := *routeReq
+		candidateReq.Path = candidatePath
+		attempt, _, _, _ := registry.Explain(&candidateReq)
+		resp.Attempts = append(resp.Attempts, attempt)
+	}
+
+	switch {
+	case err == nil:
+		// Routes answered by the gateway report the status they are configured with
+		resp.Status = http.StatusOK
+		switch {
+		case config.RouteType == dto.RouteTypeStatic && config.ResponseStatus != 0:
+			resp.Status = config.ResponseStatus
+		case config.RouteType == dto.RouteTypeRedirect && config.RedirectStatus != 0:
+			resp.Status = config.RedirectStatus
+		case config.RouteType == dto.RouteTypeMock && len(config.MockResponses) > 0 && config.MockResponses[0].Status != 0:
+			resp.Status = config.MockResponses[0].Status
+		}
+	case errors.Is(err, routing.ErrRouteUnavailable):
+		resp.Status = config.MaintenanceStatus
+		if resp.Status == 0 {
+			resp.Status = http.StatusServiceUnavailable
+		}
+	case errors.Is(err, routing.ErrMethodNotAllowed):
+		resp.Allow = registry.AllowedMethods(path)
+		resp.Status = http.StatusMethodNotAllowed
+		if method == http.MethodOptions {
+			resp.Status = http.StatusNoContent
+		}
+	default:
+		resp.Status = http.StatusNotFound
+	}
+
+	if config != nil {
+		resp.Matched = true
+		resp.MatchedPath = routing.MountPath(config)
+		resp.URIParams = uriParams
+		h.explainConfig(&resp, config, uriParams)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// redactedConfig returns a copy of config without its credentials, for responses
+// Upstream credentials (URL configs of the route, its variants, mirror and auth gRPC) are never marshalled, see dto.URLConfigResponse
+func redactedConfig(config *dto.APIConfigResponse) *dto.APIConfigResponse {
+	redacted := *config
+	redacted.JWTSecretKey = ""
+	redacted.BasicAuthUsers = nil
+	redacted.APIKeys = nil
+	return &redacted
+}
+
+// explainConfig fills the upstream (proxy routes only), auth, cache, schedule and rate limit sections for a matched config
+func (h *APIConfigHandler) explainConfig(resp *dto.RouteExplainResponse, config *dto.APIConfigResponse, uriParams map[string]string) {
+	resp.Config = redactedConfig(config)
+
+	// Static, redirect and mock routes never reach the upstream
+	if config.RouteType == "" || config.RouteType == dto.RouteTypeProxy {
+		resolvedURL := config.URL
+		for name, value := range uriParams {
+			resolvedURL = strings.ReplaceAll(resolvedURL, "{{"+name+"}}", value)
+		}
+		resp.Upstream = &dto.RouteExplainUpstream{
+			Protocol:    config.Protocol,
+			URLTemplate: config.URL,
+			URL:         resolvedURL,
+			Timeout:     config.Timeout,
+			MaxRetries:  config.MaxRetries,
+			RetryDelay:  config.RetryDelay,
+			Active:      config.URLConfig.IsActive,
+		}
+	}
+
+	authTypes := make([]string, 0)
+	for _, authType := range strings.Split(config.AuthType, ",") {
+		authType = strings.TrimSpace(strings.ToLower(authType))
+		if authType != "" && authType != "none" {
+			authTypes = append(authTypes, authType)
+		}
+	}
+	resp.Auth = &dto.RouteExplainAuth{
+		GatewayAdmin: config.IsAdmin,
+		Required:     !config.IsAdmin && config.AuthRequired,
+		Types:        authTypes,
+		APIKeyHeader: config.APIKeyHeader,
+	}
+
+	resp.Cache = &dto.RouteExplainCache{
+		Available: h.cacheService != nil && h.cacheService.IsEnabled(),
+	}
+	if resp.Cache.Available && resp.Upstream != nil && !config.Stream {
+		resp.Cache.Enabled = true
+		resp.Cache.TTL = int(h.cacheService.SuccessTTL(config).Seconds())
+	}
+
+	availability := routing.Availability(config, time.Now())
+	resp.Schedule = &dto.RouteExplainSchedule{
+		Available:           availability.Available,
+		Reason:              availability.Reason,
+		ActiveFrom:          config.ActiveFrom,
+		ActiveUntil:         config.ActiveUntil,
+		MaintenanceSchedule: config.MaintenanceSchedule,
+	}
+	if !availability.RetryAfter.IsZero() {
+		resp.Schedule.RetryAfter = &availability.RetryAfter
+	}
+
+	resp.RateLimit = &dto.RouteExplainRateLimit{Enabled: false}
+}
+
+// End Explain
+
+// Start Traffic Split
+
+// GetVariants reports a route's traffic split with per-variant status and latency as seen by this instance
+func (h *APIConfigHandler) GetVariants(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid ID", ""))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	config, status, err := h.integrasiService.GetByIDConfig(ctx, uint(id))
+	if err != nil {
+		c.JSON(status, constants.BuildErrorResponse("Get variants failed", err.Error()))
+		return
+	}
+
+	var snapshots map[string]traffic.Snapshot
+	if h.trafficStats != nil {
+		snapshots = h.trafficStats.Route(config.ID)
+	}
+
+	overrideHeader := config.SplitOverrideHeader
+	if overrideHeader == "" {
+		overrideHeader = traffic.DefaultOverrideHeader
+	}
+
+	// The primary upstream receives whatever weight the active variants leave, none while inactive
+	primaryWeight := 100
+	activeVariants := 0
+	for _, variant := range config.Variants {
+		if variant.URLConfig != nil && variant.URLConfig.IsActive {
+			primaryWeight -= variant.Weight
+			activeVariants++
+		}
+	}
+	if !config.URLConfig.IsActive && activeVariants > 0 {
+		primaryWeight = 0
+	}
+
+	resp := dto.RouteVariantsResponse{
+		ConfigID:            config.ID,
+		Path:                config.Path,
+		Method:              config.Method,
+		SplitKey:            config.SplitKey,
+		SplitOverrideHeader: overrideHeader,
+		Instance:            h.instanceID(),
+		Scope:               "instance",
+		Variants: []dto.RouteVariantStats{
+			variantStats(traffic.Primary, config.URLConfigID, primaryWeight, config.URLConfig.IsActive, snapshots[traffic.Primary]),
+		},
+	}
+	for _, variant := range config.Variants {
+		active := variant.URLConfig != nil && variant.URLConfig.IsActive
+		resp.Variants = append(resp.Variants, variantStats(variant.Name, variant.URLConfigID, variant.Weight, active, snapshots[variant.Name]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PromoteVariant makes a variant's upstream the route's own and ends the split
+func (h *APIConfigHandler) PromoteVariant(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid ID", ""))
+		return
+	}
+
+	var req dto.PromoteVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid request", err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	status, err := h.integrasiService.PromoteVariant(ctx, uint(id), req.Variant)
+	if err != nil {
+		c.JSON(status, constants.BuildErrorResponse("Promote failed", err.Error()))
+		return
+	}
+
+	logger.GetLogger().Info("Route variant promoted",
+		zap.Int("config_id", id),
+		zap.String("variant", req.Variant),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	h.refreshSplitRoute(ctx, uint(id))
+	c.JSON(status, constants.BuildSuccessResponse("Promote successful"))
+}
+
+// RollbackVariants removes every variant so the route's own upstream gets all traffic
+func (h *APIConfigHandler) RollbackVariants(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid ID", ""))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	status, err := h.integrasiService.RollbackVariants(ctx, uint(id))
+	if err != nil {
+		c.JSON(status, constants.BuildErrorResponse("Rollback failed", err.Error()))
+		return
+	}
+
+	logger.GetLogger().Info("Route variants rolled back",
+		zap.Int("config_id", id),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	h.refreshSplitRoute(ctx, uint(id))
+	c.JSON(status, constants.BuildSuccessResponse("Rollback successful"))
+}
+
+// refreshSplitRoute reloads a route after its split changed, drops its cached responses and stats
+// and notifies the other instances
+func (h *APIConfigHandler) refreshSplitRoute(ctx context.Context, id uint) {
+	if h.trafficStats != nil {
+		h.trafficStats.Reset(id)
+	}
+
+	config, _, err := h.integrasiService.GetByIDConfig(ctx, id)
+	if err != nil {
+		logger.GetLogger().Warn("Failed to fetch config for refresh after split change",
+			zap.Uint("config_id", id),
+			zap.Error(err),
+		)
+		return
+	}
+
+	go func() {
+		refreshCtx, refreshCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer refreshCancel()
+
+		if h.routeRefresher != nil {
+			if err := h.routeRefresher.RefreshSingle(refreshCtx, config.Path, config.Method); err != nil {
+				logger.GetLogger().Warn("Failed to refresh route registry after split change",
+					zap.String("path", config.Path),
+					zap.Error(err),
+				)
+			}
+		}
+		if h.cacheService != nil {
+			_ = h.cacheService.InvalidateCache(refreshCtx, config.Path)
+		}
+		if h.invalidationBus != nil {
+			_ = h.invalidationBus.PublishRouteChanged(refreshCtx, config.Path, config.Method, "", "")
+		}
+	}()
+}
+
+// variantStats converts a stats snapshot into its API representation
+func variantStats(name string, urlConfigID uint, weight int, active bool, snap traffic.Snapshot) dto.RouteVariantStats {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+	stats := dto.RouteVariantStats{
+		Name:         name,
+		URLConfigID:  urlConfigID,
+		Weight:       weight,
+		Active:       active,
+		Requests:     snap.Requests,
+		Errors:       snap.Errors,
+		ErrorRate:    snap.ErrorRate,
+		Statuses:     make(map[string]int64, len(snap.Statuses)),
+		LatencyAvgMs: ms(snap.LatencyAvg),
+		LatencyP50Ms: ms(snap.LatencyP50),
+		LatencyP95Ms: ms(snap.LatencyP95),
+		LatencyP99Ms: ms(snap.LatencyP99),
+		LatencyMaxMs: ms(snap.LatencyMax),
+	}
+	for code, count := range snap.Statuses {
+		stats.Statuses[strconv.Itoa(code)] = count
+	}
+	if !snap.Since.IsZero() {
+		stats.Since = &snap.Since
+	}
+	return stats
+}
+
+// End Traffic Split
+
+// Start Traffic Mirror
+
+// GetMirror reports a route's mirrored traffic with its latest mismatches and failures
+func (h *APIConfigHandler) GetMirror(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid ID", ""))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	config, status, err := h.integrasiService.GetByIDConfig(ctx, uint(id))
+	if err != nil {
+		c.JSON(status, constants.BuildErrorResponse("Get mirror failed", err.Error()))
+		return
+	}
+
+	var report mirror.Report
+	if h.shadow != nil {
+		report = h.shadow.Route(config.ID)
+	}
+
+	resp := dto.RouteMirrorResponse{
+		ConfigID:         config.ID,
+		Path:             config.Path,
+		Method:           config.Method,
+		Mirror:           config.Mirror,
+		Sent:             report.Sent,
+		Failed:           report.Failed,
+		Dropped:          report.Dropped,
+		Compared:         report.Compared,
+		StatusMismatches: report.StatusMismatches,
+		BodyMismatches:   report.BodyMismatches,
+		Recent:           make([]dto.RouteMirrorResult, 0, len(report.Recent)),
+	}
+	for _, result := range report.Recent {
+		resp.Recent = append(resp.Recent, dto.RouteMirrorResult{
+			At:            result.At,
+			PrimaryStatus: result.PrimaryStatus,
+			MirrorStatus:  result.MirrorStatus,
+			LatencyMs:     float64(result.Latency) / float64(time.Millisecond),
+			Error:         result.Error,
+			Differences:   result.Differences,
+		})
+	}
+	if !report.Since.IsZero() {
+		resp.Since = &report.Since
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// End Traffic Mirror
+
+// Start Hedge
+
+// GetHedge reports how often a route's slow requests were hedged and how often the second copy won
+func (h *APIConfigHandler) GetHedge(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid ID", ""))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	config, status, err := h.integrasiService.GetByIDConfig(ctx, uint(id))
+	if err != nil {
+		c.JSON(status, constants.BuildErrorResponse("Get hedge failed", err.Error()))
+		return
+	}
+
+	var snapshot hedge.Snapshot
+	if h.executor != nil {
+		snapshot = h.executor.HedgeStats(config.ID)
+	}
+
+	resp := dto.RouteHedgeResponse{
+		ConfigID:  config.ID,
+		Path:      config.Path,
+		Method:    config.Method,
+		Hedge:     config.Hedge,
+		Requests:  snapshot.Requests,
+		Hedged:    snapshot.Hedged,
+		HedgeWins: snapshot.HedgeWins,
+		Capped:    snapshot.Capped,
+		Samples:   snapshot.Samples,
+		DelayMs:   float64(snapshot.Delay) / float64(time.Millisecond),
+	}
+	if !snapshot.Since.IsZero() {
+		resp.Since = &snapshot.Since
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// End Hedge
+
+// Start Circuit Breaker
+
+// GetCircuitBreakers reports every circuit breaker of this instance with its state and window
+func (h *APIConfigHandler) GetCircuitBreakers(c *gin.Context) {
+	breakers := map[string]interface{}{}
+	if h.executor != nil {
+		breakers = h.executor.CircuitBreakers()
+	}
+	c.JSON(http.StatusOK, breakers)
+}
+
+// ControlCircuitBreaker forces a circuit breaker of this instance open or closed, or releases it
+// Other instances keep their own breakers, the response names the instance the override applies on
+func (h *APIConfigHandler) ControlCircuitBreaker(c *gin.Context) {
+	var req dto.CircuitBreakerControlRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Invalid request", err.Error()))
+		return
+	}
+	if h.executor == nil {
+		c.JSON(http.StatusNotFound, constants.BuildErrorResponse("Control failed", integrasi.ErrUnknownBreaker.Error()))
+		return
+	}
+
+	stats, err := h.executor.ControlBreaker(req.Name, req.Action)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, integrasi.ErrUnknownBreaker) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, constants.BuildErrorResponse("Control failed", err.Error()))
+		return
+	}
+
+	logger.GetLogger().Info("Circuit breaker controlled by operator",
+		zap.String("name", req.Name),
+		zap.String("action", req.Action),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, dto.CircuitBreakerControlResponse{
+		Instance: h.instanceID(),
+		Scope:    "instance",
+		Breaker:  stats,
+	})
+}
+
+// instanceID names this gateway instance, as stamped on its invalidation events when the bus is set up
+func (h *APIConfigHandler) instanceID() string {
+	if h.invalidationBus != nil {
+		return h.invalidationBus.InstanceID()
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "gateway"
+	}
+	return host
+}
+
+// End Circuit Breaker
+
 // Start Get By Id
 func (h *APIConfigHandler) GetByIDConfig(c *gin.Context) {
 	clientIP := c.ClientIP()
@@ -1470,6 +2161,11 @@ func (h *APIConfigHandler) UpdateURLConfig(c *gin.Context) {
 					zap.Uint("url_config_id", uint(id)),
 				)
 			}
+
+			// Notify other instances
+			if h.invalidationBus != nil {
+				_ = h.invalidationBus.PublishFullRefresh(refreshCtx)
+			}
 		}()
 	}
 
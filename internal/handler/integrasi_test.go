@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/routing"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	logger.Logger = zap.NewNop()
+}
+
+// upstreamWithSecrets returns a URL config carrying every upstream credential
+func upstreamWithSecrets(id uint, url string) *dto.URLConfigResponse {
+	return &dto.URLConfigResponse{
+		ID:           id,
+		Protocol:     "http",
+		URL:          url,
+		IsActive:     true,
+		AuthType:     "basic",
+		AuthUsername: "gateway",
+		AuthPassword: "secret-password",
+		AuthToken:    "secret-token",
+		AuthKey:      "secret-key",
+		AuthValue:    "secret-value",
+	}
+}
+
+func TestExplainRoute_RedactsSecrets(t *testing.T) {
+	config := &dto.APIConfigResponse{
+		ID:             1,
+		Path:           "/orders/{id}",
+		Method:         http.MethodGet,
+		Protocol:       "http",
+		URL:            "http://orders.internal/orders/{{id}}",
+		URLConfig:      *upstreamWithSecrets(1, "http://orders.internal"),
+		AuthType:       "jwt,basic,apikey",
+		AuthRequired:   true,
+		JWTSecretKey:   "secret-jwt",
+		BasicAuthUsers: []dto.BasicAuthUser{{Username: "ops", PasswordHash: "secret-hash"}},
+		APIKeys:        []dto.APIKey{{Key: "secret-api-key", Name: "partner", Active: true}},
+		Variants:       []dto.RouteVariant{{Name: "canary", URLConfigID: 2, Weight: 10, URLConfig: upstreamWithSecrets(2, "http://canary.internal")}},
+		Mirror:         &dto.RouteMirror{URLConfigID: 3, URLConfig: upstreamWithSecrets(3, "http://shadow.internal")},
+		AuthGRPCConfig: upstreamWithSecrets(4, "auth.internal:443"),
+	}
+
+	registry := routing.NewRouteRegistry(zap.NewNop())
+	if err := registry.AddRoute(config); err != nil {
+		t.Fatalf("Failed to add route: %v", err)
+	}
+	h := NewAPIConfigHandler(nil, routing.NewRefresher(registry, nil, zap.NewNop()), nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/routes/explain?method=GET&path=/orders/42", nil)
+	h.ExplainRoute(c)
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, `"matched":true`) {
+		t.Fatalf("Expected the route to be explained, got %d %s", w.Code, body)
+	}
+	if strings.Contains(body, "secret") {
+		t.Errorf("Expected no secrets in the explain output, got %s", body)
+	}
+	if !strings.Contains(body, "http://canary.internal") || !strings.Contains(body, "http://shadow.internal") {
+		t.Errorf("Expected the variant and mirror upstreams to be reported, got %s", body)
+	}
+
+	// The matched config itself is left untouched, the executor still needs the credentials
+	if config.URLConfig.AuthPassword == "" || config.JWTSecretKey == "" {
+		t.Error("Expected redaction to work on a copy")
+	}
+}
+
+func TestExplainRoute_HeaderPredicatesFromQuery(t *testing.T) {
+	registry := routing.NewRouteRegistry(zap.NewNop())
+	if err := registry.AddRoute(&dto.APIConfigResponse{
+		ID:           1,
+		Path:         "/balance",
+		Method:       http.MethodGet,
+		Protocol:     "http",
+		URL:          "http://balance.internal/v2/balance",
+		URLConfig:    dto.URLConfigResponse{ID: 1, URL: "http://balance.internal", IsActive: true},
+		MatchHeaders: map[string]string{"X-API-Version": "2"},
+	}); err != nil {
+		t.Fatalf("Failed to add route: %v", err)
+	}
+	h := NewAPIConfigHandler(nil, routing.NewRefresher(registry, nil, zap.NewNop()), nil, nil, nil, nil, nil)
+
+	explain := func(target string) string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		// The admin's own headers never take part in matching
+		c.Request.Header.Set("X-API-Version", "2")
+		h.ExplainRoute(c)
+		return w.Body.String()
+	}
+
+	if body := explain("/api/v1/routes/explain?method=GET&path=/balance"); !strings.Contains(body, `"matched":false`) {
+		t.Errorf("Expected no match without a header parameter, got %s", body)
+	}
+	if body := explain("/api/v1/routes/explain?method=GET&path=/balance&header=X-API-Version:%202"); !strings.Contains(body, `"matched":true`) {
+		t.Errorf("Expected a match with the header parameter, got %s", body)
+	}
+}
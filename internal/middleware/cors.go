@@ -144,6 +144,12 @@ func CORS(db *gorm.DB) gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400") // 24 hours
 
 		if c.Request.Method == "OPTIONS" {
+			// Dynamic routes answer OPTIONS themselves with an Allow header (or forward it upstream)
+			c.Next()
+			if c.Writer.Written() {
+				return
+			}
+
 			logger.GetLogger().Debug("Middleware: CORS preflight request handled",
 				zap.String("client_ip", clientIP),
 				zap.String("origin", origin),
@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/internal/service"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
+	"github.com/Payphone-Digital/gateway/pkg/routing"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// methodRecorder is an upstream remembering the method of every request it served
+type methodRecorder struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (r *methodRecorder) seen() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.methods...)
+}
+
+func (r *methodRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	r.methods = append(r.methods, req.Method)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", "11")
+	if req.Method != http.MethodHead {
+		w.Write([]byte(`{"ok":true}`))
+	}
+}
+
+// newProxyRouter proxies configs to upstream through the dynamic URI middleware without Redis
+func newProxyRouter(t *testing.T, upstream string, configs ...*dto.APIConfigResponse) *gin.Engine {
+	t.Helper()
+
+	router := gin.New()
+	router.Use(newProxyHandler(t, upstream, configs...))
+	return router
+}
+
+// newProxyHandler returns the dynamic URI middleware proxying configs to upstream
+func newProxyHandler(t *testing.T, upstream string, configs ...*dto.APIConfigResponse) gin.HandlerFunc {
+	t.Helper()
+
+	registry := routing.NewRouteRegistry(zap.NewNop())
+	for _, config := range configs {
+		config.Protocol = "http"
+		config.URL = upstream + "/resource"
+		config.Timeout = 5
+		config.URLConfigID = 1
+		config.URLConfig = dto.URLConfigResponse{ID: 1, URL: upstream, Protocol: "http", IsActive: true}
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route %s: %v", config.Path, err)
+		}
+	}
+
+	executor := integrasi.NewExecutor(integrasi.DefaultExecutorConfig(), nil)
+	t.Cleanup(func() { executor.Close() })
+
+	m := NewDynamicURIMiddleware(registry, service.NewCacheService(nil), nil, nil, nil, executor, nil)
+	return m.HandleDynamicURI()
+}
+
+func TestDynamicURI_AutomaticOptions(t *testing.T) {
+	router := newDirectRouter(t, nil,
+		&dto.APIConfigResponse{ID: 1, Path: "/orders", Method: http.MethodGet, RouteType: dto.RouteTypeStatic, ResponseStatus: http.StatusOK},
+		&dto.APIConfigResponse{ID: 2, Path: "/orders", Method: http.MethodPost, RouteType: dto.RouteTypeStatic, ResponseStatus: http.StatusCreated},
+	)
+
+	w := serve(router, httptest.NewRequest(http.MethodOptions, "/orders", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d %s", w.Code, w.Body.String())
+	}
+	allow := w.Header().Get("Allow")
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodOptions} {
+		if !strings.Contains(allow, method) {
+			t.Errorf("Expected Allow to list %s, got %q", method, allow)
+		}
+	}
+	if strings.Contains(allow, http.MethodDelete) {
+		t.Errorf("Expected Allow not to list DELETE, got %q", allow)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != allow {
+		t.Errorf("Expected Access-Control-Allow-Methods %q, got %q", allow, got)
+	}
+}
+
+func TestDynamicURI_HeadForwardedAsHead(t *testing.T) {
+	upstream := &methodRecorder{}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	router := newProxyRouter(t, server.URL, &dto.APIConfigResponse{
+		ID:           1,
+		Path:         "/balance",
+		Method:       http.MethodGet,
+		Manipulation: `{"wrapped": "{{ok}}"}`,
+	})
+
+	w := serve(router, httptest.NewRequest(http.MethodHead, "/balance", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if got := upstream.seen(); len(got) != 1 || got[0] != http.MethodHead {
+		t.Errorf("Expected the upstream to be sent HEAD, got %v", got)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no body, got %q", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Length"); got != "11" {
+		t.Errorf("Expected the upstream Content-Length 11, got %q", got)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Expected the upstream Content-Type, got %q", got)
+	}
+}
+
+func TestDynamicURI_AnyForwardsRequestMethod(t *testing.T) {
+	upstream := &methodRecorder{}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	router := newProxyRouter(t, server.URL, &dto.APIConfigResponse{ID: 1, Path: "/anything", Method: routing.MethodAny})
+
+	methods := []string{http.MethodGet, http.MethodPatch, http.MethodDelete}
+	for _, method := range methods {
+		w := serve(router, httptest.NewRequest(method, "/anything", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for %s, got %d %s", method, w.Code, w.Body.String())
+		}
+		if w.Body.String() != `{"ok":true}` {
+			t.Errorf("Expected the upstream body for %s, got %q", method, w.Body.String())
+		}
+	}
+
+	got := upstream.seen()
+	if len(got) != len(methods) {
+		t.Fatalf("Expected %d upstream requests, got %v", len(methods), got)
+	}
+	for i, method := range methods {
+		if got[i] != method {
+			t.Errorf("Expected the upstream to be sent %s, got %s", method, got[i])
+		}
+	}
+}
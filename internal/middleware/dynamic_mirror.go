@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/codec"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/mirror"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// startMirror sends a copy of the outbound request to the route's mirror upstream when the request is sampled
+// The copy is resolved from c right away and sent in the background through the executor without retries, its
+// response never reaches the client. Returns nil when nothing is mirrored, hand the primary response to the result otherwise
+// Multipart uploads aren't mirrored, their file parts don't outlive the request
+func (m *DynamicURIMiddleware) startMirror(c *gin.Context, config *dto.APIConfigResponse) *mirror.Pending {
+	if m.shadow == nil || config.Mirror == nil || config.Mirror.URLConfig == nil || !config.Mirror.URLConfig.IsActive {
+		return nil
+	}
+	if !mirror.Sampled(config.Mirror.Percent) {
+		return nil
+	}
+	// File parts are read from the upload's temporary files, which are gone once the client got its response
+	if codec.Kind(c.GetHeader("Content-Type")) == codec.Multipart {
+		logger.GetLogger().Debug("Multipart request not mirrored",
+			zap.String("slug", config.Path),
+		)
+		return nil
+	}
+
+	mirrored := *config
+	mirrored.URLConfigID = config.Mirror.URLConfigID
+	mirrored.URLConfig = *config.Mirror.URLConfig
+	mirrored.URL = config.Mirror.URL
+	mirrored.Protocol = config.Mirror.URLConfig.Protocol
+	mirrored.MaxRetries = 0
+	mirrored.Hedge = nil
+
+	logger.GetLogger().Debug("Mirroring dynamic URI request",
+		zap.String("slug", config.Path),
+		zap.String("mirror_url", mirrored.URL),
+		zap.Bool("compare", config.Mirror.Compare),
+	)
+
+	prepared := m.executor.Prepare(&mirrored, c)
+	// Only bodies and statuses are compared, the mirror's headers are dropped with its response
+	send := func(ctx context.Context) ([]byte, int, error) {
+		body, status, _, err := prepared(ctx)
+		return body, status, err
+	}
+	return m.shadow.Start(config.ID, send, config.Mirror.Compare, config.Mirror.IgnoreFields)
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/mirror"
+	"github.com/gin-gonic/gin"
+)
+
+func TestStartMirror_SkipsMultipart(t *testing.T) {
+	m := NewDynamicURIMiddleware(nil, nil, nil, nil, mirror.NewShadow(1, time.Second), nil, nil)
+	config := &dto.APIConfigResponse{
+		ID:     1,
+		Path:   "/documents",
+		Method: http.MethodPost,
+		Mirror: &dto.RouteMirror{
+			URLConfigID: 2,
+			Percent:     100,
+			URLConfig:   &dto.URLConfigResponse{ID: 2, URL: "http://shadow.internal", IsActive: true},
+		},
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader("--x\r\n\r\n--x--\r\n"))
+	c.Request.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+
+	// The executor is nil, mirroring the request would panic
+	if pending := m.startMirror(c, config); pending != nil {
+		t.Error("Expected multipart uploads not to be mirrored")
+	}
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/constants"
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MockResponseHeader selects a mock response by name or status, and names the one served
+const MockResponseHeader = "X-Mock-Response"
+
+// serveDirect answers static, redirect and mock routes without calling the upstream
+func (m *DynamicURIMiddleware) serveDirect(c *gin.Context, config *dto.APIConfigResponse) {
+	switch config.RouteType {
+	case dto.RouteTypeStatic:
+		m.serveStatic(c, config)
+	case dto.RouteTypeRedirect:
+		m.serveRedirect(c, config)
+	case dto.RouteTypeMock:
+		m.serveMock(c, config)
+	default:
+		logger.GetLogger().Error("Unknown route type for dynamic URI",
+			zap.String("slug", config.Path),
+			zap.String("route_type", config.RouteType),
+		)
+		c.JSON(http.StatusInternalServerError, constants.BuildErrorResponse("Internal Server Error", "Unknown route type "+config.RouteType))
+	}
+}
+
+// serveStatic writes the configured status, headers and body after resolving {{var}} placeholders
+func (m *DynamicURIMiddleware) serveStatic(c *gin.Context, config *dto.APIConfigResponse) {
+	vars := integrasi.DirectTemplateVars(config, c)
+
+	headers := make(map[string]string, len(config.ResponseHeaders))
+	for key, value := range config.ResponseHeaders {
+		headers[key] = integrasi.ResolveText(value, vars, c)
+	}
+
+	var body interface{}
+	if config.ResponseBody != nil {
+		body = integrasi.ResolveBody(config.ResponseBody, vars, c)
+	}
+
+	status := config.ResponseStatus
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	logger.GetLogger().Info("Serving static response for dynamic URI",
+		zap.String("slug", config.Path),
+		zap.Int("status", status),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	writeDirectResponse(c, status, headers, body)
+}
+
+// serveRedirect redirects to the configured location after resolving {{var}} placeholders
+// Locations built from request values that would leave the gateway for another host are refused
+func (m *DynamicURIMiddleware) serveRedirect(c *gin.Context, config *dto.APIConfigResponse) {
+	location := integrasi.ResolveText(config.RedirectLocation, integrasi.DirectTemplateVars(config, c), c)
+	if !m.redirectAllowed(config.RedirectLocation, location) {
+		logger.GetLogger().Warn("Redirect location not allowed",
+			zap.String("slug", config.Path),
+			zap.String("location", location),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Bad Request", "Redirect location not allowed"))
+		return
+	}
+
+	status := config.RedirectStatus
+	if status == 0 {
+		status = http.StatusFound
+	}
+
+	logger.GetLogger().Info("Redirecting dynamic URI",
+		zap.String("slug", config.Path),
+		zap.Int("status", status),
+		zap.String("location", location),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.Redirect(status, location)
+}
+
+// redirectAllowed reports whether location, resolved from template, is a safe redirect target
+// Locations without placeholders are taken as configured. Templated ones must be relative paths, or
+// http(s) URLs on the template's own literal host or one of the allowed redirect hosts
+func (m *DynamicURIMiddleware) redirectAllowed(template, location string) bool {
+	if !strings.Contains(template, "{{") {
+		return true
+	}
+
+	target, err := url.Parse(location)
+	if err != nil || strings.Contains(location, "\\") || strings.TrimSpace(location) != location {
+		return false
+	}
+	if target.Scheme == "" && target.Host == "" {
+		// "//host" is relative to the scheme only
+		return !strings.HasPrefix(location, "//")
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return false
+	}
+
+	if configured, err := url.Parse(template); err == nil && configured.Host != "" && !strings.Contains(configured.Host, "{{") {
+		if strings.EqualFold(configured.Host, target.Host) {
+			return true
+		}
+	}
+	for _, host := range m.redirectHosts {
+		if strings.EqualFold(host, target.Host) || strings.EqualFold(host, target.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveMock returns the mock response named by the X-Mock-Response header, the first one by default
+func (m *DynamicURIMiddleware) serveMock(c *gin.Context, config *dto.APIConfigResponse) {
+	if len(config.MockResponses) == 0 {
+		logger.GetLogger().Error("Mock route has no responses",
+			zap.String("slug", config.Path),
+		)
+		c.JSON(http.StatusInternalServerError, constants.BuildErrorResponse("Internal Server Error", "Mock route has no responses"))
+		return
+	}
+
+	mock := config.MockResponses[0]
+	if selector := strings.TrimSpace(c.GetHeader(MockResponseHeader)); selector != "" {
+		found := false
+		for _, candidate := range config.MockResponses {
+			if candidate.Name == selector || strconv.Itoa(candidate.Status) == selector {
+				mock, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			logger.GetLogger().Warn("Unknown mock response requested",
+				zap.String("slug", config.Path),
+				zap.String("selector", selector),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.JSON(http.StatusBadRequest, constants.BuildErrorResponse("Bad Request", "Unknown mock response "+selector))
+			return
+		}
+	}
+
+	status := mock.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	logger.GetLogger().Info("Serving mock response for dynamic URI",
+		zap.String("slug", config.Path),
+		zap.String("mock", mock.Name),
+		zap.Int("status", status),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.Header(MockResponseHeader, mock.Name)
+	writeDirectResponse(c, status, mock.Headers, mock.Body)
+}
+
+// writeDirectResponse writes headers then body: JSON for structured values, as is for strings
+// A Content-Type in headers takes precedence over the default one
+func writeDirectResponse(c *gin.Context, status int, headers map[string]string, body interface{}) {
+	for key, value := range headers {
+		c.Header(key, value)
+	}
+
+	switch b := body.(type) {
+	case nil:
+		c.Status(status)
+	case string:
+		c.Data(status, "text/plain; charset=utf-8", []byte(b))
+	default:
+		c.JSON(status, b)
+	}
+}
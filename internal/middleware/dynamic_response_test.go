@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/routing"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	logger.Logger = zap.NewNop()
+}
+
+// newDirectRouter serves configs through the dynamic URI middleware, unmatched requests get a 404
+func newDirectRouter(t *testing.T, redirectHosts []string, configs ...*dto.APIConfigResponse) *gin.Engine {
+	t.Helper()
+
+	registry := routing.NewRouteRegistry(zap.NewNop())
+	for _, config := range configs {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route %s: %v", config.Path, err)
+		}
+	}
+
+	m := NewDynamicURIMiddleware(registry, nil, nil, nil, nil, nil, redirectHosts)
+	router := gin.New()
+	router.Use(m.HandleDynamicURI())
+	return router
+}
+
+func serve(router *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestDynamicURI_StaticRoute(t *testing.T) {
+	// No URL config at all, static routes never call an upstream
+	router := newDirectRouter(t, nil, &dto.APIConfigResponse{
+		ID:              1,
+		Path:            "/status/{region}",
+		Method:          http.MethodGet,
+		RouteType:       dto.RouteTypeStatic,
+		ResponseStatus:  http.StatusAccepted,
+		ResponseHeaders: map[string]string{"X-Region": "{{region}}"},
+		ResponseBody:    map[string]interface{}{"region": "{{region}}", "ok": true},
+	})
+
+	w := serve(router, httptest.NewRequest(http.MethodGet, "/status/eu", nil))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("X-Region"); got != "eu" {
+		t.Errorf("Expected X-Region eu, got %q", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got %s", w.Body.String())
+	}
+	if body["region"] != "eu" || body["ok"] != true {
+		t.Errorf("Expected the resolved body, got %v", body)
+	}
+}
+
+func TestDynamicURI_StaticRouteWithInactiveURLConfig(t *testing.T) {
+	router := newDirectRouter(t, nil, &dto.APIConfigResponse{
+		ID:           1,
+		Path:         "/ping",
+		Method:       http.MethodGet,
+		URLConfigID:  7,
+		URLConfig:    dto.URLConfigResponse{ID: 7, URL: "http://unused.internal", IsActive: false},
+		RouteType:    dto.RouteTypeStatic,
+		ResponseBody: "pong",
+	})
+
+	w := serve(router, httptest.NewRequest(http.MethodGet, "/ping", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "pong" {
+		t.Errorf("Expected the static response despite the inactive URL config, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDynamicURI_RedirectRoute(t *testing.T) {
+	next := map[string]dto.Variable{"next": {DataType: "string"}}
+	router := newDirectRouter(t, []string{"partner.example.com"},
+		&dto.APIConfigResponse{
+			ID:               1,
+			Path:             "/old/{id}",
+			Method:           http.MethodGet,
+			RouteType:        dto.RouteTypeRedirect,
+			RedirectStatus:   http.StatusMovedPermanently,
+			RedirectLocation: "https://new.example.com/items/{{id}}",
+		},
+		&dto.APIConfigResponse{
+			ID:               2,
+			Path:             "/login",
+			Method:           http.MethodGet,
+			Variables:        next,
+			RouteType:        dto.RouteTypeRedirect,
+			RedirectLocation: "{{next}}",
+		},
+		&dto.APIConfigResponse{
+			ID:               3,
+			Path:             "/static-away",
+			Method:           http.MethodGet,
+			RouteType:        dto.RouteTypeRedirect,
+			RedirectLocation: "https://elsewhere.example.org/",
+		},
+	)
+
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		location string
+	}{
+		{"literal host of the template", "/old/42", http.StatusMovedPermanently, "https://new.example.com/items/42"},
+		{"relative path", "/login?next=/account", http.StatusFound, "/account"},
+		{"allowed host", "/login?next=https://partner.example.com/home", http.StatusFound, "https://partner.example.com/home"},
+		{"location without placeholders", "/static-away", http.StatusFound, "https://elsewhere.example.org/"},
+		{"other host", "/login?next=https://evil.example.net/", http.StatusBadRequest, ""},
+		{"scheme relative", "/login?next=//evil.example.net/", http.StatusBadRequest, ""},
+		{"backslash", "/login?next=/%5Cevil.example.net/", http.StatusBadRequest, ""},
+		{"javascript", "/login?next=javascript:alert(1)", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected %d, got %d %s", tt.status, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Location"); got != tt.location {
+				t.Errorf("Expected Location %q, got %q", tt.location, got)
+			}
+		})
+	}
+}
+
+func TestDynamicURI_MockRoute(t *testing.T) {
+	router := newDirectRouter(t, nil, &dto.APIConfigResponse{
+		ID:        1,
+		Path:      "/payments",
+		Method:    http.MethodPost,
+		RouteType: dto.RouteTypeMock,
+		MockResponses: []dto.MockResponse{
+			{Name: "ok", Status: http.StatusCreated, Body: map[string]interface{}{"status": "paid"}},
+			{Name: "declined", Status: http.StatusPaymentRequired, Headers: map[string]string{"X-Reason": "funds"}, Body: "declined"},
+		},
+	})
+
+	tests := []struct {
+		name     string
+		selector string
+		status   int
+		mock     string
+		body     string
+	}{
+		{"first by default", "", http.StatusCreated, "ok", `{"status":"paid"}`},
+		{"by name", "declined", http.StatusPaymentRequired, "declined", "declined"},
+		{"by status", "402", http.StatusPaymentRequired, "declined", "declined"},
+		{"unknown", "missing", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/payments", nil)
+			if tt.selector != "" {
+				req.Header.Set(MockResponseHeader, tt.selector)
+			}
+			w := serve(router, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected %d, got %d %s", tt.status, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get(MockResponseHeader); got != tt.mock {
+				t.Errorf("Expected %s %q, got %q", MockResponseHeader, tt.mock, got)
+			}
+			if tt.body != "" && w.Body.String() != tt.body {
+				t.Errorf("Expected body %s, got %s", tt.body, w.Body.String())
+			}
+		})
+	}
+}
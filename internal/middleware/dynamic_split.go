@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/traffic"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// routeVariantKey is the gin context key holding the variant serving the request
+const routeVariantKey = "route_variant"
+
+// selectVariant picks the upstream variant serving the request and returns the config to forward with
+// Variants whose URL config is inactive get no traffic, and neither does the route's own one when inactive while a
+// variant can take its share. Routes without variants are returned unchanged
+func (m *DynamicURIMiddleware) selectVariant(c *gin.Context, config *dto.APIConfigResponse) *dto.APIConfigResponse {
+	if len(config.Variants) == 0 {
+		return config
+	}
+
+	candidates := make([]traffic.Variant, 0, len(config.Variants))
+	for _, variant := range config.Variants {
+		if variant.URLConfig != nil && variant.URLConfig.IsActive {
+			candidates = append(candidates, traffic.Variant{Name: variant.Name, Weight: variant.Weight})
+		}
+	}
+	if !config.URLConfig.IsActive && len(candidates) > 0 {
+		candidates = traffic.WithoutPrimary(candidates)
+	}
+
+	overrideHeader := config.SplitOverrideHeader
+	if overrideHeader == "" {
+		overrideHeader = traffic.DefaultOverrideHeader
+	}
+	override := strings.TrimSpace(c.GetHeader(overrideHeader))
+
+	name := traffic.Pick(candidates, traffic.Seed(config.ID), integrasi.RequestKey(c, config.SplitKey), override)
+	c.Set(routeVariantKey, name)
+	c.Header(traffic.DefaultOverrideHeader, name)
+
+	logger.GetLogger().Debug("Traffic split variant selected",
+		zap.String("slug", config.Path),
+		zap.String("variant", name),
+		zap.String("override", override),
+	)
+
+	if name == traffic.Primary {
+		return config
+	}
+
+	for _, variant := range config.Variants {
+		if variant.Name != name {
+			continue
+		}
+		routed := *config
+		routed.URLConfigID = variant.URLConfigID
+		routed.URLConfig = *variant.URLConfig
+		routed.URL = variant.URL
+		routed.Protocol = variant.URLConfig.Protocol
+		return &routed
+	}
+	return config
+}
+
+// recordVariant adds an upstream call to the stats of the variant serving the request
+func (m *DynamicURIMiddleware) recordVariant(c *gin.Context, config *dto.APIConfigResponse, status int, latency time.Duration) {
+	if m.trafficStats == nil {
+		return
+	}
+	if variant := c.GetString(routeVariantKey); variant != "" {
+		m.trafficStats.Record(config.ID, variant, status, latency)
+	}
+}
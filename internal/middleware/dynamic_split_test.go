@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/traffic"
+	"github.com/gin-gonic/gin"
+)
+
+func TestSelectVariant_InactivePrimary(t *testing.T) {
+	m := NewDynamicURIMiddleware(nil, nil, nil, nil, nil, nil, nil)
+	config := &dto.APIConfigResponse{
+		ID:          1,
+		Path:        "/orders",
+		Method:      http.MethodGet,
+		URLConfigID: 1,
+		URLConfig:   dto.URLConfigResponse{ID: 1, URL: "http://primary.internal", IsActive: false},
+		SplitKey:    "header:X-User-ID",
+		Variants: []dto.RouteVariant{
+			{Name: "v2", URLConfigID: 2, Weight: 5, URL: "http://v2.internal/orders", URLConfig: &dto.URLConfigResponse{ID: 2, URL: "http://v2.internal", IsActive: true}},
+			{Name: "v3", URLConfigID: 3, Weight: 5, URL: "http://v3.internal/orders", URLConfig: &dto.URLConfigResponse{ID: 3, URL: "http://v3.internal", IsActive: false}},
+		},
+	}
+
+	for i := 0; i < 100; i++ {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+		c.Request.Header.Set("X-User-ID", fmt.Sprintf("user-%d", i))
+
+		routed := m.selectVariant(c, config)
+		if routed.URLConfigID != 2 || !routed.URLConfig.IsActive {
+			t.Fatalf("Expected the only active upstream v2, got url_config_id %d (variant %s)", routed.URLConfigID, c.GetString(routeVariantKey))
+		}
+	}
+
+	// An explicit override is still honored
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+	c.Request.Header.Set(traffic.DefaultOverrideHeader, traffic.Primary)
+	if routed := m.selectVariant(c, config); routed.URLConfigID != 1 {
+		t.Errorf("Expected the primary when forced, got url_config_id %d", routed.URLConfigID)
+	}
+}
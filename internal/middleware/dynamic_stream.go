@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/constants"
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// streamBufferSize is the most a streamed body is held before it's flushed to the client
+const streamBufferSize = 32 * 1024
+
+// processStream copies the request body to the upstream and its response back to the client as they come,
+// with the upstream's status, headers and trailers as its header policy passes them on. Nothing is cached,
+// manipulated or mirrored
+func (m *DynamicURIMiddleware) processStream(c *gin.Context, config *dto.APIConfigResponse) {
+	clientIP := c.ClientIP()
+
+	start := time.Now()
+	resp, err := m.executor.Stream(c.Request.Context(), config, c)
+	if err != nil {
+		m.recordVariant(c, config, 0, time.Since(start))
+		logger.GetLogger().Error("Dynamic URI streamed request failed",
+			zap.String("slug", config.Path),
+			zap.String("method", config.Method),
+			zap.String("client_ip", clientIP),
+			zap.Error(err),
+		)
+
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			c.JSON(http.StatusGatewayTimeout, constants.BuildErrorResponse("Gateway Timeout", "Upstream didn't respond in time"))
+		case errors.Is(err, circuit.ErrCircuitOpen) || errors.Is(err, circuit.ErrTooManyRequests) || errors.Is(err, balancer.ErrNoHealthyTarget):
+			c.JSON(http.StatusServiceUnavailable, constants.BuildErrorResponse("Service Unavailable", "Upstream is temporarily unavailable"))
+		default:
+			c.JSON(http.StatusBadGateway, constants.BuildErrorResponse("Bad Gateway", err.Error()))
+		}
+		return
+	}
+	defer resp.Body.Close()
+	m.recordVariant(c, config, resp.StatusCode, time.Since(start))
+
+	writeStreamHeaders(c, resp.Header)
+	c.Status(resp.StatusCode)
+	c.Writer.WriteHeaderNow()
+
+	written, err := copyFlushing(c.Writer, resp.Body)
+	if err != nil {
+		// Headers are out, all that's left is to cut the response short
+		logger.GetLogger().Warn("Dynamic URI stream interrupted",
+			zap.String("slug", config.Path),
+			zap.Int("response_status", resp.StatusCode),
+			zap.Int64("bytes_written", written),
+			zap.String("client_ip", clientIP),
+			zap.Error(err),
+		)
+		return
+	}
+
+	// Trailers are only known now the body is read, they go out as trailers of the chunked response
+	for name, values := range m.executor.StreamTrailers(config, resp) {
+		c.Writer.Header()[name] = values
+	}
+
+	logger.GetLogger().Info("Dynamic URI streamed request completed",
+		zap.String("slug", config.Path),
+		zap.String("method", config.Method),
+		zap.Int("response_status", resp.StatusCode),
+		zap.Int64("response_size", written),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("client_ip", clientIP),
+	)
+}
+
+// copyFlushing copies src to w, flushing after every read so the client gets the body as the upstream sends it
+func copyFlushing(w gin.ResponseWriter, src io.Reader) (int64, error) {
+	buf := make([]byte, streamBufferSize)
+	var written int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			wn, werr := w.Write(buf[:n])
+			written += int64(wn)
+			if werr != nil {
+				return written, werr
+			}
+			w.Flush()
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// writeStreamHeaders passes the upstream's headers on like writeUpstreamHeaders: the ones the gateway
+// already set, such as CORS, keep the gateway's value. Content-Length is kept, the body is copied as sent
+func writeStreamHeaders(c *gin.Context, header http.Header) {
+	upstream := make(http.Header, len(header))
+	integrasi.CopyResponseHeaders(upstream, header)
+
+	own := c.Writer.Header()
+	for name, values := range upstream {
+		if len(own[name]) > 0 {
+			continue
+		}
+		own[name] = values
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/gin-gonic/gin"
+)
+
+func TestDynamicURI_StreamKeepsGatewayHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("X-Upstream", "1")
+		w.Write([]byte("chunk"))
+	}))
+	defer server.Close()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		// Stands in for the CORS middleware running first
+		c.Header("Access-Control-Allow-Origin", "https://app.example.com")
+		c.Next()
+	})
+	router.Use(newProxyHandler(t, server.URL, &dto.APIConfigResponse{
+		ID:     1,
+		Path:   "/events",
+		Method: http.MethodGet,
+		Stream: true,
+	}))
+
+	w := serve(router, httptest.NewRequest(http.MethodGet, "/events", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "chunk" {
+		t.Fatalf("Expected the streamed upstream body, got %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Values("Access-Control-Allow-Origin"); len(got) != 1 || got[0] != "https://app.example.com" {
+		t.Errorf("Expected the gateway's CORS origin to be kept, got %v", got)
+	}
+	if got := w.Header().Get("X-Upstream"); got != "1" {
+		t.Errorf("Expected the upstream's own headers to pass, got %q", got)
+	}
+}
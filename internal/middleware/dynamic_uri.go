@@ -3,11 +3,14 @@ package middleware
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"io"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,9 +19,13 @@ import (
 	"github.com/Payphone-Digital/gateway/internal/constants"
 	"github.com/Payphone-Digital/gateway/internal/dto"
 	"github.com/Payphone-Digital/gateway/internal/service"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
 	"github.com/Payphone-Digital/gateway/pkg/integrasi"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/mirror"
 	"github.com/Payphone-Digital/gateway/pkg/routing"
+	"github.com/Payphone-Digital/gateway/pkg/traffic"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -28,14 +35,26 @@ type DynamicURIMiddleware struct {
 	registry      *routing.RouteRegistry
 	cacheService  *service.CacheService
 	jwtMiddleware *JWTMiddleware
+	trafficStats  *traffic.Stats
+	shadow        *mirror.Shadow
+	executor      *integrasi.Executor
+	redirectHosts []string
 }
 
 // NewDynamicURIMiddleware creates a new dynamic URI middleware
-func NewDynamicURIMiddleware(registry *routing.RouteRegistry, cacheService *service.CacheService, jwtMiddleware *JWTMiddleware) *DynamicURIMiddleware {
+// trafficStats is optional - if nil, per-variant stats of split routes are not recorded
+// shadow is optional - if nil, requests of mirrored routes are not mirrored
+// executor sends every proxied request through pooled clients and per-upstream circuit breakers
+// redirectHosts are the hosts redirect locations built from request values may point to
+func NewDynamicURIMiddleware(registry *routing.RouteRegistry, cacheService *service.CacheService, jwtMiddleware *JWTMiddleware, trafficStats *traffic.Stats, shadow *mirror.Shadow, executor *integrasi.Executor, redirectHosts []string) *DynamicURIMiddleware {
 	return &DynamicURIMiddleware{
 		registry:      registry,
 		cacheService:  cacheService,
 		jwtMiddleware: jwtMiddleware,
+		trafficStats:  trafficStats,
+		shadow:        shadow,
+		executor:      executor,
+		redirectHosts: redirectHosts,
 	}
 }
 
@@ -52,36 +71,33 @@ func (m *DynamicURIMiddleware) HandleDynamicURI() gin.HandlerFunc {
 			zap.String("client_ip", clientIP),
 		)
 
-		// Skip if this is an API management route (system routes)
-		// Only skip /api/v1/ (management APIs) and /api/health (health checks)
-		// Access to custom /api/ routes (e.g., /api/cek/object) should be allowed
-		if strings.HasPrefix(requestPath, "/api/v1/") || strings.HasPrefix(requestPath, "/api/health") || strings.HasPrefix(requestPath, "/health") {
+		// Skip the gateway's own routes (reserved prefixes such as /api/v1/ management APIs and health checks)
+		// Access to custom /api/ routes (e.g., /api/cek/object) is still allowed
+		if m.registry.IsReserved(requestPath) {
 			c.Next()
 			return
 		}
 
-		// Try to find API config in route registry (O(k) lookup)
-		config, uriParams, err := m.registry.Match(requestPath, requestMethod)
-		
-		// Fallback: If not found and path starts with /api/, try stripping /api
-		// This allows users to access /api/cek/object even if config is /cek/object
-		if err != nil && strings.HasPrefix(requestPath, "/api/") {
-			strippedPath := strings.TrimPrefix(requestPath, "/api")
-			// Ensure it has leading slash if it became empty or didn't have one (though /api/x implies /x)
-			if !strings.HasPrefix(strippedPath, "/") {
-				strippedPath = "/" + strippedPath
-			}
-			
-			configRetry, uriParamsRetry, errRetry := m.registry.Match(strippedPath, requestMethod)
-			if errRetry == nil {
-				config = configRetry
-				uriParams = uriParamsRetry
-				err = nil
-				logger.GetLogger().Info("Dynamic URI matched using fallback (stripped /api prefix)",
-					zap.String("original_path", requestPath),
-					zap.String("matched_path", strippedPath),
-				)
-			}
+		// Try to find API config in route registry (O(k) lookup), honoring host/header/query predicates
+		// When the path doesn't match as is, the configured prefix rewrite rules are tried in order
+		routeReq := &routing.RouteRequest{
+			Path:    requestPath,
+			Method:  requestMethod,
+			Host:    c.Request.Host,
+			Headers: c.Request.Header,
+			Query:   c.Request.URL.Query(),
+		}
+		config, uriParams, matchedPath, err := m.registry.Resolve(routeReq)
+		if err == nil && matchedPath != requestPath {
+			logger.GetLogger().Info("Dynamic URI matched after prefix rewrite",
+				zap.String("original_path", requestPath),
+				zap.String("matched_path", matchedPath),
+			)
+		}
+
+		if err == routing.ErrRouteUnavailable {
+			m.respondUnavailable(c, config)
+			return
 		}
 
 		if err != nil {
@@ -93,9 +109,25 @@ func (m *DynamicURIMiddleware) HandleDynamicURI() gin.HandlerFunc {
 					zap.String("client_ip", clientIP),
 				)
 			} else if err == routing.ErrMethodNotAllowed {
+				allow := strings.Join(m.registry.AllowedMethods(requestPath), ", ")
+				c.Header("Allow", allow)
+
+				// OPTIONS without an explicit config is answered from the methods configured on the route
+				if requestMethod == http.MethodOptions {
+					c.Header("Access-Control-Allow-Methods", allow)
+					logger.GetLogger().Debug("Answered OPTIONS for dynamic URI",
+						zap.String("path", requestPath),
+						zap.String("allow", allow),
+						zap.String("client_ip", clientIP),
+					)
+					c.AbortWithStatus(http.StatusNoContent)
+					return
+				}
+
 				logger.GetLogger().Warn("Method not allowed for dynamic URI",
 					zap.String("path", requestPath),
 					zap.String("method", requestMethod),
+					zap.String("allow", allow),
 					zap.String("client_ip", clientIP),
 				)
 				c.JSON(http.StatusMethodNotAllowed, gin.H{"message": "Method Not Allowed"})
@@ -119,21 +151,23 @@ func (m *DynamicURIMiddleware) HandleDynamicURI() gin.HandlerFunc {
             zap.Bool("is_active", config.URLConfig.IsActive),
 		)
 
-		// Check if URL Config is active
-        if !config.URLConfig.IsActive {
-            logger.GetLogger().Warn("URL Config is inactive",
-                zap.String("slug", config.Path),
-                zap.String("url_config", config.URLConfig.Nama),
-            )
-            c.JSON(http.StatusServiceUnavailable, constants.BuildErrorResponse("Service Unavailable", "Service is currently inactive"))
-            c.Abort()
-            return
-        }
+		// ANY configs forward the request's own method upstream, and so do GET configs serving a HEAD
+		// request: the upstream answers HEAD without a body instead of sending one the gateway would drop
+		if config.Method == routing.MethodAny || (requestMethod == http.MethodHead && config.Method == http.MethodGet) {
+			forwarded := *config
+			forwarded.Method = requestMethod
+			config = &forwarded
+		}
 
 		// Store URI parameters in context EARLY so ExtractUserVars can find them
 		// This is critical for validating path parameters
 		c.Set("uri_params", uriParams)
 
+		// Streamed bodies go to the upstream as is, so they're neither parsed nor validated
+		if config.Stream {
+			c.Set(integrasi.StreamBodyKey, true)
+		}
+
         // VALIDATION
 		// Convert dto.Variable map to map[string]interface{} for validator
 		validationVars := make(map[string]interface{})
@@ -152,11 +186,12 @@ func (m *DynamicURIMiddleware) HandleDynamicURI() gin.HandlerFunc {
 		// This ensures that explicit path params (usually most critical) or body payload take precedence
 		validationData := make(map[string]interface{})
 		
+		// Repeated headers and query params are validated as arrays for array variables, by their first value otherwise
 		for k, v := range userVars.HeaderParams {
-			validationData[k] = v
+			validationData[k] = integrasi.ParamValue(v, config.Variables[k].DataType)
 		}
 		for k, v := range userVars.QueryParams {
-			validationData[k] = v
+			validationData[k] = integrasi.ParamValue(v, config.Variables[k].DataType)
 		}
 		for k, v := range userVars.PathParams {
 			validationData[k] = v
@@ -211,8 +246,30 @@ func (m *DynamicURIMiddleware) HandleDynamicURI() gin.HandlerFunc {
 			}
 		}
 
+		// Split routes forward to the variant picked for this request (the route's own upstream by default)
+		config = m.selectVariant(c, config)
+
+		// Static, redirect and mock routes are answered by the gateway, the upstream is never called
+		if config.RouteType != "" && config.RouteType != dto.RouteTypeProxy {
+			c.Set("api_config", config)
+			m.serveDirect(c, config)
+			c.Abort()
+			return
+		}
+
+		// Check if URL Config is active, only proxied routes call it
+		if !config.URLConfig.IsActive {
+			logger.GetLogger().Warn("URL Config is inactive",
+				zap.String("slug", config.Path),
+				zap.String("url_config", config.URLConfig.Nama),
+			)
+			c.JSON(http.StatusServiceUnavailable, constants.BuildErrorResponse("Service Unavailable", "Service is currently inactive"))
+			c.Abort()
+			return
+		}
+
 		// Capture request body for cache key generation
-		if c.Request.Body != nil {
+		if c.Request.Body != nil && !config.Stream {
 			bodyBytes, _ := io.ReadAll(c.Request.Body)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
 			c.Set("request_body", string(bodyBytes))
@@ -267,6 +324,12 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 		}
 	}
 
+	// Streamed routes copy bodies between client and upstream, never cached nor manipulated
+	if config.Stream {
+		m.processStream(c, config)
+		return
+	}
+
 	// Generate cache key
 	cacheKey := m.cacheService.GenerateCacheKey(config, c, uriParams)
 
@@ -284,13 +347,8 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 			zap.String("client_ip", clientIP),
 		)
 
-		// Set cached headers if any
-		for key, value := range cachedHeaders {
-			c.Header(key, value)
-		}
-
-		// Return cached response
-		m.returnResponse(c, cachedData, cachedStatus, config)
+		// Return cached response with the upstream headers cached along
+		m.returnResponse(c, cachedData, cachedStatus, cachedHeaders, config)
 		return
 	}
 
@@ -305,8 +363,14 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 	ctx, cancel = context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
+	// Mirror the request to the route's shadow upstream, if any, before the primary call
+	pending := m.startMirror(c, config)
+
 	// Execute the integration request using existing handler logic
-	body, status, err := m.executeExternalIntegration(ctx, config, c, uriParams)
+	start := time.Now()
+	body, status, header, err := m.executeExternalIntegration(ctx, config, c, uriParams)
+	m.recordVariant(c, config, status, time.Since(start))
+	pending.Primary(status, body)
 	if err != nil {
 		logger.GetLogger().Error("Dynamic URI integration request failed",
 			zap.String("slug", config.Path),
@@ -319,7 +383,7 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 
 		// Cache error responses briefly (except 5xx errors)
 		if status >= 400 && status < 500 && m.cacheService.ShouldCache(c, config, status, len(body)) {
-			m.cacheService.SetCachedResponse(ctx, cacheKey, body, status, nil, config)
+			m.cacheService.SetCachedResponse(ctx, cacheKey, body, status, header, config)
 		}
 
 		if status == http.StatusRequestTimeout {
@@ -327,6 +391,12 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 			return
 		}
 
+		// The upstream's circuit breaker is open or none of its targets is healthy, fail fast instead of reporting an internal error
+		if errors.Is(err, circuit.ErrCircuitOpen) || errors.Is(err, circuit.ErrTooManyRequests) || errors.Is(err, balancer.ErrNoHealthyTarget) {
+			c.JSON(http.StatusServiceUnavailable, constants.BuildErrorResponse("Service Unavailable", "Upstream is temporarily unavailable"))
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -342,16 +412,7 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 
 	// Cache the successful response if caching is enabled and appropriate
 	if m.cacheService.ShouldCache(c, config, status, len(body)) {
-		// Extract response headers for caching
-		headers := make(map[string]string)
-		importantHeaders := []string{"content-type", "cache-control", "etag", "last-modified"}
-		for _, header := range importantHeaders {
-			if value := c.Writer.Header().Get(header); value != "" {
-				headers[header] = value
-			}
-		}
-
-		if err := m.cacheService.SetCachedResponse(ctx, cacheKey, body, status, headers, config); err != nil {
+		if err := m.cacheService.SetCachedResponse(ctx, cacheKey, body, status, header, config); err != nil {
 			logger.GetLogger().Error("Failed to cache response",
 				zap.String("slug", config.Path),
 				zap.String("cache_key", cacheKey),
@@ -361,73 +422,141 @@ func (m *DynamicURIMiddleware) processDynamicURI(c *gin.Context, config *dto.API
 	}
 
 	// Return the response
-	m.returnResponse(c, body, status, config)
+	m.returnResponse(c, body, status, header, config)
+}
+
+// respondUnavailable answers for a route outside its active window or in maintenance
+// The config's maintenance status and body are used when set, Retry-After when the route has a known return
+func (m *DynamicURIMiddleware) respondUnavailable(c *gin.Context, config *dto.APIConfigResponse) {
+	availability := routing.Availability(config, time.Now())
+
+	status := config.MaintenanceStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+
+	if !availability.RetryAfter.IsZero() {
+		seconds := int(math.Ceil(time.Until(availability.RetryAfter).Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+
+	logger.GetLogger().Info("Dynamic URI route unavailable",
+		zap.String("slug", config.Path),
+		zap.String("method", config.Method),
+		zap.String("reason", availability.Reason),
+		zap.Time("retry_after", availability.RetryAfter),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	if config.MaintenanceBody != nil {
+		c.AbortWithStatusJSON(status, config.MaintenanceBody)
+		return
+	}
+	c.AbortWithStatusJSON(status, constants.BuildErrorResponse("Service Unavailable", "Route is unavailable: "+availability.Reason))
 }
 
-// returnResponse handles response formatting and template manipulation
-func (m *DynamicURIMiddleware) returnResponse(c *gin.Context, body []byte, status int, config *dto.APIConfigResponse) {
+// returnResponse writes the upstream's response with its status and the headers its header policy passed on
+// Bodies go out as is with the upstream's Content-Type, JSON when it sent none (e.g. gRPC). Successful
+// responses of routes with a manipulation template have to be JSON and are sent as the rendered JSON instead
+func (m *DynamicURIMiddleware) returnResponse(c *gin.Context, body []byte, status int, header http.Header, config *dto.APIConfigResponse) {
+	writeUpstreamHeaders(c, header)
+
+	// HEAD answers carry the upstream's length of the body it didn't send, never a manipulated one
+	if c.Request.Method == http.MethodHead {
+		if length := header.Get("Content-Length"); length != "" {
+			c.Header("Content-Length", length)
+		}
+		c.Status(status)
+		return
+	}
+
 	switch status {
-	case http.StatusNoContent:
+	case http.StatusNoContent, http.StatusNotModified:
 		c.Status(status)
+		return
 
 	case http.StatusOK, http.StatusCreated:
-		var jsonData interface{}
-		if err := json.Unmarshal(body, &jsonData); err != nil {
-			logger.GetLogger().Error("Invalid JSON in dynamic URI response",
-				zap.String("slug", config.Path),
-				zap.String("client_ip", c.ClientIP()),
-				zap.Error(err),
-			)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid JSON"})
+		if strings.TrimSpace(config.Manipulation) != "" {
+			m.returnManipulated(c, body, status, config)
 			return
 		}
+	}
 
-		// Apply template manipulation if configured
-		if strings.TrimSpace(config.Manipulation) != "" {
-			rendered, err := integrasi.RenderTemplateWithSprig(config.Manipulation, jsonData)
-			if err != nil {
-				logger.GetLogger().Error("Failed to render template for dynamic URI",
-					zap.String("slug", config.Path),
-					zap.String("client_ip", c.ClientIP()),
-					zap.Error(err),
-				)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":   "failed to render template",
-					"details": err.Error(),
-				})
-				return
-			}
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(status, contentType, body)
+}
 
-			var finalResult interface{}
-			if err := json.Unmarshal([]byte(rendered), &finalResult); err != nil {
-				logger.GetLogger().Error("Rendered output is not valid JSON",
-					zap.String("slug", config.Path),
-					zap.String("client_ip", c.ClientIP()),
-					zap.String("rendered_output", rendered),
-					zap.Error(err),
-				)
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error":    "rendered output is not valid JSON",
-					"rendered": rendered,
-				})
-				return
-			}
+// returnManipulated renders the route's manipulation template over the JSON body and sends the result
+// Validators and encodings of the upstream's body don't hold for the rendered one and are dropped
+func (m *DynamicURIMiddleware) returnManipulated(c *gin.Context, body []byte, status int, config *dto.APIConfigResponse) {
+	var jsonData interface{}
+	if err := json.Unmarshal(body, &jsonData); err != nil {
+		logger.GetLogger().Error("Invalid JSON in dynamic URI response",
+			zap.String("slug", config.Path),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid JSON"})
+		return
+	}
 
-			c.JSON(status, finalResult)
-			return
-		}
+	rendered, err := integrasi.RenderTemplateWithSprig(config.Manipulation, jsonData)
+	if err != nil {
+		logger.GetLogger().Error("Failed to render template for dynamic URI",
+			zap.String("slug", config.Path),
+			zap.String("client_ip", c.ClientIP()),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to render template",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	var finalResult interface{}
+	if err := json.Unmarshal([]byte(rendered), &finalResult); err != nil {
+		logger.GetLogger().Error("Rendered output is not valid JSON",
+			zap.String("slug", config.Path),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("rendered_output", rendered),
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":    "rendered output is not valid JSON",
+			"rendered": rendered,
+		})
+		return
+	}
 
-		c.JSON(status, jsonData)
+	for _, name := range []string{"Content-Type", "Content-Encoding", "Content-Md5", "Etag", "Digest"} {
+		c.Writer.Header().Del(name)
+	}
+	c.JSON(status, finalResult)
+}
 
-	default:
-		c.Data(status, "application/json", body)
+// writeUpstreamHeaders copies the upstream's headers to the response. Content-Length is left for the gateway
+// to set and headers the gateway already set, e.g. CORS or rate limit headers, keep the gateway's values
+func writeUpstreamHeaders(c *gin.Context, header http.Header) {
+	own := c.Writer.Header()
+	for name, values := range header {
+		if http.CanonicalHeaderKey(name) == "Content-Length" || len(own[name]) > 0 {
+			continue
+		}
+		own[name] = append([]string(nil), values...)
 	}
 }
 
 // executeExternalIntegration handles the actual integration execution
-func (m *DynamicURIMiddleware) executeExternalIntegration(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context, uriParams map[string]string) ([]byte, int, error) {
+// Both protocols go through the executor: pooled clients, the upstream's circuit breaker and upstream authentication
+func (m *DynamicURIMiddleware) executeExternalIntegration(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context, uriParams map[string]string) ([]byte, int, http.Header, error) {
 	if config.Protocol == "grpc" {
-		// Handle gRPC request
 		logger.GetLogger().Info("Executing external gRPC request",
 			zap.String("slug", config.Path),
 			zap.String("service", config.URLConfig.GRPCService),
@@ -436,35 +565,18 @@ func (m *DynamicURIMiddleware) executeExternalIntegration(ctx context.Context, c
 			zap.Bool("tls_enabled", config.URLConfig.TLSEnabled),
 			zap.Int("uri_params_count", len(uriParams)),
 		)
-
-		return integrasi.DoRequestWithProtocol(ctx, config, c)
 	} else {
-		// Handle HTTP request
-		apiRequestConfig := integrasi.ConvertToAPIResponseConfig(config).BuildAPIRequestConfig(c)
-
-		// Add URI parameters to the request context for template rendering
-		if len(uriParams) > 0 {
-			// Create a modified context that includes URI parameters
-			// This can be used for template rendering in headers, body, etc.
-			c.Set("uri_params", uriParams)
-
-			logger.GetLogger().Info("URI parameters added to request context",
-				zap.String("slug", config.Path),
-				zap.Any("uri_params", uriParams),
-			)
-		}
-
 		logger.GetLogger().Info("Executing external HTTP request",
 			zap.String("slug", config.Path),
-			zap.String("method", apiRequestConfig.Method),
-			zap.String("url", apiRequestConfig.URL),
-			zap.Int("timeout", apiRequestConfig.Timeout),
-			zap.Int("max_retries", apiRequestConfig.MaxRetries),
+			zap.String("method", config.Method),
+			zap.String("url", config.URL),
+			zap.Int("timeout", config.Timeout),
+			zap.Int("max_retries", config.MaxRetries),
 			zap.Int("uri_params_count", len(uriParams)),
 		)
-
-		return integrasi.DoRequestSafeWithRetry(ctx, apiRequestConfig)
 	}
+
+	return m.executor.ExecuteRequest(ctx, config, c)
 }
 
 // authenticate handles dynamic authentication based on config
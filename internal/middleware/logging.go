@@ -58,9 +58,9 @@ func RequestResponseMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
-		// Read request body for logging (only for small requests)
+		// Read request body for logging (only for small requests of known length, chunked ones may be streamed)
 		var requestBody []byte
-		if c.Request.Body != nil && c.Request.ContentLength < 1024*1024 { // 1MB limit
+		if c.Request.Body != nil && c.Request.ContentLength >= 0 && c.Request.ContentLength < 1024*1024 { // 1MB limit
 			requestBody, _ = io.ReadAll(c.Request.Body)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
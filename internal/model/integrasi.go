@@ -1,6 +1,10 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"gorm.io/datatypes"
@@ -18,6 +22,9 @@ type URLConfig struct {
 	ProtoFile   *string `gorm:"type:varchar(500)" json:"proto_file,omitempty"`
 	TLSEnabled  bool    `gorm:"default:false;index:idx_url_configs_tls_enabled" json:"tls_enabled"`
 
+	// Public base path every route of this upstream is mounted under (e.g. "/pay"), empty = root
+	BasePath string `gorm:"type:varchar(255);default:''" json:"base_path"`
+
 	// Connection Pool Settings
 	MaxConnections     int `gorm:"default:100" json:"max_connections"`
 	MinIdleConnections int `gorm:"default:10" json:"min_idle_connections"`
@@ -30,10 +37,30 @@ type URLConfig struct {
 	HealthCheckInterval int    `gorm:"default:30" json:"health_check_interval"` // seconds
 
 	// Circuit Breaker Settings
-	CircuitBreakerEnabled   bool   `gorm:"default:true" json:"circuit_breaker_enabled"`
-	CircuitBreakerThreshold int    `gorm:"default:5" json:"circuit_breaker_threshold"` // failures before open
-	CircuitBreakerTimeout   int    `gorm:"default:30" json:"circuit_breaker_timeout"`  // seconds to wait before half-open
-	RetryOnStatusCodes      string `gorm:"type:varchar(100);default:'502,503,504'" json:"retry_on_status_codes"`
+	CircuitBreakerEnabled    bool   `gorm:"default:true" json:"circuit_breaker_enabled"`
+	CircuitBreakerThreshold  int    `gorm:"default:5" json:"circuit_breaker_threshold"` // failures before open
+	CircuitBreakerTimeout    int    `gorm:"default:30" json:"circuit_breaker_timeout"`  // seconds to wait before half-open
+	RetryOnStatusCodes       string `gorm:"type:varchar(100);default:'502,503,504'" json:"retry_on_status_codes"`
+	RetryOnErrors            string `gorm:"type:varchar(100);default:'connect_failure,reset'" json:"retry_on_errors"` // connect_failure, reset, timeout
+	RetryPerTryTimeoutMillis int    `gorm:"default:0" json:"retry_per_try_timeout_ms"`                                // ms, 0 = route timeout per try
+
+	// Sliding window breaker: count or time windows open on failure/slow call rates instead of failures in a row
+	CircuitBreakerScope          string  `gorm:"type:varchar(20);default:'upstream'" json:"circuit_breaker_scope"`          // upstream, route
+	CircuitBreakerWindowType     string  `gorm:"type:varchar(20);default:'consecutive'" json:"circuit_breaker_window_type"` // consecutive, count, time
+	CircuitBreakerWindowSize     int     `gorm:"default:100" json:"circuit_breaker_window_size"`                            // calls (count) or seconds (time)
+	CircuitBreakerMinimumCalls   int     `gorm:"default:20" json:"circuit_breaker_minimum_calls"`
+	CircuitBreakerFailureRate    float64 `gorm:"default:50" json:"circuit_breaker_failure_rate"`   // percent, 0 = off
+	CircuitBreakerSlowCallRate   float64 `gorm:"default:0" json:"circuit_breaker_slow_call_rate"`  // percent, 0 = off
+	CircuitBreakerSlowCallMillis int     `gorm:"default:5000" json:"circuit_breaker_slow_call_ms"` // calls at least this long are slow
+
+	// Load Balancing: requests go to the targets instead of URL when any are set, URL stays the config's identity
+	Targets               datatypes.JSON `gorm:"type:jsonb;default:'[]'::jsonb" json:"targets"` // [{"url": "http://10.0.0.2:8080", "weight": 3}]
+	LoadBalancingStrategy string         `gorm:"type:varchar(30);default:'round_robin'" json:"load_balancing_strategy"`
+	HashKey               string         `gorm:"type:varchar(100);default:''" json:"hash_key"` // consistent_hash key: header:X-User-ID, cookie:sid, query:uid, user, ip
+
+	// Response Headers: upstream response headers passed on to clients, comma-separated names, X-Internal-* matches a prefix
+	ResponseHeadersAllow string `gorm:"type:text;default:''" json:"response_headers_allow"` // empty = all
+	ResponseHeadersDeny  string `gorm:"type:text;default:''" json:"response_headers_deny"`
 
 	// Upstream Authentication
 	AuthType     string `gorm:"type:varchar(20);default:'none';index:idx_url_configs_auth_type" json:"auth_type"` // none, basic, apikey, bearer
@@ -47,9 +74,9 @@ type URLConfig struct {
 
 type APIConfig struct {
 	gorm.Model
-	Path         string         `gorm:"type:varchar(255);not null;uniqueIndex:idx_api_configs_path_method;index:idx_api_configs_path_fast" json:"path"`
-	Method       string         `gorm:"type:varchar(100);not null;uniqueIndex:idx_api_configs_path_method;index:idx_api_configs_method" json:"method"`
-	URLConfigID  uint           `gorm:"not null;index:idx_api_configs_url_config_id" json:"url_config_id"`
+	Path         string         `gorm:"type:varchar(255);not null;index:idx_api_configs_path_method_match;index:idx_api_configs_path_fast" json:"path"`
+	Method       string         `gorm:"type:varchar(100);not null;index:idx_api_configs_path_method_match;index:idx_api_configs_method" json:"method"`
+	URLConfigID  *uint          `gorm:"index:idx_api_configs_url_config_id" json:"url_config_id"` // Nil for static, redirect and mock routes
 	URI          string         `gorm:"type:varchar(500);index:idx_api_configs_uri" json:"uri"`
 	Headers      datatypes.JSON `gorm:"type:jsonb;default:'{}'::jsonb" json:"headers"`
 	QueryParams  datatypes.JSON `gorm:"type:jsonb;default:'{}'::jsonb" json:"query_params"`
@@ -74,6 +101,44 @@ type APIConfig struct {
 	// Priority (for load balancing)
 	Priority int `gorm:"default:0" json:"priority"` // higher = more priority
 
+	// Match Predicates (optional, several configs may share a path+method when these differ)
+	MatchHost    string         `gorm:"type:varchar(255);default:'';index:idx_api_configs_path_method_match" json:"match_host"` // api.partner.com or *.partner.com
+	MatchHeaders datatypes.JSON `gorm:"type:jsonb;default:'{}'::jsonb" json:"match_headers"`                                   // {"X-API-Version": "2"}, empty value = header must be present
+	MatchQuery   datatypes.JSON `gorm:"type:jsonb;default:'{}'::jsonb" json:"match_query"`                                     // {"channel": "mobile"}, empty value = param must be present
+
+	// Identifies the base path and match predicates, unique per path+method among live configs (see AutoMigrate)
+	PredicateHash string `gorm:"type:varchar(64);not null;default:''" json:"-"`
+
+	// Schedule (enforced by the route registry at match time, no config edit needed at the switch)
+	ActiveFrom          *time.Time     `gorm:"index:idx_api_configs_active_window" json:"active_from,omitempty"`
+	ActiveUntil         *time.Time     `gorm:"index:idx_api_configs_active_window" json:"active_until,omitempty"`
+	MaintenanceSchedule datatypes.JSON `gorm:"type:jsonb;default:'[]'::jsonb" json:"maintenance_schedule"` // [{"days": ["mon"], "start": "23:00", "end": "01:00", "timezone": "Asia/Jakarta"}]
+	MaintenanceStatus   int            `gorm:"default:503" json:"maintenance_status"`
+	MaintenanceBody     datatypes.JSON `gorm:"type:jsonb" json:"maintenance_body"`
+
+	// Route Type: proxy = forward to the URL config upstream, static/redirect/mock = answered by the gateway
+	RouteType        string         `gorm:"type:varchar(20);default:'proxy';index:idx_api_configs_route_type" json:"route_type"`
+	ResponseStatus   int            `gorm:"default:200" json:"response_status"`
+	ResponseHeaders  datatypes.JSON `gorm:"type:jsonb;default:'{}'::jsonb" json:"response_headers"`
+	ResponseBody     datatypes.JSON `gorm:"type:jsonb" json:"response_body"`
+	RedirectStatus   int            `gorm:"default:302" json:"redirect_status"`
+	RedirectLocation string         `gorm:"type:varchar(2048)" json:"redirect_location"`
+	MockResponses    datatypes.JSON `gorm:"type:jsonb;default:'[]'::jsonb" json:"mock_responses"` // [{"name": "ok", "status": 200, "body": {...}}]
+
+	// Traffic Split: variants take part of the traffic away from URLConfigID, e.g. a 5% canary
+	Variants            datatypes.JSON `gorm:"type:jsonb;default:'[]'::jsonb" json:"variants"` // [{"name": "v2", "url_config_id": 7, "weight": 5}]
+	SplitKey            string         `gorm:"type:varchar(100);default:''" json:"split_key"`   // header:X-User-ID, cookie:sid, query:uid, user, ip
+	SplitOverrideHeader string         `gorm:"type:varchar(100);default:''" json:"split_override_header"`
+
+	// Traffic Mirror: a copy of a share of the requests goes to another upstream, its responses are discarded
+	Mirror datatypes.JSON `gorm:"type:jsonb" json:"mirror"` // {"url_config_id": 9, "percent": 10, "compare": true, "ignore_fields": ["data.created_at"]}
+
+	// Hedging: a slow idempotent request gets a second copy to another target of the upstream, the first response wins
+	Hedge datatypes.JSON `gorm:"type:jsonb" json:"hedge"` // {"delay_ms": 0, "percentile": 95, "max_percent": 10}
+
+	// Streaming: request and response bodies are copied between client and upstream without buffering
+	Stream bool `gorm:"default:false" json:"stream"`
+
 	// Authentication Configuration
 	// AuthType: none = no auth, jwt = JWT token, basic = Basic Auth, apikey = API Key, gateway = Gateway admin auth
 	AuthType         string `gorm:"type:varchar(20);default:'none';index:idx_api_configs_auth_type" json:"auth_type"`
@@ -140,3 +205,27 @@ type APIGroupCron struct {
 	AvgDuration  int        `gorm:"default:0" json:"avg_duration"`
 	APIGroup     APIGroup   `gorm:"foreignKey:Slug;references:Slug;constraint:OnDelete:CASCADE" json:"api_group"`
 }
+
+// HashPredicates identifies a config's base path and match predicates, header names and the host compared
+// case-insensitively like the route registry does, so two configs serve the same requests only if their hashes match
+func HashPredicates(basePath, matchHost string, matchHeaders, matchQuery map[string]string) string {
+	headers := make(map[string]string, len(matchHeaders))
+	for name, value := range matchHeaders {
+		headers[strings.ToLower(name)] = value
+	}
+	query := make(map[string]string, len(matchQuery))
+	for name, value := range matchQuery {
+		query[name] = value
+	}
+
+	// Map keys are marshalled sorted
+	data, _ := json.Marshal(struct {
+		BasePath string            `json:"base_path"`
+		Host     string            `json:"host"`
+		Headers  map[string]string `json:"headers"`
+		Query    map[string]string `json:"query"`
+	}{basePath, strings.ToLower(matchHost), headers, query})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
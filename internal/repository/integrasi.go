@@ -8,6 +8,7 @@ import (
 	"github.com/Payphone-Digital/gateway/internal/model"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -166,7 +167,9 @@ func (r *APIConfigRepository) GetAllURLConfig(limit, offset int, search string)
 	return urlConfigs, total, nil
 }
 
-func (r *APIConfigRepository) UpdateURLConfig(ctx context.Context, req *model.URLConfig) error {
+// UpdateURLConfig updates a URL config and, in the same transaction, the predicate hashes of its routes
+// in routeHashes (API config ID to hash), which change with its base path
+func (r *APIConfigRepository) UpdateURLConfig(ctx context.Context, req *model.URLConfig, routeHashes map[uint]string) error {
 	if err := ctx.Err(); err != nil {
 		logger.GetLogger().Warn("Repository: Context cancelled before updating URL config",
 			zap.Uint("url_config_id", req.ID),
@@ -183,8 +186,18 @@ func (r *APIConfigRepository) UpdateURLConfig(ctx context.Context, req *model.UR
 	)
 
 	start := time.Now()
-	// Use Select("*").Updates() to ensures zero values are updated
-	err := r.db.WithContext(ctx).Model(req).Select("*").Updates(req).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Use Select("*").Updates() to ensures zero values are updated
+		if err := tx.Model(req).Select("*").Updates(req).Error; err != nil {
+			return err
+		}
+		for id, hash := range routeHashes {
+			if err := tx.Model(&model.APIConfig{}).Where("id = ?", id).Update("predicate_hash", hash).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	duration := time.Since(start)
 
 	if err != nil {
@@ -250,7 +263,7 @@ func (r *APIConfigRepository) CreateConfig(ctx context.Context, req *model.APICo
 	logger.GetLogger().Debug("Repository: Creating API config",
 		zap.String("path", req.Path),
 		zap.String("method", req.Method),
-		zap.Uint("url_config_id", req.URLConfigID),
+		zap.Uintp("url_config_id", req.URLConfigID),
 		zap.String("uri", req.URI),
 		zap.Int("max_retries", req.MaxRetries),
 		zap.Int("timeout", req.Timeout),
@@ -416,7 +429,7 @@ func (r *APIConfigRepository) UpdateConfig(ctx context.Context, req *model.APICo
 		zap.Uint("config_id", req.ID),
 		zap.String("path", req.Path),
 		zap.String("method", req.Method),
-		zap.Uint("url_config_id", req.URLConfigID),
+		zap.Uintp("url_config_id", req.URLConfigID),
 		zap.String("uri", req.URI),
 		zap.Int("max_retries", req.MaxRetries),
 		zap.Int("timeout", req.Timeout),
@@ -446,6 +459,43 @@ func (r *APIConfigRepository) UpdateConfig(ctx context.Context, req *model.APICo
 	return err
 }
 
+// UpdateTrafficSplit sets the primary upstream, variants and predicate hash (which follows the primary's base path)
+// of an API config, leaving every other column untouched
+func (r *APIConfigRepository) UpdateTrafficSplit(ctx context.Context, id, urlConfigID uint, variants datatypes.JSON, predicateHash string) error {
+	if err := ctx.Err(); err != nil {
+		logger.GetLogger().Warn("Repository: Context cancelled before updating traffic split",
+			zap.Uint("config_id", id),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	start := time.Now()
+	err := r.db.WithContext(ctx).Model(&model.APIConfig{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"url_config_id":  urlConfigID,
+		"variants":       variants,
+		"predicate_hash": predicateHash,
+	}).Error
+	duration := time.Since(start)
+
+	if err != nil {
+		logger.GetLogger().Error("Repository: Failed to update traffic split",
+			zap.Uint("config_id", id),
+			zap.Uint("url_config_id", urlConfigID),
+			zap.Duration("query_duration", duration),
+			zap.Error(err),
+		)
+	} else {
+		logger.GetLogger().Info("Repository: Traffic split updated successfully",
+			zap.Uint("config_id", id),
+			zap.Uint("url_config_id", urlConfigID),
+			zap.Duration("query_duration", duration),
+		)
+	}
+
+	return err
+}
+
 // DISABLED: UpdateGroup - Group-related functions are disabled
 func (r *APIConfigRepository) UpdateGroup(req *model.APIGroup) error {
 	logger.GetLogger().Debug("Repository: Updating API group",
@@ -914,6 +964,77 @@ func (r *APIConfigRepository) FindByPathAndMethodConfig(ctx context.Context, pat
 	return &res, nil
 }
 
+// FindAllByPathAndMethodConfig finds every API config sharing a path and method
+// Several configs may share the pair when their match predicates (host, headers, query) differ
+func (r *APIConfigRepository) FindAllByPathAndMethodConfig(ctx context.Context, path, method string) ([]model.APIConfig, error) {
+	// Check context cancellation
+	if err := ctx.Err(); err != nil {
+		logger.GetLogger().Warn("Repository: Context cancelled before finding API configs by path and method",
+			zap.String("path", path),
+			zap.String("method", method),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	start := time.Now()
+	var res []model.APIConfig
+	err := r.db.WithContext(ctx).Preload("URLConfig").Where("path = ? AND method = ?", path, method).Order("id ASC").Find(&res).Error
+	duration := time.Since(start)
+
+	if err != nil {
+		logger.GetLogger().Error("Repository: Failed to find API configs by path and method",
+			zap.String("path", path),
+			zap.String("method", method),
+			zap.Duration("query_duration", duration),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	logger.GetLogger().Debug("Repository: API configs found by path and method",
+		zap.String("path", path),
+		zap.String("method", method),
+		zap.Int("count", len(res)),
+		zap.Duration("query_duration", duration),
+	)
+
+	return res, nil
+}
+
+// FindAllByURLConfigID returns the API configs whose own upstream is the URL config, variants aside
+func (r *APIConfigRepository) FindAllByURLConfigID(ctx context.Context, urlConfigID uint) ([]model.APIConfig, error) {
+	if err := ctx.Err(); err != nil {
+		logger.GetLogger().Warn("Repository: Context cancelled before finding API configs by URL config",
+			zap.Uint("url_config_id", urlConfigID),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	start := time.Now()
+	var res []model.APIConfig
+	err := r.db.WithContext(ctx).Where("url_config_id = ?", urlConfigID).Order("id ASC").Find(&res).Error
+	duration := time.Since(start)
+
+	if err != nil {
+		logger.GetLogger().Error("Repository: Failed to find API configs by URL config",
+			zap.Uint("url_config_id", urlConfigID),
+			zap.Duration("query_duration", duration),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	logger.GetLogger().Debug("Repository: API configs found by URL config",
+		zap.Uint("url_config_id", urlConfigID),
+		zap.Int("count", len(res)),
+		zap.Duration("query_duration", duration),
+	)
+
+	return res, nil
+}
+
 // DISABLED: FindBySlugGroup - Group-related functions are disabled
 func (r *APIConfigRepository) FindBySlugGroup(slug string) (*model.APIGroup, error) {
 	logger.GetLogger().Debug("Repository: Finding API group by slug",
@@ -22,10 +22,37 @@ func (r *Router) integrasiRoutes(version *gin.RouterGroup) {
 	pathConfig.Use(r.jwtMw.RequireAuth()) // JWT protection for all Path Config routes
 	{
 		pathConfig.POST("", r.validMw.ValidateRequestBody(func() interface{} { return &dto.APIConfigRequest{} }), r.IntegrasiHandler.CreateConfig)
+		pathConfig.POST("/validate", r.validMw.ValidateRequestBody(func() interface{} { return &dto.APIConfigRequest{} }), r.IntegrasiHandler.ValidateConfig)
 		pathConfig.PUT("/:id", r.validMw.ValidateRequestBody(func() interface{} { return &dto.APIConfigRequest{} }), r.IntegrasiHandler.UpdateConfig)
 		pathConfig.DELETE("/:id", r.IntegrasiHandler.DeleteConfig)
 		pathConfig.GET("/:id", r.IntegrasiHandler.GetByIDConfig)
 		pathConfig.GET("", r.IntegrasiHandler.GetAllConfig)
+
+		// Traffic split between upstream variants
+		pathConfig.GET("/:id/variants", r.IntegrasiHandler.GetVariants)
+		pathConfig.POST("/:id/variants/promote", r.validMw.ValidateRequestBody(func() interface{} { return &dto.PromoteVariantRequest{} }), r.IntegrasiHandler.PromoteVariant)
+		pathConfig.POST("/:id/variants/rollback", r.IntegrasiHandler.RollbackVariants)
+
+		// Traffic mirrored to a shadow upstream
+		pathConfig.GET("/:id/mirror", r.IntegrasiHandler.GetMirror)
+
+		// Slow requests hedged to a second upstream target
+		pathConfig.GET("/:id/hedge", r.IntegrasiHandler.GetHedge)
+	}
+
+	// Circuit breakers of this instance - Protected with JWT authentication
+	breakers := version.Group("/circuit-breakers")
+	breakers.Use(r.jwtMw.RequireAuth())
+	{
+		breakers.GET("", r.IntegrasiHandler.GetCircuitBreakers)
+		breakers.POST("/control", r.validMw.ValidateRequestBody(func() interface{} { return &dto.CircuitBreakerControlRequest{} }), r.IntegrasiHandler.ControlCircuitBreaker)
+	}
+
+	// Route debugging - Protected with JWT authentication
+	routes := version.Group("/routes")
+	routes.Use(r.jwtMw.RequireAuth())
+	{
+		routes.GET("/explain", r.IntegrasiHandler.ExplainRoute)
 	}
 
 
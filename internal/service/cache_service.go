@@ -6,10 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/codec"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
 	"github.com/Payphone-Digital/gateway/pkg/redis"
 	"github.com/gin-gonic/gin"
@@ -40,13 +42,14 @@ func (s *CacheService) GenerateCacheKey(config *dto.APIConfigResponse, c *gin.Co
 	h := md5.New()
 
 	// Add config details
-	h.Write([]byte(fmt.Sprintf("slug:%s:method:%s:uri:%s",
-		config.Path, config.Method, config.URI)))
+	// The upstream is part of the key so split routes never serve one variant's response for another
+	h.Write([]byte(fmt.Sprintf("slug:%s:method:%s:uri:%s:upstream:%d",
+		config.Path, config.Method, config.URI, config.URLConfigID)))
 
 	// Add request method and path
 	h.Write([]byte(fmt.Sprintf(":%s:%s", c.Request.Method, c.Request.URL.Path)))
 
-	// Add query parameters (sorted for consistency)
+	// Add query parameters (sorted for consistency), every value of a repeated one in the order given
 	queryParams := c.Request.URL.Query()
 	if len(queryParams) > 0 {
 		// Sort query parameters for consistent hashing
@@ -54,6 +57,7 @@ func (s *CacheService) GenerateCacheKey(config *dto.APIConfigResponse, c *gin.Co
 		for k := range queryParams {
 			sortedKeys = append(sortedKeys, k)
 		}
+		sort.Strings(sortedKeys)
 
 		for _, k := range sortedKeys {
 			values := queryParams[k]
@@ -70,6 +74,7 @@ func (s *CacheService) GenerateCacheKey(config *dto.APIConfigResponse, c *gin.Co
 		for k := range uriParams {
 			sortedKeys = append(sortedKeys, k)
 		}
+		sort.Strings(sortedKeys)
 
 		for _, k := range sortedKeys {
 			h.Write([]byte(fmt.Sprintf(":%s=%s", k, uriParams[k])))
@@ -79,7 +84,7 @@ func (s *CacheService) GenerateCacheKey(config *dto.APIConfigResponse, c *gin.Co
 	// Add selected headers that might affect response (like Authorization, Content-Type, etc.)
 	headersToCache := []string{"authorization", "content-type", "accept", "user-agent"}
 	for _, header := range headersToCache {
-		if value := c.GetHeader(header); value != "" {
+		for _, value := range c.Request.Header.Values(header) {
 			h.Write([]byte(fmt.Sprintf(":%s:%s", header, value)))
 		}
 	}
@@ -98,8 +103,8 @@ func (s *CacheService) GenerateCacheKey(config *dto.APIConfigResponse, c *gin.Co
 	return fmt.Sprintf("integration:%s:%s", config.Path, keyHash)
 }
 
-// GetCachedResponse retrieves cached response if available and valid
-func (s *CacheService) GetCachedResponse(ctx context.Context, cacheKey string) ([]byte, int, map[string]string, bool) {
+// GetCachedResponse retrieves cached response if available and valid, with the upstream headers cached along
+func (s *CacheService) GetCachedResponse(ctx context.Context, cacheKey string) ([]byte, int, http.Header, bool) {
 	if s.redisClient == nil {
 		return nil, 0, nil, false
 	}
@@ -120,7 +125,9 @@ func (s *CacheService) GetCachedResponse(ctx context.Context, cacheKey string) (
 
 	// Convert data back to bytes
 	var data []byte
-	if strData, ok := item.Data.(string); ok {
+	if item.Body != nil || item.Data == nil {
+		data = item.Body
+	} else if strData, ok := item.Data.(string); ok {
 		data = []byte(strData)
 	} else {
 		// If it's not a string, convert it to JSON then bytes
@@ -144,8 +151,9 @@ func (s *CacheService) GetCachedResponse(ctx context.Context, cacheKey string) (
 	return data, item.Status, item.Headers, true
 }
 
-// SetCachedResponse stores response in cache
-func (s *CacheService) SetCachedResponse(ctx context.Context, cacheKey string, data []byte, status int, headers map[string]string, config *dto.APIConfigResponse) error {
+// SetCachedResponse stores response in cache along with the upstream headers passed on to clients
+// Set-Cookie is never cached, a cookie meant for one client mustn't be replayed to others
+func (s *CacheService) SetCachedResponse(ctx context.Context, cacheKey string, data []byte, status int, headers http.Header, config *dto.APIConfigResponse) error {
 	if s.redisClient == nil {
 		return nil // Cache is disabled
 	}
@@ -171,6 +179,12 @@ func (s *CacheService) SetCachedResponse(ctx context.Context, cacheKey string, d
 	// Determine TTL based on response and config
 	ttl := s.determineTTL(status, config)
 
+	if headers != nil {
+		headers = headers.Clone()
+		headers.Del("Set-Cookie")
+		delete(headers, http.TrailerPrefix+"Set-Cookie")
+	}
+
 	if err := s.redisClient.SetIntegrationResponse(ctx, cacheKey, data, status, headers, ttl); err != nil {
 		logger.GetLogger().Error("Failed to set cached response",
 			zap.String("cache_key", cacheKey),
@@ -243,6 +257,16 @@ func (s *CacheService) InvalidateCacheByURI(ctx context.Context, uri string) err
 	return s.redisClient.DeleteByPattern(ctx, pattern+"*")
 }
 
+// SuccessTTL returns how long a 200 response of config is cached for
+func (s *CacheService) SuccessTTL(config *dto.APIConfigResponse) time.Duration {
+	return s.determineTTL(http.StatusOK, config)
+}
+
+// IsEnabled reports whether responses can be cached (Redis is configured and reachable)
+func (s *CacheService) IsEnabled() bool {
+	return s.redisClient != nil && s.redisClient.IsEnabled()
+}
+
 // GetCacheStats returns cache statistics
 func (s *CacheService) GetCacheStats(ctx context.Context) (map[string]interface{}, error) {
 	if s.redisClient == nil {
@@ -303,5 +327,10 @@ func (s *CacheService) ShouldCache(c *gin.Context, config *dto.APIConfigResponse
 		return false
 	}
 
+	// Don't cache uploads, their files aren't part of the cache key
+	if codec.Kind(c.GetHeader("Content-Type")) == codec.Multipart {
+		return false
+	}
+
 	return true
 }
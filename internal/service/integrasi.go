@@ -11,7 +11,11 @@ import (
 	"github.com/Payphone-Digital/gateway/internal/dto"
 	"github.com/Payphone-Digital/gateway/internal/model"
 	"github.com/Payphone-Digital/gateway/internal/repository"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
 	"go.uber.org/zap"
 )
 
@@ -68,17 +72,31 @@ func (s *APIConfigService) CreateConfig(ctx context.Context, req dto.APIConfigRe
 		zap.Bool("has_body", req.Body != nil),
 	)
 
+	if err := normalizeRouteType(&req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
 	headersJSON, _ := json.Marshal(req.Headers)
 	queryParamsJSON, _ := json.Marshal(req.QueryParams)
 	bodyJSON, _ := json.Marshal(req.Body)
 	variablesJSON, _ := json.Marshal(req.Variables)
 	basicAuthUsersJSON, _ := json.Marshal(req.BasicAuthUsers)
 	apiKeysJSON, _ := json.Marshal(req.APIKeys)
+	matchHeadersJSON, _ := json.Marshal(req.MatchHeaders)
+	matchQueryJSON, _ := json.Marshal(req.MatchQuery)
+	maintenanceScheduleJSON, _ := json.Marshal(req.MaintenanceSchedule)
+	maintenanceBodyJSON, _ := json.Marshal(req.MaintenanceBody)
+	responseHeadersJSON, _ := json.Marshal(req.ResponseHeaders)
+	responseBodyJSON, _ := json.Marshal(req.ResponseBody)
+	mockResponsesJSON, _ := json.Marshal(req.MockResponses)
+	variantsJSON, _ := json.Marshal(storedVariants(req.Variants))
+	mirrorJSON := storedMirror(req.Mirror)
+	hedgeJSON := storedHedge(req.Hedge)
 
 	apiConfig := &model.APIConfig{
 		Path:         req.Path,
 		Method:       req.Method,
-		URLConfigID:  req.URLConfigID,
+		URLConfigID:  storedURLConfigID(req.URLConfigID),
 		URI:          req.URI,
 		Headers:      headersJSON,
 		QueryParams:  queryParamsJSON,
@@ -90,6 +108,37 @@ func (s *APIConfigService) CreateConfig(ctx context.Context, req dto.APIConfigRe
 		Manipulation: req.Manipulation,
 		Description:  req.Description,
 		IsAdmin:      req.IsAdmin,
+		Priority:     req.Priority,
+		// Match Predicates
+		MatchHost:    req.MatchHost,
+		MatchHeaders: matchHeadersJSON,
+		MatchQuery:   matchQueryJSON,
+		// Schedule
+		ActiveFrom:          req.ActiveFrom,
+		ActiveUntil:         req.ActiveUntil,
+		MaintenanceSchedule: maintenanceScheduleJSON,
+		MaintenanceStatus:   req.MaintenanceStatus,
+		MaintenanceBody:     maintenanceBodyJSON,
+		// Route Type
+		RouteType:        req.RouteType,
+		ResponseStatus:   req.ResponseStatus,
+		ResponseHeaders:  responseHeadersJSON,
+		ResponseBody:     responseBodyJSON,
+		RedirectStatus:   req.RedirectStatus,
+		RedirectLocation: req.RedirectLocation,
+		MockResponses:    mockResponsesJSON,
+		// Traffic Split
+		Variants:            variantsJSON,
+		SplitKey:            req.SplitKey,
+		SplitOverrideHeader: req.SplitOverrideHeader,
+		// Traffic Mirror
+		Mirror: mirrorJSON,
+
+		// Hedging
+		Hedge: hedgeJSON,
+
+		// Streaming
+		Stream: req.Stream,
 		// Auth Fields
 		AuthType:         req.AuthType,
 		AuthRequired:     req.AuthRequired,
@@ -105,12 +154,48 @@ func (s *APIConfigService) CreateConfig(ctx context.Context, req dto.APIConfigRe
 		APIKeys:          apiKeysJSON,
 	}
 
-	if _, err := s.repo.FindByPathAndMethodConfig(ctx, req.Path, req.Method); err == nil {
-		logger.GetLogger().Warn("Service: API config with path and method already exists",
+	if err := validateSchedule(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if status, err := s.validateVariants(req); err != nil {
+		return status, err
+	}
+
+	if status, err := s.validateMirror(req); err != nil {
+		return status, err
+	}
+
+	if err := validateHedge(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if status, err := s.validateStream(req); err != nil {
+		return status, err
+	}
+
+	duplicate, err := s.hasSamePredicates(ctx, req, 0)
+	if err != nil {
+		if errors.Is(err, errUnknownURLConfig) {
+			return http.StatusBadRequest, err
+		}
+		logger.GetLogger().Error("Service: Failed to check API configs with the same path and method",
+			zap.String("path", req.Path),
+			zap.String("method", req.Method),
+			zap.Error(err),
+		)
+		return http.StatusInternalServerError, err
+	}
+	if duplicate {
+		logger.GetLogger().Warn("Service: API config with path, method and match predicates already exists",
 			zap.String("path", req.Path),
 			zap.String("method", req.Method),
+			zap.String("match_host", req.MatchHost),
 		)
-		return http.StatusConflict, errors.New("API config with this path and method already exists")
+		return http.StatusConflict, errors.New("API config with this path, method and match predicates already exists")
+	}
+	if apiConfig.PredicateHash, err = s.predicateHash(req); err != nil {
+		return http.StatusInternalServerError, err
 	}
 
 	// Check context before expensive operation
@@ -127,6 +212,9 @@ func (s *APIConfigService) CreateConfig(ctx context.Context, req dto.APIConfigRe
 			zap.String("path", req.Path),
 			zap.Error(err),
 		)
+		if isUniqueViolation(err) {
+			return http.StatusConflict, errors.New("API config with this path, method and match predicates already exists")
+		}
 		return http.StatusInternalServerError, err
 	}
 
@@ -342,17 +430,31 @@ func (s *APIConfigService) UpdateConfig(ctx context.Context, id uint, req dto.AP
 		return http.StatusRequestTimeout, err
 	}
 
+	if err := normalizeRouteType(&req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
 	headersJSON, _ := json.Marshal(req.Headers)
 	queryParamsJSON, _ := json.Marshal(req.QueryParams)
 	bodyJSON, _ := json.Marshal(req.Body)
 	variablesJSON, _ := json.Marshal(req.Variables)
 	basicAuthUsersJSON, _ := json.Marshal(req.BasicAuthUsers)
 	apiKeysJSON, _ := json.Marshal(req.APIKeys)
+	matchHeadersJSON, _ := json.Marshal(req.MatchHeaders)
+	matchQueryJSON, _ := json.Marshal(req.MatchQuery)
+	maintenanceScheduleJSON, _ := json.Marshal(req.MaintenanceSchedule)
+	maintenanceBodyJSON, _ := json.Marshal(req.MaintenanceBody)
+	responseHeadersJSON, _ := json.Marshal(req.ResponseHeaders)
+	responseBodyJSON, _ := json.Marshal(req.ResponseBody)
+	mockResponsesJSON, _ := json.Marshal(req.MockResponses)
+	variantsJSON, _ := json.Marshal(storedVariants(req.Variants))
+	mirrorJSON := storedMirror(req.Mirror)
+	hedgeJSON := storedHedge(req.Hedge)
 
 	apiConfig := &model.APIConfig{
 		Path:         req.Path,
 		Method:       req.Method,
-		URLConfigID:  req.URLConfigID,
+		URLConfigID:  storedURLConfigID(req.URLConfigID),
 		URI:          req.URI,
 		Headers:      headersJSON,
 		QueryParams:  queryParamsJSON,
@@ -364,6 +466,37 @@ func (s *APIConfigService) UpdateConfig(ctx context.Context, id uint, req dto.AP
 		Manipulation: req.Manipulation,
 		Description:  req.Description,
 		IsAdmin:      req.IsAdmin,
+		Priority:     req.Priority,
+		// Match Predicates
+		MatchHost:    req.MatchHost,
+		MatchHeaders: matchHeadersJSON,
+		MatchQuery:   matchQueryJSON,
+		// Schedule
+		ActiveFrom:          req.ActiveFrom,
+		ActiveUntil:         req.ActiveUntil,
+		MaintenanceSchedule: maintenanceScheduleJSON,
+		MaintenanceStatus:   req.MaintenanceStatus,
+		MaintenanceBody:     maintenanceBodyJSON,
+		// Route Type
+		RouteType:        req.RouteType,
+		ResponseStatus:   req.ResponseStatus,
+		ResponseHeaders:  responseHeadersJSON,
+		ResponseBody:     responseBodyJSON,
+		RedirectStatus:   req.RedirectStatus,
+		RedirectLocation: req.RedirectLocation,
+		MockResponses:    mockResponsesJSON,
+		// Traffic Split
+		Variants:            variantsJSON,
+		SplitKey:            req.SplitKey,
+		SplitOverrideHeader: req.SplitOverrideHeader,
+		// Traffic Mirror
+		Mirror: mirrorJSON,
+
+		// Hedging
+		Hedge: hedgeJSON,
+
+		// Streaming
+		Stream: req.Stream,
 		// Auth Fields
 		AuthType:         req.AuthType,
 		AuthRequired:     req.AuthRequired,
@@ -385,10 +518,43 @@ func (s *APIConfigService) UpdateConfig(ctx context.Context, id uint, req dto.AP
 		return http.StatusNotFound, errors.New("API config not found")
 	}
 
-	if existing.Path != req.Path || existing.Method != req.Method {
-		if _, err := s.repo.FindByPathAndMethodConfig(ctx, req.Path, req.Method); err == nil {
-			return http.StatusConflict, errors.New("API config with this path and method already exists")
+	if err := validateSchedule(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if status, err := s.validateVariants(req); err != nil {
+		return status, err
+	}
+
+	if status, err := s.validateMirror(req); err != nil {
+		return status, err
+	}
+
+	if err := validateHedge(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if status, err := s.validateStream(req); err != nil {
+		return status, err
+	}
+
+	duplicate, err := s.hasSamePredicates(ctx, req, id)
+	if err != nil {
+		if errors.Is(err, errUnknownURLConfig) {
+			return http.StatusBadRequest, err
 		}
+		logger.GetLogger().Error("Service: Failed to check API configs with the same path and method",
+			zap.Uint("config_id", id),
+			zap.String("path", req.Path),
+			zap.Error(err),
+		)
+		return http.StatusInternalServerError, err
+	}
+	if duplicate {
+		return http.StatusConflict, errors.New("API config with this path, method and match predicates already exists")
+	}
+	if apiConfig.PredicateHash, err = s.predicateHash(req); err != nil {
+		return http.StatusInternalServerError, err
 	}
 
 	if err := ctx.Err(); err != nil {
@@ -400,6 +566,9 @@ func (s *APIConfigService) UpdateConfig(ctx context.Context, id uint, req dto.AP
 	}
 
 	if err := s.repo.UpdateConfig(ctx, apiConfig); err != nil {
+		if isUniqueViolation(err) {
+			return http.StatusConflict, errors.New("API config with this path, method and match predicates already exists")
+		}
 		return http.StatusInternalServerError, err
 	}
 
@@ -790,7 +959,7 @@ func (s *APIConfigService) GetByIDConfig(ctx context.Context, id uint) (*dto.API
 		Path:        res.Path,
 		Protocol:    res.URLConfig.Protocol, // Get protocol from URLConfig
 		Method:      res.Method,
-		URLConfigID: res.URLConfigID,
+		URLConfigID: urlConfigIDOf(res.URLConfigID),
 		URI:         res.URI,
 		URL:         completeURL,
 		URLConfig: dto.URLConfigResponse{
@@ -803,6 +972,7 @@ func (s *APIConfigService) GetByIDConfig(ctx context.Context, id uint) (*dto.API
 			GRPCService: getStringValue(res.URLConfig.GRPCService),
 			ProtoFile:   getStringValue(res.URLConfig.ProtoFile),
 			TLSEnabled:  res.URLConfig.TLSEnabled,
+			BasePath:    res.URLConfig.BasePath,
 		},
 		Headers:      headers,
 		QueryParams:  queryParams,
@@ -814,6 +984,7 @@ func (s *APIConfigService) GetByIDConfig(ctx context.Context, id uint) (*dto.API
 		Manipulation: res.Manipulation,
 		Description:  res.Description,
 		IsAdmin:      res.IsAdmin,
+		Priority:     res.Priority,
 
 		// Authentication fields
 		AuthType:         res.AuthType,
@@ -835,6 +1006,8 @@ func (s *APIConfigService) GetByIDConfig(ctx context.Context, id uint) (*dto.API
 	_ = json.Unmarshal(res.APIKeys, &apiKeys)
 	resp.BasicAuthUsers = basicAuthUsers
 	resp.APIKeys = apiKeys
+	decodeRoutingFields(resp, res)
+	s.resolveUpstreams([]*dto.APIConfigResponse{resp})
 
 	logger.GetLogger().Info("Service: API config retrieved successfully",
 		zap.Uint("config_id", id),
@@ -1058,7 +1231,7 @@ func (s *APIConfigService) GetBySlugConfig(ctx context.Context, slug string) (*d
 		Path:         res.Path,
 		Protocol:     res.URLConfig.Protocol, // Get protocol from URLConfig
 		Method:       res.Method,
-		URLConfigID:  res.URLConfigID,
+		URLConfigID:  urlConfigIDOf(res.URLConfigID),
 		URI:          res.URI,
 		URL:          completeURL,
 		URLConfig: dto.URLConfigResponse{
@@ -1071,6 +1244,7 @@ func (s *APIConfigService) GetBySlugConfig(ctx context.Context, slug string) (*d
 			GRPCService: getStringValue(res.URLConfig.GRPCService),
 			ProtoFile:   getStringValue(res.URLConfig.ProtoFile),
 			TLSEnabled:  res.URLConfig.TLSEnabled,
+			BasePath:    res.URLConfig.BasePath,
 		},
 		Headers:      headers,
 		QueryParams:  queryParams,
@@ -1082,6 +1256,7 @@ func (s *APIConfigService) GetBySlugConfig(ctx context.Context, slug string) (*d
 		Manipulation: res.Manipulation,
 		Description:  res.Description,
 		IsAdmin:      res.IsAdmin,
+		Priority:     res.Priority,
 		// Auth Fields
 		AuthType:         res.AuthType,
 		AuthRequired:     res.AuthRequired,
@@ -1102,6 +1277,7 @@ func (s *APIConfigService) GetBySlugConfig(ctx context.Context, slug string) (*d
 	_ = json.Unmarshal(res.APIKeys, &apiKeys)
 	resp.BasicAuthUsers = basicAuthUsers
 	resp.APIKeys = apiKeys
+	decodeRoutingFields(resp, res)
 
 	return resp, http.StatusOK, nil
 }
@@ -1124,85 +1300,39 @@ func (s *APIConfigService) GetByPathAndMethodConfig(ctx context.Context, path, m
 		return nil, http.StatusNotFound, errors.New("API config not found")
 	}
 
-	var headers map[string]string
-	var queryParams map[string]string
-	var variables map[string]dto.Variable
-	var rawBody interface{}
-
-	_ = json.Unmarshal(res.Headers, &headers)
-	_ = json.Unmarshal(res.QueryParams, &queryParams)
-	_ = json.Unmarshal(res.Variables, &variables)
+	resp := toAPIConfigResponse(res)
+	s.resolveUpstreams([]*dto.APIConfigResponse{resp})
+	return resp, http.StatusOK, nil
+}
 
-	if err := json.Unmarshal(res.Body, &rawBody); err != nil {
-		// error handling jika perlu
+// GetAllByPathAndMethodConfig retrieves every API config sharing a path and method
+// Configs on the same path+method are told apart by their match predicates (host, headers, query)
+func (s *APIConfigService) GetAllByPathAndMethodConfig(ctx context.Context, path, method string) ([]*dto.APIConfigResponse, int, error) {
+	// Check context cancellation
+	if err := ctx.Err(); err != nil {
+		logger.GetLogger().Warn("Service: Context cancelled before getting API configs by path and method",
+			zap.String("path", path),
+			zap.String("method", method),
+			zap.Error(err),
+		)
+		return nil, http.StatusRequestTimeout, err
 	}
 
-	if bodyStr, ok := rawBody.(string); ok {
-		var bodyObj map[string]interface{}
-		if err := json.Unmarshal([]byte(bodyStr), &bodyObj); err == nil {
-			rawBody = bodyObj
-		}
+	models, err := s.repo.FindAllByPathAndMethodConfig(ctx, path, method)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
 	}
-
-	body, _ := rawBody.(map[string]interface{})
-
-	// Combine base URL with URI to get complete URL
-	completeURL := res.URLConfig.URL
-	if res.URI != "" {
-		baseURL := strings.TrimSuffix(res.URLConfig.URL, "/")
-		uri := strings.TrimPrefix(res.URI, "/")
-		completeURL = baseURL + "/" + uri
+	if len(models) == 0 {
+		return nil, http.StatusNotFound, errors.New("API config not found")
 	}
 
-	resp := &dto.APIConfigResponse{
-		ID:          res.ID,
-		Path:        res.Path,
-		Protocol:    res.URLConfig.Protocol,
-		Method:      res.Method,
-		URLConfigID: res.URLConfigID,
-		URI:         res.URI,
-		URL:         completeURL,
-		URLConfig: dto.URLConfigResponse{
-			ID:          res.URLConfig.ID,
-			Nama:        res.URLConfig.Nama,
-			Protocol:    res.URLConfig.Protocol,
-			URL:         res.URLConfig.URL,
-			Deskripsi:   res.URLConfig.Deskripsi,
-			IsActive:    res.URLConfig.IsActive,
-			GRPCService: getStringValue(res.URLConfig.GRPCService),
-			ProtoFile:   getStringValue(res.URLConfig.ProtoFile),
-			TLSEnabled:  res.URLConfig.TLSEnabled,
-		},
-		Headers:          headers,
-		QueryParams:      queryParams,
-		Body:             body,
-		Variables:        variables,
-		MaxRetries:       res.MaxRetries,
-		RetryDelay:       res.RetryDelay,
-		Timeout:          res.Timeout,
-		Manipulation:     res.Manipulation,
-		Description:      res.Description,
-		IsAdmin:          res.IsAdmin,
-		AuthType:         res.AuthType,
-		AuthRequired:     res.AuthRequired,
-		AuthGRPCConfigID: res.AuthGRPCConfigID,
-		JWTSecretKey:     res.JWTSecretKey,
-		JWTIssuer:        res.JWTIssuer,
-		JWTAudience:      res.JWTAudience,
-		JWTAlgorithm:     res.JWTAlgorithm,
-		JWTExpiration:    res.JWTExpiration,
-		APIKeyHeader:     res.APIKeyHeader,
-		APIKeyLocation:   res.APIKeyLocation,
+	configs := make([]*dto.APIConfigResponse, 0, len(models))
+	for i := range models {
+		configs = append(configs, toAPIConfigResponse(&models[i]))
 	}
+	s.resolveUpstreams(configs)
 
-	var basicAuthUsers []dto.BasicAuthUser
-	var apiKeys []dto.APIKey
-	_ = json.Unmarshal(res.BasicAuthUsers, &basicAuthUsers)
-	_ = json.Unmarshal(res.APIKeys, &apiKeys)
-	resp.BasicAuthUsers = basicAuthUsers
-	resp.APIKeys = apiKeys
-
-	return resp, http.StatusOK, nil
+	return configs, http.StatusOK, nil
 }
 
 // DISABLED: Config retrieval by URI function
@@ -1269,7 +1399,7 @@ func (s *APIConfigService) GetByURIConfig(ctx context.Context, uri, method strin
 			Path:         res.Path,
 			Protocol:     res.URLConfig.Protocol, // Get protocol from URLConfig
 			Method:       res.Method,
-			URLConfigID:  res.URLConfigID,
+			URLConfigID:  urlConfigIDOf(res.URLConfigID),
 			URI:          res.URI,
 			URL:          completeURL,
 			URLConfig: dto.URLConfigResponse{
@@ -1282,6 +1412,7 @@ func (s *APIConfigService) GetByURIConfig(ctx context.Context, uri, method strin
 				GRPCService: getStringValue(res.URLConfig.GRPCService),
 						ProtoFile:   getStringValue(res.URLConfig.ProtoFile),
 				TLSEnabled:  res.URLConfig.TLSEnabled,
+				BasePath:    res.URLConfig.BasePath,
 			},
 			Headers:      headers,
 			QueryParams:  queryParams,
@@ -1371,7 +1502,7 @@ func (s *APIConfigService) GetByURIConfigWithPattern(ctx context.Context, reques
 			Path:         res.Path,
 			Protocol:     res.URLConfig.Protocol, // Get protocol from URLConfig
 			Method:       res.Method,
-			URLConfigID:  res.URLConfigID,
+			URLConfigID:  urlConfigIDOf(res.URLConfigID),
 			URI:          res.URI,
 			URL:          completeURL,
 			URLConfig: dto.URLConfigResponse{
@@ -1384,6 +1515,7 @@ func (s *APIConfigService) GetByURIConfigWithPattern(ctx context.Context, reques
 				GRPCService: getStringValue(res.URLConfig.GRPCService),
 						ProtoFile:   getStringValue(res.URLConfig.ProtoFile),
 				TLSEnabled:  res.URLConfig.TLSEnabled,
+				BasePath:    res.URLConfig.BasePath,
 			},
 			Headers:      headers,
 			QueryParams:  queryParams,
@@ -1503,7 +1635,7 @@ func (s *APIConfigService) GetAllConfig(params any) ([]dto.APIConfigResponse, in
 			Path:        data.Path,
 			Protocol:    data.URLConfig.Protocol, // Get protocol from URLConfig
 			Method:      data.Method,
-			URLConfigID: data.URLConfigID,
+			URLConfigID: urlConfigIDOf(data.URLConfigID),
 			URI:         data.URI,
 			URL:         completeURL,
 			URLConfig: dto.URLConfigResponse{
@@ -1516,6 +1648,7 @@ func (s *APIConfigService) GetAllConfig(params any) ([]dto.APIConfigResponse, in
 				GRPCService: getStringValue(data.URLConfig.GRPCService),
 				ProtoFile:   getStringValue(data.URLConfig.ProtoFile),
 				TLSEnabled:  data.URLConfig.TLSEnabled,
+				BasePath:    data.URLConfig.BasePath,
 			},
 			Headers:      headers,
 			QueryParams:  queryParams,
@@ -1527,6 +1660,7 @@ func (s *APIConfigService) GetAllConfig(params any) ([]dto.APIConfigResponse, in
 			Manipulation: data.Manipulation,
 			Description:  data.Description,
 			IsAdmin:      data.IsAdmin,
+			Priority:     data.Priority,
 			// Auth Fields
 			AuthType:         data.AuthType,
 			AuthRequired:     data.AuthRequired,
@@ -1547,6 +1681,7 @@ func (s *APIConfigService) GetAllConfig(params any) ([]dto.APIConfigResponse, in
 		_ = json.Unmarshal(data.APIKeys, &apiKeys)
 		respItem.BasicAuthUsers = basicAuthUsers
 		respItem.APIKeys = apiKeys
+		decodeRoutingFields(&respItem, &data)
 
 		res = append(res, respItem)
 	}
@@ -1832,6 +1967,40 @@ func (s *APIConfigService) CreateURLConfig(ctx context.Context, req dto.URLConfi
 		zap.Bool("is_active", req.IsActive),
 	)
 
+	basePath, err := normalizeBasePath(req.BasePath)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	req.BasePath = basePath
+
+	if err := validateTargets(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if req.LoadBalancingStrategy == "" {
+		req.LoadBalancingStrategy = balancer.RoundRobin
+	}
+	if err := validateCircuitBreaker(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := validateRetry(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := validateResponseHeaders(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if req.RetryOnStatusCodes == "" {
+		req.RetryOnStatusCodes = "502,503,504"
+	}
+	if req.RetryOnErrors == "" {
+		req.RetryOnErrors = retry.ConnectFailure + "," + retry.Reset
+	}
+	if req.CircuitBreakerScope == "" {
+		req.CircuitBreakerScope = integrasi.BreakerScopeUpstream
+	}
+	if req.CircuitBreakerWindowType == "" {
+		req.CircuitBreakerWindowType = circuit.WindowConsecutive
+	}
+
 	// Check if URL already exists
 	if _, err := s.repo.FindByURLConfig(ctx, req.URL); err == nil {
 		logger.GetLogger().Warn("Service: URL config duplicates",
@@ -1849,6 +2018,36 @@ func (s *APIConfigService) CreateURLConfig(ctx context.Context, req dto.URLConfi
 		GRPCService: stringPtr(req.GRPCService),
 		ProtoFile:   stringPtr(req.ProtoFile),
 		TLSEnabled:  req.TLSEnabled,
+		BasePath:    req.BasePath,
+		// Connection Pool, Health Check and Circuit Breaker Fields
+		MaxConnections:           req.MaxConnections,
+		MinIdleConnections:       req.MinIdleConnections,
+		ConnectionTimeout:        req.ConnectionTimeout,
+		ReadTimeout:              req.ReadTimeout,
+		WriteTimeout:             req.WriteTimeout,
+		HealthCheckPath:          req.HealthCheckPath,
+		HealthCheckInterval:      req.HealthCheckInterval,
+		CircuitBreakerEnabled:    req.CircuitBreakerEnabled,
+		CircuitBreakerThreshold:  req.CircuitBreakerThreshold,
+		CircuitBreakerTimeout:    req.CircuitBreakerTimeout,
+		RetryOnStatusCodes:       req.RetryOnStatusCodes,
+		RetryOnErrors:            req.RetryOnErrors,
+		RetryPerTryTimeoutMillis: req.RetryPerTryTimeoutMillis,
+
+		CircuitBreakerScope:          req.CircuitBreakerScope,
+		CircuitBreakerWindowType:     req.CircuitBreakerWindowType,
+		CircuitBreakerWindowSize:     req.CircuitBreakerWindowSize,
+		CircuitBreakerMinimumCalls:   req.CircuitBreakerMinimumCalls,
+		CircuitBreakerFailureRate:    req.CircuitBreakerFailureRate,
+		CircuitBreakerSlowCallRate:   req.CircuitBreakerSlowCallRate,
+		CircuitBreakerSlowCallMillis: req.CircuitBreakerSlowCallMillis,
+		// Load Balancing Fields
+		Targets:               storedTargets(req.Targets),
+		LoadBalancingStrategy: req.LoadBalancingStrategy,
+		HashKey:               req.HashKey,
+		// Response Header Fields
+		ResponseHeadersAllow: req.ResponseHeadersAllow,
+		ResponseHeadersDeny:  req.ResponseHeadersDeny,
 		// Auth Fields
 		AuthType:     req.AuthType,
 		AuthUsername: req.AuthUsername,
@@ -1901,6 +2100,13 @@ func (s *APIConfigService) GetByIDURLConfig(ctx context.Context, id uint) (*dto.
 		GRPCService: getStringValue(res.GRPCService),
 		ProtoFile:   getStringValue(res.ProtoFile),
 		TLSEnabled:  res.TLSEnabled,
+		BasePath:    res.BasePath,
+
+		Targets:               decodeTargets(res.Targets),
+		LoadBalancingStrategy: res.LoadBalancingStrategy,
+		HashKey:               res.HashKey,
+		ResponseHeadersAllow:  res.ResponseHeadersAllow,
+		ResponseHeadersDeny:   res.ResponseHeadersDeny,
 	}
 
 	logger.GetLogger().Info("Service: URL config retrieved successfully",
@@ -2001,6 +2207,13 @@ func (s *APIConfigService) GetAllURLConfig(params any) ([]dto.URLConfigResponse,
 			GRPCService: getStringValue(data.GRPCService),
 			ProtoFile:   getStringValue(data.ProtoFile),
 			TLSEnabled:  data.TLSEnabled,
+			BasePath:    data.BasePath,
+
+			Targets:               decodeTargets(data.Targets),
+			LoadBalancingStrategy: data.LoadBalancingStrategy,
+			HashKey:               data.HashKey,
+			ResponseHeadersAllow:  data.ResponseHeadersAllow,
+			ResponseHeadersDeny:   data.ResponseHeadersDeny,
 		})
 	}
 
@@ -2016,6 +2229,40 @@ func (s *APIConfigService) UpdateURLConfig(ctx context.Context, id uint, req dto
 		return http.StatusRequestTimeout, err
 	}
 
+	basePath, err := normalizeBasePath(req.BasePath)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	req.BasePath = basePath
+
+	if err := validateTargets(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if req.LoadBalancingStrategy == "" {
+		req.LoadBalancingStrategy = balancer.RoundRobin
+	}
+	if err := validateCircuitBreaker(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := validateRetry(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := validateResponseHeaders(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if req.RetryOnStatusCodes == "" {
+		req.RetryOnStatusCodes = "502,503,504"
+	}
+	if req.RetryOnErrors == "" {
+		req.RetryOnErrors = retry.ConnectFailure + "," + retry.Reset
+	}
+	if req.CircuitBreakerScope == "" {
+		req.CircuitBreakerScope = integrasi.BreakerScopeUpstream
+	}
+	if req.CircuitBreakerWindowType == "" {
+		req.CircuitBreakerWindowType = circuit.WindowConsecutive
+	}
+
 	urlConfig := &model.URLConfig{
 		Nama:        req.Nama,
 		Protocol:    req.Protocol,
@@ -2025,6 +2272,36 @@ func (s *APIConfigService) UpdateURLConfig(ctx context.Context, id uint, req dto
 		GRPCService: stringPtr(req.GRPCService),
 		ProtoFile:   stringPtr(req.ProtoFile),
 		TLSEnabled:  req.TLSEnabled,
+		BasePath:    req.BasePath,
+		// Connection Pool, Health Check and Circuit Breaker Fields
+		MaxConnections:           req.MaxConnections,
+		MinIdleConnections:       req.MinIdleConnections,
+		ConnectionTimeout:        req.ConnectionTimeout,
+		ReadTimeout:              req.ReadTimeout,
+		WriteTimeout:             req.WriteTimeout,
+		HealthCheckPath:          req.HealthCheckPath,
+		HealthCheckInterval:      req.HealthCheckInterval,
+		CircuitBreakerEnabled:    req.CircuitBreakerEnabled,
+		CircuitBreakerThreshold:  req.CircuitBreakerThreshold,
+		CircuitBreakerTimeout:    req.CircuitBreakerTimeout,
+		RetryOnStatusCodes:       req.RetryOnStatusCodes,
+		RetryOnErrors:            req.RetryOnErrors,
+		RetryPerTryTimeoutMillis: req.RetryPerTryTimeoutMillis,
+
+		CircuitBreakerScope:          req.CircuitBreakerScope,
+		CircuitBreakerWindowType:     req.CircuitBreakerWindowType,
+		CircuitBreakerWindowSize:     req.CircuitBreakerWindowSize,
+		CircuitBreakerMinimumCalls:   req.CircuitBreakerMinimumCalls,
+		CircuitBreakerFailureRate:    req.CircuitBreakerFailureRate,
+		CircuitBreakerSlowCallRate:   req.CircuitBreakerSlowCallRate,
+		CircuitBreakerSlowCallMillis: req.CircuitBreakerSlowCallMillis,
+		// Load Balancing Fields
+		Targets:               storedTargets(req.Targets),
+		LoadBalancingStrategy: req.LoadBalancingStrategy,
+		HashKey:               req.HashKey,
+		// Response Header Fields
+		ResponseHeadersAllow: req.ResponseHeadersAllow,
+		ResponseHeadersDeny:  req.ResponseHeadersDeny,
 		// Auth Fields
 		AuthType:     req.AuthType,
 		AuthUsername: req.AuthUsername,
@@ -2044,11 +2321,27 @@ func (s *APIConfigService) UpdateURLConfig(ctx context.Context, id uint, req dto
 
 	urlConfig.ID = id
 
-	if err := s.repo.UpdateURLConfig(ctx, urlConfig); err != nil {
+	// Routes mounted under a new base path are identified by new predicate hashes
+	var routeHashes map[uint]string
+	if existing != nil && existing.BasePath != req.BasePath {
+		routes, err := s.repo.FindAllByURLConfigID(ctx, id)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		routeHashes = make(map[uint]string, len(routes))
+		for _, route := range routes {
+			routeHashes[route.ID] = storedPredicateHash(route, req.BasePath)
+		}
+	}
+
+	if err := s.repo.UpdateURLConfig(ctx, urlConfig, routeHashes); err != nil {
 		logger.GetLogger().Error("Service: Failed to update URL config",
 			zap.Uint("url_config_id", id),
 			zap.Error(err),
 		)
+		if isUniqueViolation(err) {
+			return http.StatusConflict, errors.New("a route of this URL config has the same path, method and match predicates as another route under the new base path")
+		}
 		return http.StatusInternalServerError, err
 	}
 
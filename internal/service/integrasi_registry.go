@@ -5,11 +5,17 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/internal/model"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // LoadAllActiveConfigs loads all API configs for route registry initialization
@@ -35,74 +41,346 @@ func (s *APIConfigService) LoadAllActiveConfigs(ctx context.Context) ([]*dto.API
 
 	// Convert to DTO
 	configs := make([]*dto.APIConfigResponse, 0, len(models))
-	for _, res := range models {
-		var headers map[string]string
-		var queryParams map[string]string
-		var variables map[string]dto.Variable
-		var rawBody interface{}
-
-		_ = json.Unmarshal(res.Headers, &headers)
-		_ = json.Unmarshal(res.QueryParams, &queryParams)
-		_ = json.Unmarshal(res.Variables, &variables)
-
-		if err := json.Unmarshal(res.Body, &rawBody); err != nil {
-			// error handling if needed
-		}
+	for i := range models {
+		configs = append(configs, toAPIConfigResponse(&models[i]))
+	}
+	s.resolveUpstreams(configs)
 
-		if bodyStr, ok := rawBody.(string); ok {
-			var bodyObj map[string]interface{}
-			if err := json.Unmarshal([]byte(bodyStr), &bodyObj); err == nil {
-				rawBody = bodyObj
-			}
+	logger.GetLogger().Info("Service: All active configs loaded successfully",
+		zap.Int("count", len(configs)),
+	)
+
+	return configs, nil
+}
+
+// storedURLConfigID is the url_config_id column of a config, NULL for routes without an upstream
+func storedURLConfigID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// urlConfigIDOf reads a stored url_config_id, 0 for routes without an upstream
+func urlConfigIDOf(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
+
+// toAPIConfigResponse converts an API config model into the response used by the route registry
+func toAPIConfigResponse(res *model.APIConfig) *dto.APIConfigResponse {
+	var headers map[string]string
+	var queryParams map[string]string
+	var variables map[string]dto.Variable
+	var rawBody interface{}
+
+	_ = json.Unmarshal(res.Headers, &headers)
+	_ = json.Unmarshal(res.QueryParams, &queryParams)
+	_ = json.Unmarshal(res.Variables, &variables)
+
+	if err := json.Unmarshal(res.Body, &rawBody); err != nil {
+		// error handling if needed
+	}
+
+	if bodyStr, ok := rawBody.(string); ok {
+		var bodyObj map[string]interface{}
+		if err := json.Unmarshal([]byte(bodyStr), &bodyObj); err == nil {
+			rawBody = bodyObj
 		}
+	}
+
+	body, _ := rawBody.(map[string]interface{})
+
+	// Combine base URL with URI to get complete URL
+	completeURL := res.URLConfig.URL
+	if res.URI != "" {
+		baseURL := strings.TrimSuffix(res.URLConfig.URL, "/")
+		uri := strings.TrimPrefix(res.URI, "/")
+		completeURL = baseURL + "/" + uri
+	}
+
+	resp := &dto.APIConfigResponse{
+		ID:               res.ID,
+		Path:             res.Path,
+		Protocol:         res.URLConfig.Protocol,
+		Method:           res.Method,
+		URLConfigID:      urlConfigIDOf(res.URLConfigID),
+		URI:              res.URI,
+		URL:              completeURL,
+		URLConfig:        toUpstreamConfig(&res.URLConfig),
+		Headers:          headers,
+		QueryParams:      queryParams,
+		Body:             body,
+		Variables:        variables,
+		MaxRetries:       res.MaxRetries,
+		RetryDelay:       res.RetryDelay,
+		Timeout:          res.Timeout,
+		Manipulation:     res.Manipulation,
+		Description:      res.Description,
+		IsAdmin:          res.IsAdmin,
+		Priority:         res.Priority,
+		AuthType:         res.AuthType,
+		AuthRequired:     res.AuthRequired,
+		AuthGRPCConfigID: res.AuthGRPCConfigID,
+		JWTSecretKey:     res.JWTSecretKey,
+		JWTIssuer:        res.JWTIssuer,
+		JWTAudience:      res.JWTAudience,
+		JWTAlgorithm:     res.JWTAlgorithm,
+		JWTExpiration:    res.JWTExpiration,
+		APIKeyHeader:     res.APIKeyHeader,
+		APIKeyLocation:   res.APIKeyLocation,
+	}
+
+	var basicAuthUsers []dto.BasicAuthUser
+	var apiKeys []dto.APIKey
+	_ = json.Unmarshal(res.BasicAuthUsers, &basicAuthUsers)
+	_ = json.Unmarshal(res.APIKeys, &apiKeys)
+	resp.BasicAuthUsers = basicAuthUsers
+	resp.APIKeys = apiKeys
+	decodeRoutingFields(resp, res)
+
+	return resp
+}
+
+// toUpstreamConfig converts a URL config model into the upstream a route sends its requests to, including the
+// targets, connection pool, circuit breaker and upstream authentication settings the executor applies
+func toUpstreamConfig(res *model.URLConfig) dto.URLConfigResponse {
+	return dto.URLConfigResponse{
+		ID:                           res.ID,
+		Nama:                         res.Nama,
+		Protocol:                     res.Protocol,
+		URL:                          res.URL,
+		Deskripsi:                    res.Deskripsi,
+		IsActive:                     res.IsActive,
+		GRPCService:                  getStringValue(res.GRPCService),
+		ProtoFile:                    getStringValue(res.ProtoFile),
+		TLSEnabled:                   res.TLSEnabled,
+		BasePath:                     res.BasePath,
+		MaxConnections:               res.MaxConnections,
+		MinIdleConnections:           res.MinIdleConnections,
+		ConnectionTimeout:            res.ConnectionTimeout,
+		ReadTimeout:                  res.ReadTimeout,
+		WriteTimeout:                 res.WriteTimeout,
+		HealthCheckPath:              res.HealthCheckPath,
+		HealthCheckInterval:          res.HealthCheckInterval,
+		CircuitBreakerEnabled:        res.CircuitBreakerEnabled,
+		CircuitBreakerThreshold:      res.CircuitBreakerThreshold,
+		CircuitBreakerTimeout:        res.CircuitBreakerTimeout,
+		RetryOnStatusCodes:           res.RetryOnStatusCodes,
+		RetryOnErrors:                res.RetryOnErrors,
+		RetryPerTryTimeoutMillis:     res.RetryPerTryTimeoutMillis,
+		CircuitBreakerScope:          res.CircuitBreakerScope,
+		CircuitBreakerWindowType:     res.CircuitBreakerWindowType,
+		CircuitBreakerWindowSize:     res.CircuitBreakerWindowSize,
+		CircuitBreakerMinimumCalls:   res.CircuitBreakerMinimumCalls,
+		CircuitBreakerFailureRate:    res.CircuitBreakerFailureRate,
+		CircuitBreakerSlowCallRate:   res.CircuitBreakerSlowCallRate,
+		CircuitBreakerSlowCallMillis: res.CircuitBreakerSlowCallMillis,
+		Targets:                      decodeTargets(res.Targets),
+		LoadBalancingStrategy:        res.LoadBalancingStrategy,
+		HashKey:                      res.HashKey,
+		ResponseHeadersAllow:         res.ResponseHeadersAllow,
+		ResponseHeadersDeny:          res.ResponseHeadersDeny,
+		AuthType:                     res.AuthType,
+		AuthUsername:                 res.AuthUsername,
+		AuthPassword:                 res.AuthPassword,
+		AuthToken:                    res.AuthToken,
+		AuthKey:                      res.AuthKey,
+		AuthValue:                    res.AuthValue,
+		AuthAddTo:                    res.AuthAddTo,
+	}
+}
+
+// decodeRoutingFields copies the settings the route registry and middleware need (match predicates,
+// schedule, route type, traffic split, mirror, hedge and streaming) onto resp
+// Variant and mirror upstreams are left unresolved, see resolveUpstreams
+func decodeRoutingFields(resp *dto.APIConfigResponse, res *model.APIConfig) {
+	var matchHeaders map[string]string
+	var matchQuery map[string]string
+	_ = json.Unmarshal(res.MatchHeaders, &matchHeaders)
+	_ = json.Unmarshal(res.MatchQuery, &matchQuery)
+
+	resp.MatchHost = res.MatchHost
+	resp.MatchHeaders = matchHeaders
+	resp.MatchQuery = matchQuery
+
+	var maintenanceSchedule []dto.MaintenanceWindow
+	var maintenanceBody interface{}
+	_ = json.Unmarshal(res.MaintenanceSchedule, &maintenanceSchedule)
+	_ = json.Unmarshal(res.MaintenanceBody, &maintenanceBody)
+
+	resp.ActiveFrom = res.ActiveFrom
+	resp.ActiveUntil = res.ActiveUntil
+	resp.MaintenanceSchedule = maintenanceSchedule
+	resp.MaintenanceStatus = res.MaintenanceStatus
+	resp.MaintenanceBody = maintenanceBody
+
+	var responseHeaders map[string]string
+	var responseBody interface{}
+	var mockResponses []dto.MockResponse
+	_ = json.Unmarshal(res.ResponseHeaders, &responseHeaders)
+	_ = json.Unmarshal(res.ResponseBody, &responseBody)
+	_ = json.Unmarshal(res.MockResponses, &mockResponses)
+
+	resp.RouteType = res.RouteType
+	if resp.RouteType == "" {
+		resp.RouteType = dto.RouteTypeProxy
+	}
+	resp.ResponseStatus = res.ResponseStatus
+	resp.ResponseHeaders = responseHeaders
+	resp.ResponseBody = responseBody
+	resp.RedirectStatus = res.RedirectStatus
+	resp.RedirectLocation = res.RedirectLocation
+	resp.MockResponses = mockResponses
+
+	var variants []dto.RouteVariant
+	_ = json.Unmarshal(res.Variants, &variants)
+	resp.Variants = variants
+	resp.SplitKey = res.SplitKey
+	resp.SplitOverrideHeader = res.SplitOverrideHeader
+
+	var mirror *dto.RouteMirror
+	_ = json.Unmarshal(res.Mirror, &mirror)
+	resp.Mirror = mirror
+
+	var hedge *dto.RouteHedge
+	_ = json.Unmarshal(res.Hedge, &hedge)
+	resp.Hedge = hedge
+	resp.Stream = res.Stream
+}
+
+// hasSamePredicates reports whether another config (not excludeID) already serves req's
+// path and method under the same base path with exactly the same match predicates
+func (s *APIConfigService) hasSamePredicates(ctx context.Context, req dto.APIConfigRequest, excludeID uint) (bool, error) {
+	existing, err := s.repo.FindAllByPathAndMethodConfig(ctx, req.Path, req.Method)
+	if err != nil {
+		return false, err
+	}
 
-		body, _ := rawBody.(map[string]interface{})
+	// Configs mounted under different URL config base paths never collide
+	basePath, err := s.routeBasePath(req.URLConfigID)
+	if err != nil {
+		return false, err
+	}
 
-		// Combine base URL with URI to get complete URL
-		completeURL := res.URLConfig.URL
-		if res.URI != "" {
-			baseURL := strings.TrimSuffix(res.URLConfig.URL, "/")
-			uri := strings.TrimPrefix(res.URI, "/")
-			completeURL = baseURL + "/" + uri
+	for i := range existing {
+		if existing[i].ID == excludeID || existing[i].URLConfig.BasePath != basePath {
+			continue
 		}
 
-		config := &dto.APIConfigResponse{
-			ID:          res.ID,
-			Path:        res.Path,
-			Protocol:    res.URLConfig.Protocol,
-			Method:      res.Method,
-			URLConfigID: res.URLConfigID,
-			URI:         res.URI,
-			URL:         completeURL,
-			URLConfig: dto.URLConfigResponse{
-				ID:          res.URLConfig.ID,
-				Nama:        res.URLConfig.Nama,
-				Protocol:    res.URLConfig.Protocol,
-				URL:         res.URLConfig.URL,
-				Deskripsi:   res.URLConfig.Deskripsi,
-				IsActive:    res.URLConfig.IsActive,
-				GRPCService: getStringValue(res.URLConfig.GRPCService),
-				ProtoFile:   getStringValue(res.URLConfig.ProtoFile),
-				TLSEnabled:  res.URLConfig.TLSEnabled,
-			},
-			Headers:      headers,
-			QueryParams:  queryParams,
-			Body:         body,
-			Variables:    variables,
-			MaxRetries:   res.MaxRetries,
-			RetryDelay:   res.RetryDelay,
-			Timeout:      res.Timeout,
-			Manipulation: res.Manipulation,
-			Description:  res.Description,
-			IsAdmin:      res.IsAdmin,
+		var matchHeaders map[string]string
+		var matchQuery map[string]string
+		_ = json.Unmarshal(existing[i].MatchHeaders, &matchHeaders)
+		_ = json.Unmarshal(existing[i].MatchQuery, &matchQuery)
+
+		if strings.EqualFold(existing[i].MatchHost, req.MatchHost) &&
+			sameStringMap(matchHeaders, req.MatchHeaders, true) &&
+			sameStringMap(matchQuery, req.MatchQuery, false) {
+			return true, nil
 		}
+	}
 
-		configs = append(configs, config)
+	return false, nil
+}
+
+// errUnknownURLConfig is returned for a url_config_id no URL config has
+var errUnknownURLConfig = errors.New("URL config not found")
+
+// referencedURLConfig loads the URL config field refers to: 400 when there is none, 500 when the lookup fails
+func (s *APIConfigService) referencedURLConfig(field string, urlConfigID uint) (*model.URLConfig, int, error) {
+	urlConfig, err := s.repo.GetByIDURLConfig(urlConfigID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, http.StatusBadRequest, fmt.Errorf("%s %d: %w", field, urlConfigID, errUnknownURLConfig)
+	}
+	if err != nil {
+		logger.GetLogger().Error("Service: Failed to get referenced URL config",
+			zap.String("field", field),
+			zap.Uint("url_config_id", urlConfigID),
+			zap.Error(err),
+		)
+		return nil, http.StatusInternalServerError, err
 	}
+	return urlConfig, http.StatusOK, nil
+}
 
-	logger.GetLogger().Info("Service: All active configs loaded successfully",
-		zap.Int("count", len(configs)),
-	)
+// routeBasePath is the base path of the URL config a route is mounted under, "" without one
+func (s *APIConfigService) routeBasePath(urlConfigID uint) (string, error) {
+	if urlConfigID == 0 {
+		return "", nil
+	}
+	urlConfig, err := s.repo.GetByIDURLConfig(urlConfigID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("url_config_id %d: %w", urlConfigID, errUnknownURLConfig)
+	}
+	if err != nil {
+		return "", err
+	}
+	return urlConfig.BasePath, nil
+}
 
-	return configs, nil
+// predicateHash is the stored model.HashPredicates of req, unique per path+method in the database
+func (s *APIConfigService) predicateHash(req dto.APIConfigRequest) (string, error) {
+	basePath, err := s.routeBasePath(req.URLConfigID)
+	if err != nil {
+		return "", err
+	}
+	return model.HashPredicates(basePath, req.MatchHost, req.MatchHeaders, req.MatchQuery), nil
+}
+
+// storedPredicateHash is the model.HashPredicates of a stored config mounted under basePath
+func storedPredicateHash(config model.APIConfig, basePath string) string {
+	var matchHeaders, matchQuery map[string]string
+	_ = json.Unmarshal(config.MatchHeaders, &matchHeaders)
+	_ = json.Unmarshal(config.MatchQuery, &matchQuery)
+	return model.HashPredicates(basePath, config.MatchHost, matchHeaders, matchQuery)
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation, such as a config
+// created concurrently with the same path, method and predicates
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// sameStringMap compares two predicate maps, optionally ignoring key case (header names)
+func sameStringMap(a, b map[string]string, foldKeys bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	normalize := func(m map[string]string) map[string]string {
+		out := make(map[string]string, len(m))
+		for k, v := range m {
+			if foldKeys {
+				k = strings.ToLower(k)
+			}
+			out[k] = v
+		}
+		return out
+	}
+
+	na, nb := normalize(a), normalize(b)
+	for k, v := range na {
+		if other, ok := nb[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeBasePath cleans a URL config base path to "/segment[/segment...]" or "" for the root
+// Base paths are static, parameters belong in the route paths mounted under them
+func normalizeBasePath(basePath string) (string, error) {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(basePath, "/") {
+		if segment == "" || strings.ContainsAny(segment, "{}*") {
+			return "", errors.New("base_path must be a static path like /pay")
+		}
+	}
+	return "/" + basePath, nil
 }
@@ -0,0 +1,46 @@
+package service
+
+// Extension to integrasi.go for streamed pass-through routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+// validateStream checks a streamed route is an HTTP proxy route sending its body only once
+// Caching and manipulation are left as configured, they're skipped while the route streams
+func (s *APIConfigService) validateStream(req dto.APIConfigRequest) (int, error) {
+	if !req.Stream {
+		return http.StatusOK, nil
+	}
+	if req.RouteType != "" && req.RouteType != dto.RouteTypeProxy {
+		return http.StatusBadRequest, errors.New("stream is only supported on proxy routes")
+	}
+	if req.Mirror != nil {
+		return http.StatusBadRequest, errors.New("stream can't be combined with mirror, the request body is only sent once")
+	}
+	if req.Hedge != nil {
+		return http.StatusBadRequest, errors.New("stream can't be combined with hedge, the request body is only sent once")
+	}
+
+	urlConfig, status, err := s.referencedURLConfig("url_config_id", req.URLConfigID)
+	if err != nil {
+		return status, err
+	}
+	if urlConfig.Protocol == "grpc" {
+		return http.StatusBadRequest, errors.New("stream is only supported on HTTP upstreams")
+	}
+	for _, variant := range req.Variants {
+		variantConfig, status, err := s.referencedURLConfig("variant "+variant.Name+" url_config_id", variant.URLConfigID)
+		if err != nil {
+			return status, err
+		}
+		if variantConfig.Protocol == "grpc" {
+			return http.StatusBadRequest, fmt.Errorf("stream is only supported on HTTP upstreams, variant %q is gRPC", variant.Name)
+		}
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,281 @@
+package service
+
+// Extension to integrasi.go for splitting a route's traffic between upstream variants,
+// mirroring it to a secondary upstream and hedging its slow requests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/traffic"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// validateVariants checks variant names, weights, upstreams and the split key, returning the status to answer with
+func (s *APIConfigService) validateVariants(req dto.APIConfigRequest) (int, error) {
+	if req.SplitKey != "" && !ValidSplitKey(req.SplitKey) {
+		return http.StatusBadRequest, fmt.Errorf("split_key must be header:<name>, cookie:<name>, query:<name>, user or ip, got %q", req.SplitKey)
+	}
+	if len(req.Variants) == 0 {
+		return http.StatusOK, nil
+	}
+	if req.RouteType != "" && req.RouteType != dto.RouteTypeProxy {
+		return http.StatusBadRequest, errors.New("variants are only supported on proxy routes")
+	}
+
+	names := make(map[string]bool, len(req.Variants))
+	total := 0
+	for i, variant := range req.Variants {
+		if variant.Name == "" || variant.Name == traffic.Primary {
+			return http.StatusBadRequest, fmt.Errorf("variant %d: name is required and %q is reserved for the route's own upstream", i, traffic.Primary)
+		}
+		if names[variant.Name] {
+			return http.StatusBadRequest, fmt.Errorf("variant %d: duplicate name %q", i, variant.Name)
+		}
+		names[variant.Name] = true
+
+		if variant.Weight < 0 || variant.Weight > 100 {
+			return http.StatusBadRequest, fmt.Errorf("variant %s: weight must be between 0 and 100, got %d", variant.Name, variant.Weight)
+		}
+		total += variant.Weight
+
+		if _, status, err := s.referencedURLConfig("variant "+variant.Name+" url_config_id", variant.URLConfigID); err != nil {
+			return status, err
+		}
+	}
+	if total > 100 {
+		return http.StatusBadRequest, fmt.Errorf("variant weights add up to %d, at most 100 is allowed", total)
+	}
+	return http.StatusOK, nil
+}
+
+// ValidSplitKey reports whether key is a supported sticky assignment key
+func ValidSplitKey(key string) bool {
+	switch key {
+	case "user", "ip":
+		return true
+	}
+	source, name, found := strings.Cut(key, ":")
+	if !found || strings.TrimSpace(name) == "" {
+		return false
+	}
+	switch source {
+	case "header", "cookie", "query":
+		return true
+	}
+	return false
+}
+
+// storedVariants drops the resolved upstream fields, only name, upstream ID and weight are stored
+func storedVariants(variants []dto.RouteVariant) []dto.RouteVariant {
+	stored := make([]dto.RouteVariant, 0, len(variants))
+	for _, variant := range variants {
+		stored = append(stored, dto.RouteVariant{
+			Name:        variant.Name,
+			URLConfigID: variant.URLConfigID,
+			Weight:      variant.Weight,
+		})
+	}
+	return stored
+}
+
+// validateMirror checks the mirror percent and upstream, returning the status to answer with
+func (s *APIConfigService) validateMirror(req dto.APIConfigRequest) (int, error) {
+	if req.Mirror == nil {
+		return http.StatusOK, nil
+	}
+	if req.RouteType != "" && req.RouteType != dto.RouteTypeProxy {
+		return http.StatusBadRequest, errors.New("mirror is only supported on proxy routes")
+	}
+	if req.Mirror.Percent < 0 || req.Mirror.Percent > 100 {
+		return http.StatusBadRequest, fmt.Errorf("mirror percent must be between 0 and 100, got %d", req.Mirror.Percent)
+	}
+	if req.Mirror.URLConfigID == req.URLConfigID {
+		return http.StatusBadRequest, errors.New("mirror url_config_id must differ from the route's own")
+	}
+	if _, status, err := s.referencedURLConfig("mirror url_config_id", req.Mirror.URLConfigID); err != nil {
+		return status, err
+	}
+	return http.StatusOK, nil
+}
+
+// storedMirror drops the resolved upstream fields, nil (SQL NULL) when the route isn't mirrored
+func storedMirror(mirror *dto.RouteMirror) datatypes.JSON {
+	if mirror == nil {
+		return nil
+	}
+	stored, _ := json.Marshal(dto.RouteMirror{
+		URLConfigID:  mirror.URLConfigID,
+		Percent:      mirror.Percent,
+		Compare:      mirror.Compare,
+		IgnoreFields: mirror.IgnoreFields,
+	})
+	return stored
+}
+
+// validateHedge checks the hedge delay, percentile and rate cap
+func validateHedge(req dto.APIConfigRequest) error {
+	if req.Hedge == nil {
+		return nil
+	}
+	if req.RouteType != "" && req.RouteType != dto.RouteTypeProxy {
+		return errors.New("hedge is only supported on proxy routes")
+	}
+	if req.Hedge.DelayMs < 0 {
+		return fmt.Errorf("hedge delay_ms must not be negative, got %d", req.Hedge.DelayMs)
+	}
+	if req.Hedge.Percentile < 0 || req.Hedge.Percentile > 99 {
+		return fmt.Errorf("hedge percentile must be between 1 and 99, got %d", req.Hedge.Percentile)
+	}
+	if req.Hedge.MaxPercent < 0 || req.Hedge.MaxPercent > 100 {
+		return fmt.Errorf("hedge max_percent must be between 0 and 100, got %g", req.Hedge.MaxPercent)
+	}
+	return nil
+}
+
+// storedHedge encodes the hedge settings, nil (SQL NULL) when the route isn't hedged
+func storedHedge(hedge *dto.RouteHedge) datatypes.JSON {
+	if hedge == nil {
+		return nil
+	}
+	stored, _ := json.Marshal(hedge)
+	return stored
+}
+
+// resolveUpstreams loads the upstream of every variant and of the mirror of configs
+// Variants whose URL config no longer exists are dropped, their weight goes back to the primary upstream;
+// a mirror whose URL config no longer exists is dropped as well
+func (s *APIConfigService) resolveUpstreams(configs []*dto.APIConfigResponse) {
+	urlConfigs := make(map[uint]*dto.URLConfigResponse)
+	lookup := func(id uint) *dto.URLConfigResponse {
+		if urlConfig, ok := urlConfigs[id]; ok {
+			return urlConfig
+		}
+		var resolved *dto.URLConfigResponse
+		if urlConfig, err := s.repo.GetByIDURLConfig(id); err == nil && urlConfig != nil {
+			upstream := toUpstreamConfig(urlConfig)
+			resolved = &upstream
+		}
+		urlConfigs[id] = resolved
+		return resolved
+	}
+
+	for _, config := range configs {
+		if config.Mirror != nil {
+			if urlConfig := lookup(config.Mirror.URLConfigID); urlConfig != nil {
+				config.Mirror.URLConfig = urlConfig
+				config.Mirror.URL = upstreamURL(urlConfig.URL, config.URI)
+			} else {
+				logger.GetLogger().Warn("Service: Mirror URL config not found, mirror skipped",
+					zap.String("path", config.Path),
+					zap.Uint("url_config_id", config.Mirror.URLConfigID),
+				)
+				config.Mirror = nil
+			}
+		}
+
+		if len(config.Variants) == 0 {
+			continue
+		}
+
+		resolved := make([]dto.RouteVariant, 0, len(config.Variants))
+		for _, variant := range config.Variants {
+			urlConfig := lookup(variant.URLConfigID)
+			if urlConfig == nil {
+				logger.GetLogger().Warn("Service: Variant URL config not found, variant skipped",
+					zap.String("path", config.Path),
+					zap.String("variant", variant.Name),
+					zap.Uint("url_config_id", variant.URLConfigID),
+				)
+				continue
+			}
+
+			variant.URLConfig = urlConfig
+			variant.URL = upstreamURL(urlConfig.URL, config.URI)
+			resolved = append(resolved, variant)
+		}
+		config.Variants = resolved
+	}
+}
+
+// upstreamURL joins an upstream's base URL and a route's URI
+func upstreamURL(base, uri string) string {
+	if uri == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(uri, "/")
+}
+
+// PromoteVariant makes a variant's upstream the route's own and ends the split
+func (s *APIConfigService) PromoteVariant(ctx context.Context, id uint, name string) (int, error) {
+	res, err := s.repo.GetByIDConfig(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, errors.New("API config not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	var variants []dto.RouteVariant
+	if len(res.Variants) > 0 {
+		if err := json.Unmarshal(res.Variants, &variants); err != nil {
+			return http.StatusInternalServerError, fmt.Errorf("failed to decode the route's variants: %w", err)
+		}
+	}
+	for _, variant := range variants {
+		if variant.Name != name {
+			continue
+		}
+
+		basePath, err := s.routeBasePath(variant.URLConfigID)
+		if errors.Is(err, errUnknownURLConfig) {
+			return http.StatusBadRequest, fmt.Errorf("variant %q: %w", name, err)
+		}
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if err := s.repo.UpdateTrafficSplit(ctx, id, variant.URLConfigID, []byte("[]"), storedPredicateHash(*res, basePath)); err != nil {
+			if isUniqueViolation(err) {
+				return http.StatusConflict, errors.New("an API config with this path, method and match predicates already exists under the variant's base path")
+			}
+			return http.StatusInternalServerError, err
+		}
+		logger.GetLogger().Info("Service: Variant promoted",
+			zap.Uint("config_id", id),
+			zap.String("variant", name),
+			zap.Uintp("old_url_config_id", res.URLConfigID),
+			zap.Uint("new_url_config_id", variant.URLConfigID),
+		)
+		return http.StatusOK, nil
+	}
+
+	return http.StatusNotFound, fmt.Errorf("variant %q not found", name)
+}
+
+// RollbackVariants removes every variant, the route's own upstream gets all traffic again
+func (s *APIConfigService) RollbackVariants(ctx context.Context, id uint) (int, error) {
+	res, err := s.repo.GetByIDConfig(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, errors.New("API config not found")
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := s.repo.UpdateTrafficSplit(ctx, id, urlConfigIDOf(res.URLConfigID), []byte("[]"), res.PredicateHash); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	logger.GetLogger().Info("Service: Variants rolled back",
+		zap.Uint("config_id", id),
+		zap.Uintp("url_config_id", res.URLConfigID),
+	)
+	return http.StatusOK, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+// Extension to integrasi.go for URL configs load balancing over several upstream targets,
+// the sliding window settings of their circuit breakers, their retry policy and the response headers they pass on
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
+	"github.com/Payphone-Digital/gateway/pkg/integrasi"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
+	"gorm.io/datatypes"
+)
+
+// headerNamePattern matches an HTTP header name (RFC 9110 token), optionally ending in * for a prefix
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'+.^_`|~-]+\\*?$")
+
+// validateTargets checks the target URLs and weights, the strategy and the consistent hash key
+func validateTargets(req dto.URLConfigRequest) error {
+	if !balancer.Valid(req.LoadBalancingStrategy) {
+		return fmt.Errorf("unsupported load_balancing_strategy %q", req.LoadBalancingStrategy)
+	}
+	if req.HashKey != "" && !ValidSplitKey(req.HashKey) {
+		return fmt.Errorf("hash_key must be header:<name>, cookie:<name>, query:<name>, user or ip, got %q", req.HashKey)
+	}
+
+	seen := make(map[string]bool, len(req.Targets))
+	for i, target := range req.Targets {
+		if target.URL == "" {
+			return fmt.Errorf("target %d: url is required", i)
+		}
+		if req.Protocol != "grpc" {
+			if u, err := url.Parse(target.URL); err != nil || u.Scheme == "" || u.Host == "" {
+				return fmt.Errorf("target %d: url must be absolute like http://10.0.0.2:8080, got %q", i, target.URL)
+			}
+		}
+		if seen[target.URL] {
+			return fmt.Errorf("target %d: duplicate url %q", i, target.URL)
+		}
+		seen[target.URL] = true
+
+		if target.Weight < 0 {
+			return fmt.Errorf("target %s: weight must not be negative, got %d", target.URL, target.Weight)
+		}
+	}
+	return nil
+}
+
+// validateCircuitBreaker checks the breaker scope, window and rate thresholds
+func validateCircuitBreaker(req dto.URLConfigRequest) error {
+	if !integrasi.ValidBreakerScope(req.CircuitBreakerScope) {
+		return fmt.Errorf("circuit_breaker_scope must be upstream or route, got %q", req.CircuitBreakerScope)
+	}
+	if !circuit.ValidWindowType(req.CircuitBreakerWindowType) {
+		return fmt.Errorf("circuit_breaker_window_type must be consecutive, count or time, got %q", req.CircuitBreakerWindowType)
+	}
+	if req.CircuitBreakerWindowSize < 0 || req.CircuitBreakerMinimumCalls < 0 || req.CircuitBreakerSlowCallMillis < 0 {
+		return fmt.Errorf("circuit_breaker_window_size, circuit_breaker_minimum_calls and circuit_breaker_slow_call_ms must not be negative")
+	}
+	for name, rate := range map[string]float64{
+		"circuit_breaker_failure_rate":   req.CircuitBreakerFailureRate,
+		"circuit_breaker_slow_call_rate": req.CircuitBreakerSlowCallRate,
+	} {
+		if rate < 0 || rate > 100 {
+			return fmt.Errorf("%s must be a percentage between 0 and 100, got %g", name, rate)
+		}
+	}
+	return nil
+}
+
+// validateRetry checks the retried status codes and error classes and the per-try timeout
+func validateRetry(req dto.URLConfigRequest) error {
+	for _, item := range strings.Split(req.RetryOnStatusCodes, ",") {
+		if item = strings.TrimSpace(item); item != "" && len(retry.ParseStatusCodes(item)) == 0 {
+			return fmt.Errorf("retry_on_status_codes must list status codes like 502,503,504, got %q", item)
+		}
+	}
+	for _, item := range strings.Split(req.RetryOnErrors, ",") {
+		if item = strings.TrimSpace(item); item != "" && !retry.ValidClass(item) {
+			return fmt.Errorf("retry_on_errors must list connect_failure, reset or timeout, got %q", item)
+		}
+	}
+	if req.RetryPerTryTimeoutMillis < 0 {
+		return fmt.Errorf("retry_per_try_timeout_ms must not be negative, got %d", req.RetryPerTryTimeoutMillis)
+	}
+	return nil
+}
+
+// validateResponseHeaders checks the allowed and denied response header lists name headers, a trailing * for a prefix
+func validateResponseHeaders(req dto.URLConfigRequest) error {
+	for field, list := range map[string]string{
+		"response_headers_allow": req.ResponseHeadersAllow,
+		"response_headers_deny":  req.ResponseHeadersDeny,
+	} {
+		for _, name := range integrasi.ParseHeaderNames(list) {
+			if !headerNamePattern.MatchString(name) {
+				return fmt.Errorf("%s must list header names like Set-Cookie or X-Internal-*, got %q", field, name)
+			}
+		}
+	}
+	return nil
+}
+
+// storedTargets encodes the targets for the jsonb column, an empty list when there are none
+func storedTargets(targets []dto.UpstreamTarget) datatypes.JSON {
+	if targets == nil {
+		targets = []dto.UpstreamTarget{}
+	}
+	stored, _ := json.Marshal(targets)
+	return stored
+}
+
+// decodeTargets decodes the targets of the jsonb column, nil when there are none
+func decodeTargets(raw datatypes.JSON) []dto.UpstreamTarget {
+	var targets []dto.UpstreamTarget
+	_ = json.Unmarshal(raw, &targets)
+	if len(targets) == 0 {
+		return nil
+	}
+	return targets
+}
@@ -0,0 +1,209 @@
+package service
+
+// Extension to integrasi.go for dry-run validation of path configs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/schedule"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// templateVarPattern matches {{name}} placeholders resolved from Variables
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// builtinTemplateVars are resolved from the request context without being declared
+var builtinTemplateVars = map[string]bool{
+	"current_date": true,
+	"current_user": true,
+}
+
+// ValidateConfigRequest checks the parts of a config that don't depend on the route registry
+// Returns template variables referenced but not declared, and whether URLConfigID is unknown
+func (s *APIConfigService) ValidateConfigRequest(ctx context.Context, req dto.APIConfigRequest) ([]string, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	undeclared := UndeclaredTemplateVariables(req)
+
+	// Static, redirect and mock routes may leave url_config_id out
+	unknownURLConfig := false
+	routeType := strings.ToLower(strings.TrimSpace(req.RouteType))
+	if req.URLConfigID != 0 || routeType == "" || routeType == dto.RouteTypeProxy {
+		_, err := s.repo.GetByIDURLConfig(req.URLConfigID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			unknownURLConfig = true
+		case err != nil:
+			return nil, false, err
+		}
+	}
+
+	logger.GetLogger().Info("Service: Path config validated",
+		zap.String("path", req.Path),
+		zap.String("method", req.Method),
+		zap.Strings("undeclared_variables", undeclared),
+		zap.Bool("unknown_url_config_id", unknownURLConfig),
+	)
+
+	return undeclared, unknownURLConfig, nil
+}
+
+// UndeclaredTemplateVariables lists {{var}} placeholders in URI, headers, query params, body,
+// static response, redirect location and variable values that are neither declared in Variables,
+// path params, nor built-in
+func UndeclaredTemplateVariables(req dto.APIConfigRequest) []string {
+	declared := make(map[string]bool, len(req.Variables))
+	for name := range req.Variables {
+		declared[name] = true
+	}
+	for _, name := range pathParamNames(req.Path) {
+		declared[name] = true
+	}
+
+	referenced := make(map[string]bool)
+	collect := func(text string) {
+		for _, match := range templateVarPattern.FindAllStringSubmatch(text, -1) {
+			referenced[match[1]] = true
+		}
+	}
+
+	collect(req.URI)
+	for _, value := range req.Headers {
+		collect(value)
+	}
+	for _, value := range req.QueryParams {
+		collect(value)
+	}
+	collectBodyTemplates(req.Body, collect)
+	collect(req.RedirectLocation)
+	for _, value := range req.ResponseHeaders {
+		collect(value)
+	}
+	collectBodyTemplates(req.ResponseBody, collect)
+	for _, variable := range req.Variables {
+		if value, ok := variable.Value.(string); ok {
+			collect(value)
+		}
+	}
+
+	undeclared := make([]string, 0)
+	for name := range referenced {
+		if !declared[name] && !builtinTemplateVars[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	return undeclared
+}
+
+// collectBodyTemplates walks a JSON body and passes every string to collect
+func collectBodyTemplates(value interface{}, collect func(string)) {
+	switch v := value.(type) {
+	case string:
+		collect(v)
+	case map[string]interface{}:
+		for _, item := range v {
+			collectBodyTemplates(item, collect)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectBodyTemplates(item, collect)
+		}
+	}
+}
+
+// pathParamNames returns the params a route path exposes to templates
+// Example: "/v1/{id:int}/*/{rest...}" -> ["id", "wildcard", "rest"]
+func pathParamNames(path string) []string {
+	names := make([]string, 0)
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		switch {
+		case segment == "*" || segment == "**":
+			names = append(names, "wildcard")
+		case len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}':
+			name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+			if idx := strings.Index(name, ":"); idx >= 0 {
+				name = name[:idx]
+			}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// validateSchedule checks the activation window, maintenance windows and maintenance status
+func validateSchedule(req dto.APIConfigRequest) error {
+	if req.ActiveFrom != nil && req.ActiveUntil != nil && !req.ActiveFrom.Before(*req.ActiveUntil) {
+		return errors.New("active_from must be before active_until")
+	}
+	if req.MaintenanceStatus != 0 && (req.MaintenanceStatus < 400 || req.MaintenanceStatus > 599) {
+		return fmt.Errorf("maintenance_status must be a 4xx or 5xx status, got %d", req.MaintenanceStatus)
+	}
+	return schedule.Validate(req.MaintenanceSchedule)
+}
+
+// normalizeRouteType defaults and checks the route type and the settings it requires
+func normalizeRouteType(req *dto.APIConfigRequest) error {
+	req.RouteType = strings.ToLower(strings.TrimSpace(req.RouteType))
+	if req.RouteType == "" {
+		req.RouteType = dto.RouteTypeProxy
+	}
+
+	switch req.RouteType {
+	case dto.RouteTypeProxy:
+		if req.URLConfigID == 0 {
+			return errors.New("url_config_id is required for proxy routes")
+		}
+	case dto.RouteTypeStatic:
+		if req.ResponseStatus == 0 {
+			req.ResponseStatus = http.StatusOK
+		}
+		if req.ResponseStatus < 100 || req.ResponseStatus > 599 {
+			return fmt.Errorf("response_status must be a valid HTTP status, got %d", req.ResponseStatus)
+		}
+	case dto.RouteTypeRedirect:
+		if req.RedirectStatus == 0 {
+			req.RedirectStatus = http.StatusFound
+		}
+		switch req.RedirectStatus {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return fmt.Errorf("redirect_status must be 301, 302, 307 or 308, got %d", req.RedirectStatus)
+		}
+		if strings.TrimSpace(req.RedirectLocation) == "" {
+			return errors.New("redirect_location is required for redirect routes")
+		}
+	case dto.RouteTypeMock:
+		if len(req.MockResponses) == 0 {
+			return errors.New("mock_responses requires at least one response for mock routes")
+		}
+		names := make(map[string]bool, len(req.MockResponses))
+		for i := range req.MockResponses {
+			mock := &req.MockResponses[i]
+			if mock.Status == 0 {
+				mock.Status = http.StatusOK
+			}
+			if mock.Status < 100 || mock.Status > 599 {
+				return fmt.Errorf("mock response %d: status must be a valid HTTP status, got %d", i, mock.Status)
+			}
+			if mock.Name != "" && names[mock.Name] {
+				return fmt.Errorf("mock response %d: duplicate name %q", i, mock.Name)
+			}
+			names[mock.Name] = true
+		}
+	default:
+		return fmt.Errorf("route_type must be proxy, static, redirect or mock, got %q", req.RouteType)
+	}
+	return nil
+}
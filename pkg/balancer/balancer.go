@@ -0,0 +1,211 @@
+package balancer
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Strategies spreading an upstream's requests over its targets
+const (
+	RoundRobin       = "round_robin"       // Targets in turn, weights ignored
+	Weighted         = "weighted"          // Smooth weighted round robin, a weight 3 target gets 3 requests per 1 of a weight 1 target
+	LeastConnections = "least_connections" // Fewest requests in flight relative to weight
+	Random           = "random"            // Random, proportional to weight
+	ConsistentHash   = "consistent_hash"   // Same key, same target; removing a target only moves its own keys
+)
+
+// ErrNoHealthyTarget is returned when every target of an upstream is unhealthy
+var ErrNoHealthyTarget = errors.New("no healthy upstream target")
+
+// Target is one instance of an upstream
+type Target struct {
+	Address string
+	Weight  int // <= 0 counts as 1
+}
+
+func (t Target) weight() int {
+	if t.Weight <= 0 {
+		return 1
+	}
+	return t.Weight
+}
+
+// Valid reports whether strategy is supported, empty means round robin
+func Valid(strategy string) bool {
+	switch strategy {
+	case "", RoundRobin, Weighted, LeastConnections, Random, ConsistentHash:
+		return true
+	}
+	return false
+}
+
+// Balancer picks the target serving each request to an upstream
+// It keeps the round robin position and smooth weights per upstream and the requests in flight per target
+type Balancer struct {
+	mu        sync.Mutex
+	positions map[string]uint64
+	current   map[string]map[string]int
+	inFlight  map[string]int
+}
+
+// New creates a balancer
+func New() *Balancer {
+	return &Balancer{
+		positions: make(map[string]uint64),
+		current:   make(map[string]map[string]int),
+		inFlight:  make(map[string]int),
+	}
+}
+
+// Pick returns the target of upstream serving a request, among the targets healthy reports as such
+// key is only used by ConsistentHash, an empty key picks at random. A nil healthy treats every target as healthy
+func (b *Balancer) Pick(upstream, strategy string, targets []Target, key string, healthy func(address string) bool) (Target, error) {
+	candidates := make([]Target, 0, len(targets))
+	for _, target := range targets {
+		if healthy == nil || healthy(target.Address) {
+			candidates = append(candidates, target)
+		}
+	}
+	if len(candidates) == 0 {
+		return Target{}, ErrNoHealthyTarget
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	switch strategy {
+	case Weighted:
+		return b.weighted(upstream, candidates), nil
+	case LeastConnections:
+		return b.leastConnections(candidates), nil
+	case Random:
+		return random(candidates), nil
+	case ConsistentHash:
+		if key == "" {
+			return random(candidates), nil
+		}
+		return consistentHash(candidates, key), nil
+	default:
+		return b.roundRobin(upstream, candidates), nil
+	}
+}
+
+// Acquire counts a request in flight to address until the returned function is called
+func (b *Balancer) Acquire(address string) func() {
+	b.mu.Lock()
+	b.inFlight[address]++
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if b.inFlight[address]--; b.inFlight[address] <= 0 {
+				delete(b.inFlight, address)
+			}
+		})
+	}
+}
+
+// InFlight returns the requests in flight per target address
+func (b *Balancer) InFlight() map[string]int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	inFlight := make(map[string]int, len(b.inFlight))
+	for address, count := range b.inFlight {
+		inFlight[address] = count
+	}
+	return inFlight
+}
+
+func (b *Balancer) roundRobin(upstream string, candidates []Target) Target {
+	b.mu.Lock()
+	position := b.positions[upstream]
+	b.positions[upstream]++
+	b.mu.Unlock()
+
+	return candidates[position%uint64(len(candidates))]
+}
+
+// weighted is nginx' smooth weighted round robin: every pick raises each target's current weight by
+// its weight and lowers the winner's by the total, spreading a heavy target's turns instead of bunching them
+func (b *Balancer) weighted(upstream string, candidates []Target) Target {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	current, ok := b.current[upstream]
+	if !ok {
+		current = make(map[string]int)
+		b.current[upstream] = current
+	}
+
+	total := 0
+	best := -1
+	for i, target := range candidates {
+		current[target.Address] += target.weight()
+		total += target.weight()
+		if best < 0 || current[target.Address] > current[candidates[best].Address] {
+			best = i
+		}
+	}
+	current[candidates[best].Address] -= total
+	return candidates[best]
+}
+
+// leastConnections picks the target with the fewest requests in flight per unit of weight,
+// ties go to the first such target from a random start so idle upstreams don't pin one target
+func (b *Balancer) leastConnections(candidates []Target) Target {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	start := rand.Intn(len(candidates))
+	best := candidates[start]
+	for i := 1; i < len(candidates); i++ {
+		target := candidates[(start+i)%len(candidates)]
+		// inFlight(target)/weight(target) < inFlight(best)/weight(best)
+		if b.inFlight[target.Address]*best.weight() < b.inFlight[best.Address]*target.weight() {
+			best = target
+		}
+	}
+	return best
+}
+
+func random(candidates []Target) Target {
+	total := 0
+	for _, target := range candidates {
+		total += target.weight()
+	}
+	n := rand.Intn(total)
+	for _, target := range candidates {
+		if n -= target.weight(); n < 0 {
+			return target
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// consistentHash is weighted rendezvous hashing: every target scores the key and the highest score wins,
+// so a key only moves when its target goes away or a new target outscores it
+func consistentHash(candidates []Target, key string) Target {
+	best := candidates[0]
+	bestScore := math.Inf(-1)
+	for _, target := range candidates {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(target.Address))
+
+		// Uniform in (0, 1), then -w/ln(u) makes the win probability proportional to the weight
+		u := (float64(h.Sum64()>>11) + 0.5) / (1 << 53)
+		score := -float64(target.weight()) / math.Log(u)
+		if score > bestScore {
+			best, bestScore = target, score
+		}
+	}
+	return best
+}
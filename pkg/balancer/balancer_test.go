@@ -0,0 +1,130 @@
+package balancer
+
+import (
+	"fmt"
+	"testing"
+)
+
+var targets = []Target{
+	{Address: "http://10.0.0.1", Weight: 1},
+	{Address: "http://10.0.0.2", Weight: 1},
+	{Address: "http://10.0.0.3", Weight: 1},
+}
+
+func TestValid(t *testing.T) {
+	for _, strategy := range []string{"", RoundRobin, Weighted, LeastConnections, Random, ConsistentHash} {
+		if !Valid(strategy) {
+			t.Errorf("Expected %q to be valid", strategy)
+		}
+	}
+	if Valid("fastest") {
+		t.Error("Expected unknown strategy to be invalid")
+	}
+}
+
+func TestPick_RoundRobin(t *testing.T) {
+	b := New()
+
+	for i := 0; i < 6; i++ {
+		got, err := b.Pick("upstream", RoundRobin, targets, "", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected := targets[i%3].Address; got.Address != expected {
+			t.Errorf("Pick %d = %s, expected %s", i, got.Address, expected)
+		}
+	}
+}
+
+func TestPick_SkipsUnhealthy(t *testing.T) {
+	b := New()
+	healthy := func(address string) bool { return address != "http://10.0.0.2" }
+
+	for i := 0; i < 10; i++ {
+		got, err := b.Pick("upstream", RoundRobin, targets, "", healthy)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got.Address == "http://10.0.0.2" {
+			t.Fatal("Unhealthy target was picked")
+		}
+	}
+
+	if _, err := b.Pick("upstream", RoundRobin, targets, "", func(string) bool { return false }); err != ErrNoHealthyTarget {
+		t.Errorf("Expected ErrNoHealthyTarget, got %v", err)
+	}
+}
+
+func TestPick_Weighted(t *testing.T) {
+	b := New()
+	weighted := []Target{{Address: "a", Weight: 3}, {Address: "b", Weight: 1}}
+
+	var sequence string
+	for i := 0; i < 8; i++ {
+		got, _ := b.Pick("upstream", Weighted, weighted, "", nil)
+		sequence += got.Address
+	}
+	// Smooth: the heavy target's turns are spread, not bunched
+	if sequence != "aabaaaba" {
+		t.Errorf("Expected sequence aabaaaba, got %s", sequence)
+	}
+}
+
+func TestPick_LeastConnections(t *testing.T) {
+	b := New()
+	release1 := b.Acquire("http://10.0.0.1")
+	release2 := b.Acquire("http://10.0.0.2")
+
+	for i := 0; i < 10; i++ {
+		got, _ := b.Pick("upstream", LeastConnections, targets, "", nil)
+		if got.Address != "http://10.0.0.3" {
+			t.Fatalf("Expected idle target, got %s", got.Address)
+		}
+	}
+
+	release1()
+	release1() // Releasing twice counts once
+	release2()
+	if inFlight := b.InFlight(); len(inFlight) != 0 {
+		t.Errorf("Expected nothing in flight, got %v", inFlight)
+	}
+}
+
+func TestPick_ConsistentHash(t *testing.T) {
+	b := New()
+
+	assigned := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		got, _ := b.Pick("upstream", ConsistentHash, targets, key, nil)
+		again, _ := b.Pick("upstream", ConsistentHash, targets, key, nil)
+		if got != again {
+			t.Fatalf("Key %s moved from %s to %s", key, got.Address, again.Address)
+		}
+		assigned[key] = got.Address
+	}
+
+	// Taking a target out only moves the keys it had
+	healthy := func(address string) bool { return address != "http://10.0.0.3" }
+	for key, before := range assigned {
+		after, _ := b.Pick("upstream", ConsistentHash, targets, key, healthy)
+		if before != "http://10.0.0.3" && after.Address != before {
+			t.Errorf("Key %s moved from %s to %s though its target stayed healthy", key, before, after.Address)
+		}
+	}
+}
+
+func TestPick_Random(t *testing.T) {
+	b := New()
+	weighted := []Target{{Address: "a", Weight: 9}, {Address: "b", Weight: 1}}
+
+	counts := make(map[string]int)
+	const total = 10000
+	for i := 0; i < total; i++ {
+		got, _ := b.Pick("upstream", Random, weighted, "", nil)
+		counts[got.Address]++
+	}
+	if share := float64(counts["a"]) / total; share < 0.85 || share > 0.95 {
+		t.Errorf("Expected about 90%% on a, got %.2f", share)
+	}
+}
@@ -12,9 +12,11 @@ import (
 type State int
 
 const (
-	StateClosed   State = iota // Normal operation - requests pass through
-	StateOpen                  // Circuit is open - requests fail fast
-	StateHalfOpen              // Testing if service recovered
+	StateClosed       State = iota // Normal operation - requests pass through
+	StateOpen                      // Circuit is open - requests fail fast
+	StateHalfOpen                  // Testing if service recovered
+	StateForcedOpen                // Held open by an operator - requests fail fast until released
+	StateForcedClosed              // Held closed by an operator - requests pass whatever their outcome
 )
 
 func (s State) String() string {
@@ -25,6 +27,10 @@ func (s State) String() string {
 		return "OPEN"
 	case StateHalfOpen:
 		return "HALF_OPEN"
+	case StateForcedOpen:
+		return "FORCED_OPEN"
+	case StateForcedClosed:
+		return "FORCED_CLOSED"
 	default:
 		return "UNKNOWN"
 	}
@@ -42,6 +48,14 @@ type Config struct {
 	Timeout          time.Duration // Time to wait before half-open
 	SuccessThreshold int           // Successes needed to close from half-open
 	MaxHalfOpen      int           // Max concurrent requests in half-open
+
+	// Sliding window, Threshold is only used by the consecutive window type
+	WindowType            string        // consecutive (default), count or time
+	WindowSize            int           // Calls (count) or seconds (time) in the window
+	MinimumCalls          int           // Calls in the window before rates can open the circuit
+	FailureRateThreshold  float64       // Percentage of failed calls opening the circuit, 0 = off
+	SlowCallRateThreshold float64       // Percentage of slow calls opening the circuit, 0 = off
+	SlowCallDuration      time.Duration // Calls taking at least this long are slow
 }
 
 // DefaultConfig returns sensible defaults
@@ -62,6 +76,8 @@ type Breaker struct {
 	successes        int
 	halfOpenRequests int
 	lastFailure      time.Time
+	openedAt         time.Time
+	window           *window
 	config           Config
 	logger           *zap.Logger
 	name             string
@@ -75,12 +91,18 @@ func NewBreaker(name string, config Config, logger *zap.Logger) *Breaker {
 
 	return &Breaker{
 		state:  StateClosed,
+		window: newWindow(config.WindowType, config.WindowSize),
 		config: config,
 		logger: logger,
 		name:   name,
 	}
 }
 
+// windowed reports whether the breaker opens on rates over a sliding window (must hold lock)
+func (b *Breaker) windowed() bool {
+	return b.config.WindowType == WindowCount || b.config.WindowType == WindowTime
+}
+
 // Execute wraps a function with circuit breaker logic
 func (b *Breaker) Execute(fn func() error) error {
 	if err := b.Allow(); err != nil {
@@ -103,7 +125,7 @@ func (b *Breaker) Allow() error {
 
 	case StateOpen:
 		// Check if timeout has passed
-		if time.Since(b.lastFailure) >= b.config.Timeout {
+		if time.Since(b.openedAt) >= b.config.Timeout {
 			b.transitionTo(StateHalfOpen)
 			b.halfOpenRequests = 1
 			return nil
@@ -117,6 +139,9 @@ func (b *Breaker) Allow() error {
 		b.halfOpenRequests++
 		return nil
 
+	case StateForcedOpen:
+		return ErrCircuitOpen
+
 	default:
 		return nil
 	}
@@ -124,25 +149,39 @@ func (b *Breaker) Allow() error {
 
 // Record records the result of a request
 func (b *Breaker) Record(err error) {
+	b.RecordCall(err, 0)
+}
+
+// RecordCall records the result of a request that took duration, for slow call rates
+func (b *Breaker) RecordCall(err error, duration time.Duration) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if err != nil {
-		b.recordFailure()
+	now := time.Now()
+	slow := b.config.SlowCallRateThreshold > 0 && b.config.SlowCallDuration > 0 && duration >= b.config.SlowCallDuration
+	if b.windowed() && b.state == StateClosed {
+		b.window.record(now, err != nil, slow)
+	}
+
+	// A slow call while testing recovery fails the test as much as an error does
+	if err != nil || (slow && b.state == StateHalfOpen) {
+		b.recordFailure(now)
 	} else {
-		b.recordSuccess()
+		b.recordSuccess(now)
 	}
 }
 
 // recordFailure handles a failure (must hold lock)
-func (b *Breaker) recordFailure() {
+func (b *Breaker) recordFailure(now time.Time) {
 	b.failures++
 	b.successes = 0
-	b.lastFailure = time.Now()
+	b.lastFailure = now
 
 	switch b.state {
 	case StateClosed:
-		if b.failures >= b.config.Threshold {
+		if b.windowed() {
+			b.evaluateWindow(now)
+		} else if b.failures >= b.config.Threshold {
 			b.transitionTo(StateOpen)
 		}
 
@@ -153,7 +192,7 @@ func (b *Breaker) recordFailure() {
 }
 
 // recordSuccess handles a success (must hold lock)
-func (b *Breaker) recordSuccess() {
+func (b *Breaker) recordSuccess(now time.Time) {
 	b.failures = 0
 
 	switch b.state {
@@ -165,6 +204,32 @@ func (b *Breaker) recordSuccess() {
 
 	case StateClosed:
 		b.successes++
+		if b.windowed() {
+			// Slow successes count towards the slow call rate
+			b.evaluateWindow(now)
+		}
+	}
+}
+
+// evaluateWindow opens the circuit once the window holds enough calls and either rate
+// reaches its threshold (must hold lock)
+func (b *Breaker) evaluateWindow(now time.Time) {
+	calls, failures, slow := b.window.totals(now)
+	if calls == 0 || calls < b.config.MinimumCalls {
+		return
+	}
+
+	failureRate := float64(failures) * 100 / float64(calls)
+	slowCallRate := float64(slow) * 100 / float64(calls)
+	if (b.config.FailureRateThreshold > 0 && failureRate >= b.config.FailureRateThreshold) ||
+		(b.config.SlowCallRateThreshold > 0 && slowCallRate >= b.config.SlowCallRateThreshold) {
+		b.logger.Warn("Circuit breaker rate threshold reached",
+			zap.String("name", b.name),
+			zap.Int("calls", calls),
+			zap.Float64("failure_rate", failureRate),
+			zap.Float64("slow_call_rate", slowCallRate),
+		)
+		b.transitionTo(StateOpen)
 	}
 }
 
@@ -174,9 +239,14 @@ func (b *Breaker) transitionTo(newState State) {
 	b.state = newState
 	b.halfOpenRequests = 0
 
-	if newState == StateClosed {
+	switch newState {
+	case StateClosed:
 		b.failures = 0
 		b.successes = 0
+		b.window.reset()
+	case StateOpen:
+		b.openedAt = time.Now()
+		b.window.reset()
 	}
 
 	b.logger.Info("Circuit breaker state changed",
@@ -194,11 +264,25 @@ func (b *Breaker) State() State {
 	return b.state
 }
 
-// IsOpen returns true if circuit is open
+// IsOpen returns true if circuit is open, by itself or forced
 func (b *Breaker) IsOpen() bool {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return b.state == StateOpen
+	return b.state == StateOpen || b.state == StateForcedOpen
+}
+
+// ForceOpen holds the circuit open, rejecting every request until ForceClose or Reset
+func (b *Breaker) ForceOpen() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.transitionTo(StateForcedOpen)
+}
+
+// ForceClose holds the circuit closed, letting every request through until ForceOpen or Reset
+func (b *Breaker) ForceClose() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.transitionTo(StateForcedClosed)
 }
 
 // Stats returns circuit breaker statistics
@@ -206,7 +290,7 @@ func (b *Breaker) Stats() map[string]interface{} {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	return map[string]interface{}{
+	stats := map[string]interface{}{
 		"name":         b.name,
 		"state":        b.state.String(),
 		"failures":     b.failures,
@@ -215,9 +299,41 @@ func (b *Breaker) Stats() map[string]interface{} {
 		"threshold":    b.config.Threshold,
 		"timeout":      b.config.Timeout.String(),
 	}
+
+	if b.windowed() {
+		calls, failures, slow := b.window.totals(time.Now())
+		stats["window_type"] = b.config.WindowType
+		stats["window_size"] = b.config.WindowSize
+		stats["window_calls"] = calls
+		if calls > 0 {
+			stats["failure_rate"] = float64(failures) * 100 / float64(calls)
+			stats["slow_call_rate"] = float64(slow) * 100 / float64(calls)
+		}
+	}
+	return stats
 }
 
-// Reset resets the circuit breaker to closed state
+// Configure replaces the breaker's configuration, keeping its current state and counters
+func (b *Breaker) Configure(config Config) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.config == config {
+		return
+	}
+	if b.config.WindowType != config.WindowType || b.config.WindowSize != config.WindowSize {
+		b.window = newWindow(config.WindowType, config.WindowSize)
+	}
+	b.config = config
+
+	b.logger.Info("Circuit breaker reconfigured",
+		zap.String("name", b.name),
+		zap.Int("threshold", config.Threshold),
+		zap.Duration("timeout", config.Timeout),
+	)
+}
+
+// Reset resets the circuit breaker to closed state, releasing a forced state
 func (b *Breaker) Reset() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -226,6 +342,7 @@ func (b *Breaker) Reset() {
 	b.failures = 0
 	b.successes = 0
 	b.halfOpenRequests = 0
+	b.window.reset()
 
 	b.logger.Info("Circuit breaker reset",
 		zap.String("name", b.name),
@@ -272,6 +389,25 @@ func (r *BreakerRegistry) GetOrCreate(name string) *Breaker {
 	return breaker
 }
 
+// GetOrCreateWithConfig gets or creates the breaker with the given name and makes it use config
+func (r *BreakerRegistry) GetOrCreateWithConfig(name string, config Config) *Breaker {
+	r.mu.RLock()
+	breaker, exists := r.breakers[name]
+	r.mu.RUnlock()
+
+	if !exists {
+		r.mu.Lock()
+		if breaker, exists = r.breakers[name]; !exists {
+			breaker = NewBreaker(name, config, r.logger)
+			r.breakers[name] = breaker
+		}
+		r.mu.Unlock()
+	}
+
+	breaker.Configure(config)
+	return breaker
+}
+
 // Get gets a breaker by name
 func (r *BreakerRegistry) Get(name string) (*Breaker, bool) {
 	r.mu.RLock()
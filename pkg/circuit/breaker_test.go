@@ -168,6 +168,120 @@ func TestBreakerRegistry(t *testing.T) {
 	}
 }
 
+func TestBreakerRegistry_GetOrCreateWithConfig(t *testing.T) {
+	registry := NewBreakerRegistry(DefaultConfig(), nil)
+
+	config := Config{
+		Threshold:        2,
+		Timeout:          1 * time.Second,
+		SuccessThreshold: 1,
+		MaxHalfOpen:      1,
+	}
+	breaker := registry.GetOrCreateWithConfig("backend1", config)
+
+	breaker.Record(errors.New("error 1"))
+	breaker.Record(errors.New("error 2"))
+	if breaker.State() != StateOpen {
+		t.Errorf("Expected state OPEN after 2 failures with threshold 2, got %s", breaker.State().String())
+	}
+
+	// Reconfiguring keeps the instance and its state
+	config.Threshold = 10
+	again := registry.GetOrCreateWithConfig("backend1", config)
+	if again != breaker {
+		t.Error("Expected same breaker instance for same name")
+	}
+	if again.State() != StateOpen {
+		t.Errorf("Expected state to stay OPEN after reconfiguring, got %s", again.State().String())
+	}
+	if got := again.Stats()["threshold"].(int); got != 10 {
+		t.Errorf("Expected threshold 10 after reconfiguring, got %d", got)
+	}
+}
+
+func TestBreaker_CountWindowFailureRate(t *testing.T) {
+	config := DefaultConfig()
+	config.WindowType = WindowCount
+	config.WindowSize = 10
+	config.MinimumCalls = 10
+	config.FailureRateThreshold = 50
+	breaker := NewBreaker("test", config, zap.NewNop())
+
+	for i := 0; i < 6; i++ {
+		breaker.Record(nil)
+	}
+	for i := 0; i < 3; i++ {
+		breaker.Record(errors.New("test error"))
+	}
+	if breaker.State() != StateClosed {
+		t.Fatalf("Expected state CLOSED below the minimum calls, got %s", breaker.State().String())
+	}
+
+	// The window slides: only the last 10 calls count
+	breaker.Record(nil)
+	breaker.Record(errors.New("test error"))
+	if breaker.State() != StateClosed {
+		t.Fatalf("Expected state CLOSED at 40%% failures, got %s", breaker.State().String())
+	}
+
+	breaker.Record(errors.New("test error"))
+	if breaker.State() != StateOpen {
+		t.Errorf("Expected state OPEN at 50%% failures, got %s", breaker.State().String())
+	}
+}
+
+func TestBreaker_TimeWindowSlowCallRate(t *testing.T) {
+	config := DefaultConfig()
+	config.WindowType = WindowTime
+	config.WindowSize = 60
+	config.MinimumCalls = 4
+	config.SlowCallRateThreshold = 50
+	config.SlowCallDuration = time.Second
+	breaker := NewBreaker("test", config, zap.NewNop())
+
+	breaker.RecordCall(nil, 10*time.Millisecond)
+	breaker.RecordCall(nil, 10*time.Millisecond)
+	breaker.RecordCall(nil, 2*time.Second)
+	if breaker.State() != StateClosed {
+		t.Fatalf("Expected state CLOSED below the minimum calls, got %s", breaker.State().String())
+	}
+
+	breaker.RecordCall(nil, 2*time.Second)
+	if breaker.State() != StateOpen {
+		t.Errorf("Expected state OPEN at 50%% slow calls, got %s", breaker.State().String())
+	}
+	if err := breaker.Allow(); err != ErrCircuitOpen {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+}
+
+func TestBreaker_Forced(t *testing.T) {
+	breaker := NewBreaker("test", Config{Threshold: 1, Timeout: time.Millisecond, SuccessThreshold: 1, MaxHalfOpen: 1}, zap.NewNop())
+
+	breaker.ForceOpen()
+	time.Sleep(5 * time.Millisecond)
+	if err := breaker.Allow(); err != ErrCircuitOpen {
+		t.Errorf("Expected a forced open breaker to stay open past its timeout, got %v", err)
+	}
+	if !breaker.IsOpen() {
+		t.Error("Expected IsOpen for a forced open breaker")
+	}
+
+	breaker.ForceClose()
+	for i := 0; i < 5; i++ {
+		breaker.Record(errors.New("test error"))
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("Expected a forced closed breaker to let requests through, got %v", err)
+		}
+	}
+
+	breaker.Reset()
+	breaker.Record(errors.New("test error"))
+	if breaker.State() != StateOpen {
+		t.Errorf("Expected the released breaker to open by itself again, got %s", breaker.State().String())
+	}
+}
+
 func TestState_String(t *testing.T) {
 	tests := []struct {
 		state    State
@@ -176,6 +290,8 @@ func TestState_String(t *testing.T) {
 		{StateClosed, "CLOSED"},
 		{StateOpen, "OPEN"},
 		{StateHalfOpen, "HALF_OPEN"},
+		{StateForcedOpen, "FORCED_OPEN"},
+		{StateForcedClosed, "FORCED_CLOSED"},
 		{State(99), "UNKNOWN"},
 	}
 
@@ -0,0 +1,90 @@
+package circuit
+
+import "time"
+
+// Window types deciding when a closed breaker opens
+const (
+	WindowConsecutive = "consecutive" // Threshold failures in a row
+	WindowCount       = "count"       // Failure or slow call rate over the last WindowSize calls
+	WindowTime        = "time"        // Failure or slow call rate over the last WindowSize seconds
+)
+
+// ValidWindowType reports whether windowType is supported, empty means consecutive
+func ValidWindowType(windowType string) bool {
+	switch windowType {
+	case "", WindowConsecutive, WindowCount, WindowTime:
+		return true
+	}
+	return false
+}
+
+// bucket aggregates the calls of one slot of a window
+type bucket struct {
+	calls    int
+	failures int
+	slow     int
+	second   int64 // Unix second the bucket holds, time-based windows only
+}
+
+// window is a sliding window of call outcomes, one bucket per call (count) or per second (time)
+type window struct {
+	timeBased bool
+	buckets   []bucket
+	position  int // Next bucket of a count-based window
+}
+
+func newWindow(windowType string, size int) *window {
+	if size <= 0 {
+		size = 1
+	}
+	return &window{
+		timeBased: windowType == WindowTime,
+		buckets:   make([]bucket, size),
+	}
+}
+
+// record adds a call outcome
+func (w *window) record(now time.Time, failed, slow bool) {
+	var b *bucket
+	if w.timeBased {
+		second := now.Unix()
+		b = &w.buckets[int(second%int64(len(w.buckets)))]
+		if b.second != second {
+			*b = bucket{second: second}
+		}
+	} else {
+		b = &w.buckets[w.position]
+		*b = bucket{}
+		w.position = (w.position + 1) % len(w.buckets)
+	}
+
+	b.calls++
+	if failed {
+		b.failures++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// totals returns the calls, failures and slow calls in the window
+func (w *window) totals(now time.Time) (calls, failures, slow int) {
+	second := now.Unix()
+	for _, b := range w.buckets {
+		if w.timeBased && second-b.second >= int64(len(w.buckets)) {
+			continue
+		}
+		calls += b.calls
+		failures += b.failures
+		slow += b.slow
+	}
+	return calls, failures, slow
+}
+
+// reset empties the window
+func (w *window) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+	w.position = 0
+}
@@ -0,0 +1,261 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Body kinds, named by their canonical Content-Type
+const (
+	JSON      = "application/json"
+	Form      = "application/x-www-form-urlencoded"
+	Multipart = "multipart/form-data"
+	XML       = "application/xml"
+	Binary    = "application/octet-stream"
+)
+
+// Kind returns the codec of a Content-Type header value: JSON, Form, Multipart, XML or Binary
+// An empty Content-Type is JSON, any type without a codec of its own is Binary
+func Kind(contentType string) string {
+	if strings.TrimSpace(contentType) == "" {
+		return JSON
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+
+	switch {
+	case mediaType == JSON || strings.HasSuffix(mediaType, "+json"):
+		return JSON
+	case mediaType == Form:
+		return Form
+	case mediaType == Multipart:
+		return Multipart
+	case mediaType == XML || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return XML
+	}
+	return Binary
+}
+
+// Decode parses body by its Content-Type into fields
+// Form values given more than once become arrays. Any body without a codec of its own is tried as JSON,
+// so clients leaving Content-Type wrong still get their fields read; nil when it isn't JSON either.
+// Multipart bodies are parsed by the caller, see DecodeMultipart
+func Decode(contentType string, body []byte) (map[string]interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	switch Kind(contentType) {
+	case Form:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("decode form: %w", err)
+		}
+		return fromValues(values), nil
+	case XML:
+		return decodeXML(body)
+	case Multipart:
+		return nil, errors.New("decode multipart: parse the form and use DecodeMultipart")
+	case JSON:
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, fmt.Errorf("decode JSON: %w", err)
+		}
+		return fields, nil
+	default:
+		var fields map[string]interface{}
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return nil, nil
+		}
+		return fields, nil
+	}
+}
+
+// DecodeMultipart returns the fields of a parsed multipart form
+// Files are described by their filename, content_type and size, their content stays in the form
+func DecodeMultipart(form *multipart.Form) map[string]interface{} {
+	if form == nil {
+		return nil
+	}
+
+	fields := fromValues(form.Value)
+	for name, files := range form.File {
+		described := make([]interface{}, 0, len(files))
+		for _, file := range files {
+			described = append(described, map[string]interface{}{
+				"filename":     file.Filename,
+				"content_type": file.Header.Get("Content-Type"),
+				"size":         file.Size,
+			})
+		}
+		if len(described) == 1 {
+			fields[name] = described[0]
+		} else {
+			fields[name] = described
+		}
+	}
+	return fields
+}
+
+// Encode writes fields as contentType and returns the body with the Content-Type to send, which carries the
+// boundary for multipart. files are multipart file parts sent under their field name, opened and copied
+// while the body is read, so a file is never held in memory as a whole. raw is the body of Binary types
+func Encode(contentType string, fields map[string]interface{}, files map[string][]*multipart.FileHeader, raw []byte) (io.Reader, string, error) {
+	switch Kind(contentType) {
+	case Form:
+		return strings.NewReader(toValues(fields).Encode()), contentType, nil
+	case XML:
+		body, err := encodeXML(fields)
+		if err != nil {
+			return nil, "", err
+		}
+		return bytes.NewReader(body), contentType, nil
+	case Multipart:
+		return encodeMultipart(fields, files)
+	case Binary:
+		return bytes.NewReader(raw), contentType, nil
+	default:
+		if contentType == "" {
+			contentType = JSON
+		}
+		body, err := json.Marshal(fields)
+		if err != nil {
+			return nil, "", fmt.Errorf("encode JSON: %w", err)
+		}
+		return bytes.NewReader(body), contentType, nil
+	}
+}
+
+// encodeMultipart streams fields then files through a pipe, the returned reader must be read or closed
+func encodeMultipart(fields map[string]interface{}, files map[string][]*multipart.FileHeader) (io.Reader, string, error) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeMultipart(writer, fields, files)
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, writer.FormDataContentType(), nil
+}
+
+func writeMultipart(writer *multipart.Writer, fields map[string]interface{}, files map[string][]*multipart.FileHeader) error {
+	values := toValues(fields)
+	for _, name := range sortedKeys(values) {
+		for _, value := range values[name] {
+			if err := writer.WriteField(name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, file := range files[name] {
+			if err := writeFile(writer, name, file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeFile(writer *multipart.Writer, name string, file *multipart.FileHeader) error {
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("open file part %s: %w", name, err)
+	}
+	defer src.Close()
+
+	header := make(map[string][]string)
+	header["Content-Disposition"] = []string{mime.FormatMediaType("form-data", map[string]string{"name": name, "filename": file.Filename})}
+	contentType := file.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = Binary
+	}
+	header["Content-Type"] = []string{contentType}
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, src)
+	return err
+}
+
+// fromValues turns form values into fields, a value given more than once becomes an array
+func fromValues(values map[string][]string) map[string]interface{} {
+	fields := make(map[string]interface{}, len(values))
+	for name, list := range values {
+		if len(list) == 1 {
+			fields[name] = list[0]
+			continue
+		}
+		items := make([]interface{}, 0, len(list))
+		for _, value := range list {
+			items = append(items, value)
+		}
+		fields[name] = items
+	}
+	return fields
+}
+
+// toValues turns fields into form values: arrays repeat their name, objects are sent as JSON
+func toValues(fields map[string]interface{}) url.Values {
+	values := url.Values{}
+	for name, value := range fields {
+		if items, ok := value.([]interface{}); ok {
+			for _, item := range items {
+				values.Add(name, scalar(item))
+			}
+			continue
+		}
+		values.Add(name, scalar(value))
+	}
+	return values
+}
+
+// scalar formats a field value as text, objects and arrays as JSON
+func scalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	case float64:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package codec
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKind(t *testing.T) {
+	cases := map[string]string{
+		"":                                  JSON,
+		"application/json; charset=utf-8":   JSON,
+		"application/vnd.api+json":          JSON,
+		"application/x-www-form-urlencoded": Form,
+		"multipart/form-data; boundary=abc": Multipart,
+		"text/xml; charset=utf-8":           XML,
+		"application/soap+xml":              XML,
+		"application/pdf":                   Binary,
+	}
+	for contentType, want := range cases {
+		if got := Kind(contentType); got != want {
+			t.Errorf("Kind(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	form, err := Decode(Form, []byte("account=123&tag=a&tag=b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"account": "123", "tag": []interface{}{"a", "b"}}; !reflect.DeepEqual(form, want) {
+		t.Errorf("Unexpected form fields %v", form)
+	}
+
+	xmlFields, err := Decode("text/xml", []byte(`<?xml version="1.0"?><transfer currency="IDR"><amount>100</amount><note>a</note><note>b</note></transfer>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"@currency": "IDR", "amount": "100", "note": []interface{}{"a", "b"}}
+	if !reflect.DeepEqual(xmlFields, want) {
+		t.Errorf("Unexpected XML fields %v", xmlFields)
+	}
+
+	// A JSON body sent with another type is still read, anything else has no fields
+	if fields, _ := Decode("text/plain", []byte(`{"id": 1}`)); fields["id"] != float64(1) {
+		t.Errorf("Expected JSON fields of a text/plain body, got %v", fields)
+	}
+	if fields, err := Decode("application/pdf", []byte("%PDF-1.4")); fields != nil || err != nil {
+		t.Errorf("Expected no fields for a binary body, got %v (%v)", fields, err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	body, contentType, _ := Encode(Form, map[string]interface{}{"amount": float64(100), "tag": []interface{}{"a", "b"}}, nil, nil)
+	if got, _ := io.ReadAll(body); string(got) != "amount=100&tag=a&tag=b" || contentType != Form {
+		t.Errorf("Unexpected form body %q (%s)", got, contentType)
+	}
+
+	body, _, _ = Encode(XML, map[string]interface{}{"transfer": map[string]interface{}{"@currency": "IDR", "amount": "100"}}, nil, nil)
+	got, _ := io.ReadAll(body)
+	if !strings.HasSuffix(string(got), `<transfer currency="IDR"><amount>100</amount></transfer>`) {
+		t.Errorf("Unexpected XML body %s", got)
+	}
+
+	raw := []byte{0x25, 0x50, 0x44, 0x46}
+	body, contentType, _ = Encode("application/pdf", nil, nil, raw)
+	if got, _ := io.ReadAll(body); !bytes.Equal(got, raw) || contentType != "application/pdf" {
+		t.Errorf("Expected the raw body as is, got %v (%s)", got, contentType)
+	}
+}
+
+func TestMultipartRoundTrip(t *testing.T) {
+	// Parse an upload the way the gateway receives it
+	var upload bytes.Buffer
+	writer := multipart.NewWriter(&upload)
+	writer.WriteField("customer_id", "42")
+	part, _ := writer.CreateFormFile("ktp", "ktp.jpg")
+	part.Write([]byte("jpeg bytes"))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/kyc", &upload)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := DecodeMultipart(req.MultipartForm)
+	if fields["customer_id"] != "42" || fields["ktp"].(map[string]interface{})["filename"] != "ktp.jpg" {
+		t.Errorf("Unexpected multipart fields %v", fields)
+	}
+
+	// Send it on with the file under another name
+	body, contentType, err := Encode(Multipart, map[string]interface{}{"cif": "42"}, map[string][]*multipart.FileHeader{"document": req.MultipartForm.File["ktp"]}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, _ := mime.ParseMediaType(contentType)
+	reader := multipart.NewReader(body, params["boundary"])
+	form, err := reader.ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["cif"][0] != "42" || form.File["document"][0].Filename != "ktp.jpg" {
+		t.Errorf("Unexpected outbound form %v %v", form.Value, form.File)
+	}
+	file, _ := form.File["document"][0].Open()
+	if content, _ := io.ReadAll(file); string(content) != "jpeg bytes" {
+		t.Errorf("Expected the file content passed on, got %q", content)
+	}
+}
@@ -0,0 +1,173 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// XML elements map to fields the way JSON objects do: child elements are fields, repeated ones arrays,
+// attributes are "@name" fields and the text of an element with attributes or children is "#text"
+const (
+	xmlAttrPrefix = "@"
+	xmlTextKey    = "#text"
+	xmlRoot       = "request" // Root element of encoded fields that don't name their own
+)
+
+// decodeXML returns the fields of the root element, or {root: text} for a root holding only text
+func decodeXML(body []byte) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("decode XML: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode XML: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		value, err := decodeElement(decoder, start)
+		if err != nil {
+			return nil, fmt.Errorf("decode XML: %w", err)
+		}
+		if fields, ok := value.(map[string]interface{}); ok {
+			return fields, nil
+		}
+		return map[string]interface{}{start.Name.Local: value}, nil
+	}
+}
+
+// decodeElement reads the element opened by start up to its end, as text when it has neither attributes nor children
+func decodeElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	fields := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		fields[xmlAttrPrefix+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			addField(fields, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(fields) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				fields[xmlTextKey] = content
+			}
+			return fields, nil
+		}
+	}
+}
+
+// addField adds value under name, turning the field into an array when name repeats
+func addField(fields map[string]interface{}, name string, value interface{}) {
+	existing, ok := fields[name]
+	if !ok {
+		fields[name] = value
+		return
+	}
+	if items, ok := existing.([]interface{}); ok {
+		fields[name] = append(items, value)
+		return
+	}
+	fields[name] = []interface{}{existing, value}
+}
+
+// encodeXML writes fields as an XML document. Fields holding a single object are that object under its
+// own name as root element, any other fields go under <request>
+func encodeXML(fields map[string]interface{}) ([]byte, error) {
+	root, content := xmlRoot, interface{}(fields)
+	if len(fields) == 1 {
+		for name, value := range fields {
+			if _, ok := value.(map[string]interface{}); ok {
+				root, content = name, value
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if err := encodeElement(encoder, root, content); err != nil {
+		return nil, fmt.Errorf("encode XML: %w", err)
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, fmt.Errorf("encode XML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeElement writes value as element name, arrays as one element per item
+func encodeElement(encoder *xml.Encoder, name string, value interface{}) error {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if err := encodeElement(encoder, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := encoder.EncodeToken(xml.CharData(scalar(value))); err != nil {
+			return err
+		}
+		return encoder.EncodeToken(start.End())
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, xmlAttrPrefix) {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: strings.TrimPrefix(key, xmlAttrPrefix)}, Value: scalar(fields[key])})
+		}
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if text, ok := fields[xmlTextKey]; ok {
+		if err := encoder.EncodeToken(xml.CharData(scalar(text))); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		if strings.HasPrefix(key, xmlAttrPrefix) || key == xmlTextKey {
+			continue
+		}
+		if err := encodeElement(encoder, key, fields[key]); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
@@ -1,18 +1,84 @@
 package database
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/Payphone-Digital/gateway/internal/model"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 // AutoMigrate runs database migrations for all models
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+// notifyTriggers installs the config change triggers DB_NOTIFY_ENABLED listens to, false leaves them as they are:
+// other instances sharing the database may still listen to them
+func AutoMigrate(db *gorm.DB, notifyTriggers bool) error {
+	// path+method is no longer unique: match predicates allow several configs per pair
+	if db.Migrator().HasIndex(&model.APIConfig{}, "idx_api_configs_path_method") {
+		if err := db.Migrator().DropIndex(&model.APIConfig{}, "idx_api_configs_path_method"); err != nil {
+			return err
+		}
+	}
+
+	// retry_per_try_timeout holds milliseconds, its column is named after them now
+	if db.Migrator().HasColumn(&model.URLConfig{}, "retry_per_try_timeout") && !db.Migrator().HasColumn(&model.URLConfig{}, "retry_per_try_timeout_millis") {
+		if err := db.Migrator().RenameColumn(&model.URLConfig{}, "retry_per_try_timeout", "retry_per_try_timeout_millis"); err != nil {
+			return err
+		}
+	}
+
+	if err := db.AutoMigrate(
 		&model.User{},
 		&model.URLConfig{},
 		&model.APIConfig{},
 		&model.APIGroup{},
 		&model.APIGroupStep{},
 		&model.APIGroupCron{},
-	)
+	); err != nil {
+		return err
+	}
+
+	// path+method is unique again together with the base path and match predicates
+	if err := backfillPredicateHashes(db); err != nil {
+		return err
+	}
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_api_configs_path_method_predicates ON api_configs (path, method, predicate_hash) WHERE deleted_at IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to create unique path, method and predicates index, configs serving the same requests must be removed first: %w", err)
+	}
+
+	// NOTIFY on config changes so every gateway instance hot reloads, whoever wrote the row
+	if !notifyTriggers {
+		return nil
+	}
+	return CreateConfigChangeTriggers(db)
+}
+
+// backfillPredicateHashes sets the predicate hash of configs stored before it existed
+func backfillPredicateHashes(db *gorm.DB) error {
+	var rows []struct {
+		ID           uint
+		BasePath     string
+		MatchHost    string
+		MatchHeaders datatypes.JSON
+		MatchQuery   datatypes.JSON
+	}
+	if err := db.Table("api_configs").
+		Select("api_configs.id, COALESCE(url_configs.base_path, '') AS base_path, api_configs.match_host, api_configs.match_headers, api_configs.match_query").
+		Joins("LEFT JOIN url_configs ON url_configs.id = api_configs.url_config_id").
+		Where("api_configs.predicate_hash = ''").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var matchHeaders, matchQuery map[string]string
+		_ = json.Unmarshal(row.MatchHeaders, &matchHeaders)
+		_ = json.Unmarshal(row.MatchQuery, &matchQuery)
+
+		hash := model.HashPredicates(row.BasePath, row.MatchHost, matchHeaders, matchQuery)
+		if err := db.Table("api_configs").Where("id = ?", row.ID).Update("predicate_hash", hash).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
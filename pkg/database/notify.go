@@ -0,0 +1,73 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ConfigChangeChannel is the NOTIFY channel carrying api_configs/url_configs row changes
+const ConfigChangeChannel = "gateway_config_changes"
+
+// CreateConfigChangeTriggers installs triggers that NOTIFY ConfigChangeChannel on every
+// insert, update and delete of api_configs and url_configs, including writes that bypass the admin API
+// Payload: {"table": "api_configs", "op": "UPDATE", "id": 1, "path": "/v1/x", "method": "GET", "old_path": ..., "old_method": ...}
+func CreateConfigChangeTriggers(db *gorm.DB) error {
+	functionSQL := fmt.Sprintf(`
+		CREATE OR REPLACE FUNCTION gateway_notify_config_change() RETURNS trigger AS $$
+		DECLARE
+			payload jsonb;
+		BEGIN
+			payload := jsonb_build_object('table', TG_TABLE_NAME, 'op', TG_OP);
+
+			IF TG_OP = 'DELETE' THEN
+				payload := payload || jsonb_build_object('id', OLD.id);
+			ELSE
+				payload := payload || jsonb_build_object('id', NEW.id);
+			END IF;
+
+			IF TG_TABLE_NAME = 'api_configs' THEN
+				IF TG_OP <> 'DELETE' THEN
+					payload := payload || jsonb_build_object('path', NEW.path, 'method', NEW.method);
+				END IF;
+				IF TG_OP <> 'INSERT' THEN
+					payload := payload || jsonb_build_object('old_path', OLD.path, 'old_method', OLD.method);
+				END IF;
+			END IF;
+
+			PERFORM pg_notify('%s', payload::text);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+	`, ConfigChangeChannel)
+
+	if err := db.Exec(functionSQL).Error; err != nil {
+		return fmt.Errorf("failed to create config change notify function: %w", err)
+	}
+
+	for _, table := range []string{"api_configs", "url_configs"} {
+		trigger := fmt.Sprintf("gateway_%s_notify", table)
+		statements := []string{
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", trigger, table),
+			fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT OR UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION gateway_notify_config_change();", trigger, table),
+		}
+		for _, stmt := range statements {
+			if err := db.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("failed to create notify trigger on %s: %w", table, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// DropConfigChangeTriggers removes the triggers installed by CreateConfigChangeTriggers, so config writes
+// stop paying for a NOTIFY nobody listens to. Never run on startup: only call it once no instance listens anymore
+func DropConfigChangeTriggers(db *gorm.DB) error {
+	for _, table := range []string{"api_configs", "url_configs"} {
+		if err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS gateway_%s_notify ON %s;", table, table)).Error; err != nil {
+			return fmt.Errorf("failed to drop notify trigger on %s: %w", table, err)
+		}
+	}
+	return db.Exec("DROP FUNCTION IF EXISTS gateway_notify_config_change();").Error
+}
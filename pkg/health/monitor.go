@@ -8,7 +8,9 @@ import (
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
 // Status represents health check status
@@ -18,7 +20,7 @@ const (
 	StatusUnknown Status = iota
 	StatusHealthy
 	StatusUnhealthy
-	StatusDegraded
+	StatusDegraded // Answered, but not with a 2xx, e.g. a 404 from a backend without a health endpoint
 )
 
 func (s Status) String() string {
@@ -122,6 +124,12 @@ func (c *GRPCChecker) Check(ctx context.Context) CheckResult {
 	resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
 	result.Latency = time.Since(start)
 
+	// A server without the health service answers, so it's up
+	if status.Code(err) == codes.Unimplemented {
+		result.Status = StatusUnknown
+		result.LastError = err
+		return result
+	}
 	if err != nil {
 		result.Status = StatusUnhealthy
 		result.LastError = err
@@ -140,10 +148,17 @@ func (c *GRPCChecker) Check(ctx context.Context) CheckResult {
 	return result
 }
 
-// Monitor manages health checks for multiple backends
+// registration is a checker with the interval it runs at
+type registration struct {
+	checker  Checker
+	interval time.Duration
+	stop     context.CancelFunc // Stops its checks, nil while the monitor isn't running
+}
+
+// Monitor manages health checks for multiple backends, each checked at its own interval
 type Monitor struct {
 	mu       sync.RWMutex
-	checkers map[string]Checker
+	checkers map[string]*registration
 	results  map[string]*CheckResult
 	interval time.Duration
 	logger   *zap.Logger
@@ -161,7 +176,7 @@ func NewMonitor(interval time.Duration, logger *zap.Logger) *Monitor {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Monitor{
-		checkers: make(map[string]Checker),
+		checkers: make(map[string]*registration),
 		results:  make(map[string]*CheckResult),
 		interval: interval,
 		logger:   logger,
@@ -170,20 +185,17 @@ func NewMonitor(interval time.Duration, logger *zap.Logger) *Monitor {
 	}
 }
 
-// RegisterHTTPChecker registers an HTTP health checker
-func (m *Monitor) RegisterHTTPChecker(address, path string, client *http.Client) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
+// RegisterHTTPChecker registers an HTTP health checker, checked every interval (0 = the monitor's interval)
+func (m *Monitor) RegisterHTTPChecker(address, path string, client *http.Client, interval time.Duration) {
 	if client == nil {
 		client = &http.Client{Timeout: 5 * time.Second}
 	}
 
-	m.checkers[address] = &HTTPChecker{
+	m.register(address, &HTTPChecker{
 		Address: address,
 		Path:    path,
 		Client:  client,
-	}
+	}, interval)
 
 	m.logger.Info("Registered HTTP health checker",
 		zap.String("address", address),
@@ -191,32 +203,67 @@ func (m *Monitor) RegisterHTTPChecker(address, path string, client *http.Client)
 	)
 }
 
-// RegisterGRPCChecker registers a gRPC health checker
-func (m *Monitor) RegisterGRPCChecker(address string, conn *grpc.ClientConn) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.checkers[address] = &GRPCChecker{
+// RegisterGRPCChecker registers a gRPC health checker, checked every interval (0 = the monitor's interval)
+func (m *Monitor) RegisterGRPCChecker(address string, conn *grpc.ClientConn, interval time.Duration) {
+	m.register(address, &GRPCChecker{
 		Address: address,
 		Conn:    conn,
-	}
+	}, interval)
 
 	m.logger.Info("Registered gRPC health checker",
 		zap.String("address", address),
 	)
 }
 
+// register replaces the checker of address, started right away when the monitor runs
+func (m *Monitor) register(address string, checker Checker, interval time.Duration) {
+	if interval <= 0 {
+		interval = m.interval
+	}
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.checkers[address]; ok && existing.stop != nil {
+		existing.stop()
+	}
+	reg := &registration{checker: checker, interval: interval}
+	m.checkers[address] = reg
+	if m.running {
+		m.startLocked(address, reg)
+	}
+}
+
+// Unregister stops checking address and forgets its result, so it counts as healthy again
+func (m *Monitor) Unregister(address string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if reg, ok := m.checkers[address]; ok {
+		if reg.stop != nil {
+			reg.stop()
+		}
+		delete(m.checkers, address)
+		delete(m.results, address)
+		m.logger.Info("Unregistered health checker", zap.String("address", address))
+	}
+}
+
 // Start starts the health monitor
 func (m *Monitor) Start() {
 	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.running {
-		m.mu.Unlock()
 		return
 	}
 	m.running = true
-	m.mu.Unlock()
-
-	go m.runChecks()
+	for address, reg := range m.checkers {
+		m.startLocked(address, reg)
+	}
 }
 
 // Stop stops the health monitor
@@ -232,73 +279,77 @@ func (m *Monitor) Stop() {
 	m.cancel()
 }
 
-// runChecks runs health checks periodically
-func (m *Monitor) runChecks() {
-	ticker := time.NewTicker(m.interval)
-	defer ticker.Stop()
+// startLocked runs the checks of reg in the background until it's replaced, unregistered or the monitor stops (must hold lock)
+func (m *Monitor) startLocked(address string, reg *registration) {
+	ctx, stop := context.WithCancel(m.ctx)
+	reg.stop = stop
+	go m.runChecks(ctx, address, reg)
+}
 
-	// Run initial checks
-	m.checkAll()
+// runChecks checks address right away, then every interval of reg
+func (m *Monitor) runChecks(ctx context.Context, address string, reg *registration) {
+	ticker := time.NewTicker(reg.interval)
+	defer ticker.Stop()
 
 	for {
+		m.check(ctx, address, reg)
+
 		select {
-		case <-m.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			m.checkAll()
 		}
 	}
 }
 
-// checkAll checks all registered backends
-func (m *Monitor) checkAll() {
-	m.mu.RLock()
-	checkers := make(map[string]Checker)
-	for addr, checker := range m.checkers {
-		checkers[addr] = checker
+// check runs one check of reg and records its result, unless reg was replaced or unregistered meanwhile
+func (m *Monitor) check(ctx context.Context, address string, reg *registration) {
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	result := reg.checker.Check(checkCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	if m.checkers[address] != reg {
+		m.mu.Unlock()
+		return
 	}
-	m.mu.RUnlock()
-
-	for address, checker := range checkers {
-		ctx, cancel := context.WithTimeout(m.ctx, 5*time.Second)
-		result := checker.Check(ctx)
-		cancel()
-
-		m.mu.Lock()
-		if existing, ok := m.results[address]; ok {
-			result.CheckCount = existing.CheckCount + 1
-			if result.Status == StatusUnhealthy {
-				result.FailureCount = existing.FailureCount + 1
-			} else {
-				result.FailureCount = existing.FailureCount
-			}
+	if existing, ok := m.results[address]; ok {
+		result.CheckCount = existing.CheckCount + 1
+		if result.Status == StatusUnhealthy {
+			result.FailureCount = existing.FailureCount + 1
 		} else {
-			result.CheckCount = 1
-			if result.Status == StatusUnhealthy {
-				result.FailureCount = 1
-			}
+			result.FailureCount = existing.FailureCount
 		}
-		m.results[address] = &result
-		m.mu.Unlock()
-
-		if result.Status != StatusHealthy {
-			m.logger.Warn("Health check failed",
-				zap.String("address", address),
-				zap.String("status", result.Status.String()),
-				zap.Duration("latency", result.Latency),
-				zap.Error(result.LastError),
-			)
+	} else {
+		result.CheckCount = 1
+		if result.Status == StatusUnhealthy {
+			result.FailureCount = 1
 		}
 	}
+	m.results[address] = &result
+	m.mu.Unlock()
+
+	if result.Status != StatusHealthy {
+		m.logger.Warn("Health check failed",
+			zap.String("address", address),
+			zap.String("status", result.Status.String()),
+			zap.Duration("latency", result.Latency),
+			zap.Error(result.LastError),
+		)
+	}
 }
 
-// IsHealthy checks if a backend is healthy
+// IsHealthy checks if a backend may take traffic: anything but a failed check
+// Degraded backends answered, only not at their health endpoint, so they stay routable
 func (m *Monitor) IsHealthy(address string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	if result, ok := m.results[address]; ok {
-		return result.Status == StatusHealthy
+		return result.Status != StatusUnhealthy
 	}
 	return true // Assume healthy if not tracked
 }
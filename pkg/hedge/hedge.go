@@ -0,0 +1,278 @@
+package hedge
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/pkg/retry"
+)
+
+// Defaults of routes leaving hedge settings at zero
+const (
+	DefaultPercentile = 95
+	DefaultMaxPercent = 10
+)
+
+const (
+	minSamples = 20               // Latencies a route needs before its percentile is used as delay
+	maxSamples = 1000             // Latest latencies kept per route
+	capWindow  = 10 * time.Second // Hedges are capped as a share of a route's requests over this window
+
+	recomputeEvery = 50 // Latencies recorded before a route's percentile delay is computed again
+)
+
+// Config is the hedging of one route
+type Config struct {
+	Delay      time.Duration // Wait before the second copy, 0 = the route's observed Percentile latency
+	Percentile int           // 1-99
+	MaxPercent float64       // Share of requests hedged at most
+}
+
+// Result is the outcome of one copy of a request
+type Result struct {
+	Body   []byte
+	Status int
+	Header http.Header
+	Err    error
+}
+
+// failed reports whether r is no better than no response at all
+func (r Result) failed() bool {
+	return r.Err != nil || r.Status >= 500
+}
+
+// Outcome tells how a request went with hedging
+type Outcome int
+
+const (
+	NotHedged  Outcome = iota // The first copy answered within the delay
+	Capped                    // The delay passed but the route was at its hedge rate cap
+	PrimaryWon                // Hedged, the first copy answered first
+	HedgeWon                  // Hedged, the second copy answered first
+)
+
+// Snapshot is the hedging of a route so far
+type Snapshot struct {
+	Requests  int64
+	Hedged    int64
+	HedgeWins int64
+	Capped    int64
+	Samples   int
+	Delay     time.Duration // Current delay, 0 while a percentile based delay lacks samples
+	Since     time.Time
+}
+
+// route holds the latencies and counters of one route, behind its own lock
+type route struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	budget    *retry.Budget
+	snapshot  Snapshot
+
+	// The percentile latency is computed from a sorted copy of the latencies, so it's cached
+	// and only recomputed once recomputeEvery new latencies came in or the percentile changed
+	percentile int
+	observed   time.Duration
+	stale      int
+}
+
+// Hedger tracks per-route latencies and hedge rates
+type Hedger struct {
+	mu     sync.RWMutex // Guards routes only, each route has its own lock
+	routes map[uint]*route
+}
+
+// New creates a hedger
+func New() *Hedger {
+	return &Hedger{routes: make(map[uint]*route)}
+}
+
+// Do sends a request of route id with primary, and with hedge too if primary hasn't answered within
+// the route's delay and the route is below its hedge rate cap. The first good response wins and the
+// other copy is cancelled; a failed first response waits for the other copy
+func (h *Hedger) Do(ctx context.Context, id uint, config Config, primary, hedge func(ctx context.Context) Result) Result {
+	r := h.route(id, config)
+	r.request()
+	delay, ok := r.delay(config)
+	if !ok {
+		// Not enough latencies to know what slow is yet
+		start := time.Now()
+		result := primary(ctx)
+		r.record(time.Since(start), NotHedged)
+		return result
+	}
+
+	start := time.Now()
+	result, outcome := race(ctx, delay, r.budget.Withdraw, primary, hedge)
+	r.record(time.Since(start), outcome)
+	return result
+}
+
+// race runs primary, then hedge after delay if allow agrees, returning the first good result
+func race(ctx context.Context, delay time.Duration, allow func() bool, primary, hedge func(ctx context.Context) Result) (Result, Outcome) {
+	primaryCtx, cancelPrimary := context.WithCancel(ctx)
+	defer cancelPrimary()
+	hedgeCtx, cancelHedge := context.WithCancel(ctx)
+	defer cancelHedge()
+
+	type copyResult struct {
+		result Result
+		hedge  bool
+	}
+	results := make(chan copyResult, 2)
+	go func() { results <- copyResult{primary(primaryCtx), false} }()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case first := <-results:
+		return first.result, NotHedged
+	case <-timer.C:
+	}
+
+	if !allow() {
+		return (<-results).result, Capped
+	}
+	go func() { results <- copyResult{hedge(hedgeCtx), true} }()
+
+	first := <-results
+	if first.result.failed() {
+		first = <-results
+	}
+	if first.hedge {
+		cancelPrimary()
+		return first.result, HedgeWon
+	}
+	cancelHedge()
+	return first.result, PrimaryWon
+}
+
+// Route returns the hedging of route id so far
+func (h *Hedger) Route(id uint) Snapshot {
+	h.mu.RLock()
+	r, ok := h.routes[id]
+	h.mu.RUnlock()
+
+	if !ok {
+		return Snapshot{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot
+}
+
+// Stats returns the hedging of every route by ID
+func (h *Hedger) Stats() map[uint]Snapshot {
+	h.mu.RLock()
+	routes := make(map[uint]*route, len(h.routes))
+	for id, r := range h.routes {
+		routes[id] = r
+	}
+	h.mu.RUnlock()
+
+	stats := make(map[uint]Snapshot, len(routes))
+	for id, r := range routes {
+		r.mu.Lock()
+		stats[id] = r.snapshot
+		r.mu.Unlock()
+	}
+	return stats
+}
+
+// Reset forgets the latencies and counters of route id, e.g. after its hedging changed
+func (h *Hedger) Reset(id uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.routes, id)
+}
+
+// route returns the state of route id, creating it
+func (h *Hedger) route(id uint, config Config) *route {
+	h.mu.RLock()
+	r, ok := h.routes[id]
+	h.mu.RUnlock()
+	if ok {
+		return r
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if r, ok := h.routes[id]; ok {
+		return r
+	}
+	maxPercent := config.MaxPercent
+	if maxPercent <= 0 {
+		maxPercent = DefaultMaxPercent
+	}
+	r = &route{
+		budget:   retry.NewBudget(retry.BudgetConfig{Percent: maxPercent, Window: capWindow}),
+		snapshot: Snapshot{Since: time.Now()},
+	}
+	h.routes[id] = r
+	return r
+}
+
+func (r *route) request() {
+	r.mu.Lock()
+	r.snapshot.Requests++
+	r.mu.Unlock()
+
+	r.budget.Request()
+}
+
+// delay returns the wait before hedging a request, false while it can't be told yet
+func (r *route) delay(config Config) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if config.Delay > 0 {
+		r.snapshot.Delay = config.Delay
+		return config.Delay, true
+	}
+	if len(r.latencies) < minSamples {
+		return 0, false
+	}
+
+	percentile := config.Percentile
+	if percentile <= 0 || percentile >= 100 {
+		percentile = DefaultPercentile
+	}
+	if percentile != r.percentile || r.stale >= recomputeEvery {
+		sorted := append([]time.Duration(nil), r.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		r.observed = sorted[(len(sorted)-1)*percentile/100]
+		r.percentile = percentile
+		r.stale = 0
+	}
+	r.snapshot.Delay = r.observed
+	return r.observed, true
+}
+
+// record adds the latency the client saw and the outcome of a request
+func (r *route) record(latency time.Duration, outcome Outcome) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.latencies) < maxSamples {
+		r.latencies = append(r.latencies, latency)
+	} else {
+		r.latencies[r.next] = latency
+		r.next = (r.next + 1) % maxSamples
+	}
+	r.snapshot.Samples = len(r.latencies)
+	r.stale++
+
+	switch outcome {
+	case Capped:
+		r.snapshot.Capped++
+	case PrimaryWon:
+		r.snapshot.Hedged++
+	case HedgeWon:
+		r.snapshot.Hedged++
+		r.snapshot.HedgeWins++
+	}
+}
@@ -0,0 +1,153 @@
+package hedge
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// sleeper answers with status after d, or with the context's error once it is cancelled
+func sleeper(d time.Duration, status int, cancelled chan<- struct{}) func(ctx context.Context) Result {
+	return func(ctx context.Context) Result {
+		select {
+		case <-time.After(d):
+			return Result{Status: status}
+		case <-ctx.Done():
+			if cancelled != nil {
+				close(cancelled)
+			}
+			return Result{Err: ctx.Err()}
+		}
+	}
+}
+
+func TestHedger_HedgeWins(t *testing.T) {
+	h := New()
+	cancelled := make(chan struct{})
+	config := Config{Delay: 10 * time.Millisecond, MaxPercent: 100}
+
+	result := h.Do(context.Background(), 1, config,
+		sleeper(time.Second, http.StatusOK, cancelled),
+		sleeper(time.Millisecond, http.StatusAccepted, nil),
+	)
+	if result.Status != http.StatusAccepted {
+		t.Errorf("Expected the hedge's response, got %d", result.Status)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the slow primary to be cancelled")
+	}
+
+	snap := h.Route(1)
+	if snap.Requests != 1 || snap.Hedged != 1 || snap.HedgeWins != 1 {
+		t.Errorf("Unexpected snapshot %+v", snap)
+	}
+}
+
+func TestHedger_FastPrimaryNotHedged(t *testing.T) {
+	h := New()
+	hedged := false
+	config := Config{Delay: 100 * time.Millisecond, MaxPercent: 100}
+
+	result := h.Do(context.Background(), 1, config,
+		sleeper(time.Millisecond, http.StatusOK, nil),
+		func(ctx context.Context) Result { hedged = true; return Result{Status: http.StatusOK} },
+	)
+	if result.Status != http.StatusOK || hedged {
+		t.Errorf("Expected the primary's response without a hedge, got %d (hedged %v)", result.Status, hedged)
+	}
+}
+
+func TestHedger_FailedFirstResponseWaits(t *testing.T) {
+	h := New()
+	config := Config{Delay: time.Millisecond, MaxPercent: 100}
+
+	result := h.Do(context.Background(), 1, config,
+		sleeper(50*time.Millisecond, http.StatusOK, nil),
+		sleeper(5*time.Millisecond, http.StatusBadGateway, nil),
+	)
+	if result.Status != http.StatusOK {
+		t.Errorf("Expected the primary's good response over the hedge's failure, got %d", result.Status)
+	}
+	if snap := h.Route(1); snap.HedgeWins != 0 {
+		t.Errorf("Expected no hedge win, got %d", snap.HedgeWins)
+	}
+}
+
+func TestHedger_RateCap(t *testing.T) {
+	h := New()
+	config := Config{Delay: time.Millisecond, MaxPercent: 10}
+
+	for i := 0; i < 10; i++ {
+		h.Do(context.Background(), 1, config,
+			sleeper(5*time.Millisecond, http.StatusOK, nil),
+			sleeper(time.Millisecond, http.StatusOK, nil),
+		)
+	}
+
+	snap := h.Route(1)
+	if snap.Hedged != 1 || snap.Capped != 9 {
+		t.Errorf("Expected 1 of 10 requests hedged at 10%%, got %+v", snap)
+	}
+}
+
+func TestHedger_PercentileDelay(t *testing.T) {
+	h := New()
+	config := Config{Percentile: 50, MaxPercent: 100}
+	hedges := 0
+	hedge := func(ctx context.Context) Result { hedges++; return Result{Status: http.StatusOK} }
+
+	// No hedging until the route has enough latencies
+	for i := 0; i < minSamples; i++ {
+		h.Do(context.Background(), 1, config, sleeper(2*time.Millisecond, http.StatusOK, nil), hedge)
+	}
+	if hedges != 0 {
+		t.Fatalf("Expected no hedges before %d samples, got %d", minSamples, hedges)
+	}
+
+	snap := h.Route(1)
+	if snap.Samples != minSamples {
+		t.Errorf("Expected %d samples, got %d", minSamples, snap.Samples)
+	}
+
+	// Far slower than the median now
+	h.Do(context.Background(), 1, config, sleeper(time.Second, http.StatusOK, nil), hedge)
+	if hedges != 1 {
+		t.Errorf("Expected a request slower than the median to be hedged, got %d hedges", hedges)
+	}
+	if delay := h.Route(1).Delay; delay < 2*time.Millisecond || delay > 100*time.Millisecond {
+		t.Errorf("Expected the median delay around 2ms, got %s", delay)
+	}
+}
+
+func TestHedger_PercentileDelayIsCached(t *testing.T) {
+	h := New()
+	config := Config{Percentile: 50, MaxPercent: 100}
+	r := h.route(1, config)
+	for i := 0; i < minSamples; i++ {
+		r.record(10*time.Millisecond, NotHedged)
+	}
+	if delay, ok := r.delay(config); !ok || delay != 10*time.Millisecond {
+		t.Fatalf("Expected a 10ms median, got %s (%v)", delay, ok)
+	}
+
+	// Slower latencies only move the delay once enough of them came in
+	for i := 0; i < recomputeEvery-1; i++ {
+		r.record(time.Second, NotHedged)
+	}
+	if delay, _ := r.delay(config); delay != 10*time.Millisecond {
+		t.Errorf("Expected the cached 10ms median, got %s", delay)
+	}
+	r.record(time.Second, NotHedged)
+	if delay, _ := r.delay(config); delay != time.Second {
+		t.Errorf("Expected the median recomputed to 1s, got %s", delay)
+	}
+
+	// A changed percentile is computed right away
+	if delay, _ := r.delay(Config{Percentile: 10}); delay != 10*time.Millisecond {
+		t.Errorf("Expected the 10th percentile at 10ms, got %s", delay)
+	}
+}
@@ -0,0 +1,172 @@
+package integrasi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestKey extracts a per-client key from c, empty when the request doesn't carry it
+// key is header:<name>, cookie:<name>, query:<name>, user (the authenticated user ID) or ip
+func RequestKey(c *gin.Context, key string) string {
+	switch key {
+	case "":
+		return ""
+	case "user":
+		if userID, exists := c.Get("user_id"); exists && userID != nil {
+			return fmt.Sprint(userID)
+		}
+		return ""
+	case "ip":
+		return c.ClientIP()
+	}
+
+	source, name, _ := strings.Cut(key, ":")
+	switch source {
+	case "header":
+		return c.GetHeader(name)
+	case "cookie":
+		value, _ := c.Cookie(name)
+		return value
+	case "query":
+		return c.Query(name)
+	}
+	return ""
+}
+
+// pickTarget routes config to one of its URL config's healthy, non-ejected targets by the URL config's strategy
+// The returned copy has the target as URL config address, so pooling and breakers apply per instance, along
+// with the URL config's own address naming the group the target's outliers are detected in.
+// Configs whose URL config has no targets are returned unchanged, with an empty group
+func (e *Executor) pickTarget(config *dto.APIConfigResponse, c *gin.Context) (*dto.APIConfigResponse, string, error) {
+	upstream := config.URLConfig
+	e.registerTargetHealthChecks(upstream)
+	if len(upstream.Targets) == 0 {
+		return config, "", nil
+	}
+
+	targets := make([]balancer.Target, 0, len(upstream.Targets))
+	addresses := make([]string, 0, len(upstream.Targets))
+	for _, target := range upstream.Targets {
+		targets = append(targets, balancer.Target{Address: target.URL, Weight: target.Weight})
+		addresses = append(addresses, target.URL)
+	}
+	e.outliers.SetHosts(upstream.URL, addresses)
+
+	target, err := e.balancer.Pick(upstream.URL, upstream.LoadBalancingStrategy, targets, RequestKey(c, upstream.HashKey), e.healthyFor(config))
+	if err != nil {
+		e.logger.Warn("No healthy upstream target",
+			zap.String("slug", config.Path),
+			zap.String("upstream", upstream.URL),
+			zap.Int("targets", len(targets)),
+		)
+		return nil, "", err
+	}
+
+	e.logger.Debug("Upstream target selected",
+		zap.String("slug", config.Path),
+		zap.String("upstream", upstream.URL),
+		zap.String("strategy", upstream.LoadBalancingStrategy),
+		zap.String("target", target.Address),
+	)
+
+	return routeTo(config, target.Address), upstream.URL, nil
+}
+
+// healthyFor reports whether a target may serve config: healthy, not ejected and with closed breakers
+// Route scoped breakers only take a target out for their own route
+func (e *Executor) healthyFor(config *dto.APIConfigResponse) func(address string) bool {
+	return func(address string) bool {
+		return e.IsBackendHealthy(address) && !e.breakerOpen(breakerName(address, config))
+	}
+}
+
+// routeTo returns a copy of config sent to the target at address instead of its URL config's own address
+func routeTo(config *dto.APIConfigResponse, address string) *dto.APIConfigResponse {
+	routed := *config
+	routed.URLConfig.URL = address
+	routed.URL = targetURL(config.URL, config.URLConfig.URL, address)
+	return &routed
+}
+
+// targetURL moves a route's complete URL from the URL config's base onto a target's
+func targetURL(routeURL, base, target string) string {
+	base = strings.TrimSuffix(base, "/")
+	if !strings.HasPrefix(routeURL, base) {
+		return routeURL
+	}
+	return strings.TrimSuffix(target, "/") + strings.TrimPrefix(routeURL, base)
+}
+
+// targetHealthChecks are the health checks registered for the targets of one URL config
+type targetHealthChecks struct {
+	settings string   // Targets and health check settings they were registered with
+	targets  []string // Checked targets
+}
+
+// registerTargetHealthChecks keeps the health checks of upstream's targets in line with its settings
+// HTTP targets are checked at the URL config's health_check_path, none when it's empty, gRPC targets with the
+// standard health protocol, every health_check_interval. Checks of targets no URL config has any more are removed
+func (e *Executor) registerTargetHealthChecks(upstream dto.URLConfigResponse) {
+	addresses := make([]string, 0, len(upstream.Targets))
+	for _, target := range upstream.Targets {
+		addresses = append(addresses, target.URL)
+	}
+	settings := fmt.Sprint(upstream.Protocol, upstream.TLSEnabled, upstream.HealthCheckPath, upstream.HealthCheckInterval, addresses)
+
+	e.mu.RLock()
+	registered, ok := e.healthChecks[upstream.URL]
+	e.mu.RUnlock()
+	if (ok && registered.settings == settings) || (!ok && len(addresses) == 0) {
+		return
+	}
+
+	checked := addresses
+	if upstream.Protocol != "grpc" && upstream.HealthCheckPath == "" {
+		checked = nil
+	}
+
+	e.mu.Lock()
+	if current, ok := e.healthChecks[upstream.URL]; ok && current.settings == settings {
+		e.mu.Unlock()
+		return
+	}
+	previous := e.healthChecks[upstream.URL].targets
+	if len(addresses) == 0 {
+		delete(e.healthChecks, upstream.URL)
+	} else {
+		e.healthChecks[upstream.URL] = targetHealthChecks{settings: settings, targets: checked}
+	}
+	inUse := make(map[string]bool)
+	for _, other := range e.healthChecks {
+		for _, address := range other.targets {
+			inUse[address] = true
+		}
+	}
+	e.mu.Unlock()
+
+	for _, address := range previous {
+		if !inUse[address] {
+			e.healthMonitor.Unregister(address)
+		}
+	}
+
+	interval := time.Duration(upstream.HealthCheckInterval) * time.Second
+	for _, address := range checked {
+		switch upstream.Protocol {
+		case "grpc":
+			if conn, err := e.pool.GetGRPCConnection(context.Background(), address, upstream.TLSEnabled); err == nil {
+				e.healthMonitor.RegisterGRPCChecker(address, conn, interval)
+			}
+		default:
+			client := e.pool.GetHTTPClientWithConfig(upstream.ID, address, upstream.TLSEnabled, e.poolConfigFor(upstream))
+			e.healthMonitor.RegisterHTTPChecker(address, upstream.HealthCheckPath, client, interval)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package integrasi
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/pkg/codec"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// multipartMemory is the most of a multipart upload held in memory, larger file parts are spooled to disk
+const multipartMemory = 1 << 20
+
+// filePlaceholder is a body template value naming a single variable, e.g. "{{ktp}}"
+var filePlaceholder = regexp.MustCompile(`^\{\{\s*([\w.-]+)\s*\}\}$`)
+
+// extractBodyParams reads the fields of c's body by its Content-Type, leaving the body readable again
+// Multipart uploads are parsed once, their file parts are described rather than read
+func extractBodyParams(c *gin.Context) map[string]interface{} {
+	contentType := c.GetHeader("Content-Type")
+	if codec.Kind(contentType) == codec.Multipart {
+		return codec.DecodeMultipart(multipartForm(c))
+	}
+
+	fields, err := codec.Decode(contentType, requestBody(c))
+	if err != nil {
+		logger.GetLogger().Debug("Request body not parsed",
+			zap.String("content_type", contentType),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return fields
+}
+
+// multipartForm parses c's multipart body once, nil when it isn't a valid one
+func multipartForm(c *gin.Context) *multipart.Form {
+	if c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(multipartMemory); err != nil {
+			logger.GetLogger().Debug("Multipart body not parsed",
+				zap.Error(err),
+			)
+			return nil
+		}
+	}
+	return c.Request.MultipartForm
+}
+
+// requestBody returns c's body, leaving it readable again
+func requestBody(c *gin.Context) []byte {
+	if c.Request.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil
+	}
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	return body
+}
+
+// headerValue returns the value of header name in headers, whatever its case
+func headerValue(headers map[string][]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// fileParts moves the entries of body whose template is a single placeholder of an uploaded file part
+// ("document": "{{ktp}}") into file parts, so the client's file is passed on under the template's name
+func fileParts(template map[string]interface{}, body map[string]interface{}, c *gin.Context) map[string][]*multipart.FileHeader {
+	if codec.Kind(c.GetHeader("Content-Type")) != codec.Multipart {
+		return nil
+	}
+	form := multipartForm(c)
+	if form == nil {
+		return nil
+	}
+
+	files := make(map[string][]*multipart.FileHeader)
+	for name, value := range template {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+		match := filePlaceholder.FindStringSubmatch(text)
+		if match == nil || len(form.File[match[1]]) == 0 {
+			continue
+		}
+		files[name] = form.File[match[1]]
+		delete(body, name)
+	}
+	return files
+}
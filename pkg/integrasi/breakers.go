@@ -0,0 +1,137 @@
+package integrasi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
+	"go.uber.org/zap"
+)
+
+// Scopes of a URL config's circuit breakers
+const (
+	BreakerScopeUpstream = "upstream" // One breaker per URL config and upstream address shared by all its routes
+	BreakerScopeRoute    = "route"    // One breaker per URL config, upstream address and route, a failing endpoint only trips itself
+)
+
+// Actions an operator can take on a circuit breaker
+const (
+	BreakerForceOpen  = "force_open"
+	BreakerForceClose = "force_close"
+	BreakerRelease    = "release"
+)
+
+// Sliding window defaults for URL configs leaving settings at zero
+const (
+	defaultBreakerMinimumCalls = 20
+	defaultBreakerFailureRate  = 50
+	defaultBreakerSlowCall     = 5 * time.Second
+	defaultBreakerCountWindow  = 100 // calls
+	defaultBreakerTimeWindow   = 60  // seconds
+)
+
+var (
+	ErrUnknownBreaker       = errors.New("unknown circuit breaker")
+	ErrUnknownBreakerAction = errors.New("unknown circuit breaker action")
+)
+
+// ValidBreakerScope reports whether scope is supported, empty means upstream
+func ValidBreakerScope(scope string) bool {
+	return scope == "" || scope == BreakerScopeUpstream || scope == BreakerScopeRoute
+}
+
+// breakerName returns the name of the breaker guarding config's calls to address
+// Names start with the URL config ID, "#7 <address>", so URL configs sharing an address never share or
+// reconfigure each other's breakers; route scoped names are "#7 <address> <METHOD> <path>"
+func breakerName(address string, config *dto.APIConfigResponse) string {
+	name := address
+	if config.URLConfig.ID != 0 {
+		name = fmt.Sprintf("#%d %s", config.URLConfig.ID, address)
+	}
+	if config.URLConfig.CircuitBreakerScope == BreakerScopeRoute {
+		return fmt.Sprintf("%s %s %s", name, config.Method, config.Path)
+	}
+	return name
+}
+
+// breakerOpen reports whether the breaker with the given name exists and is open
+func (e *Executor) breakerOpen(name string) bool {
+	breaker, exists := e.circuitBreaker.Get(name)
+	return exists && breaker.IsOpen()
+}
+
+// circuitConfigFor returns the executor's breaker config with the URL config's breaker settings applied
+func (e *Executor) circuitConfigFor(urlConfig dto.URLConfigResponse) circuit.Config {
+	config := e.config.CircuitConfig
+	if urlConfig.CircuitBreakerThreshold > 0 {
+		config.Threshold = urlConfig.CircuitBreakerThreshold
+	}
+	if urlConfig.CircuitBreakerTimeout > 0 {
+		config.Timeout = time.Duration(urlConfig.CircuitBreakerTimeout) * time.Second
+	}
+
+	switch urlConfig.CircuitBreakerWindowType {
+	case circuit.WindowCount, circuit.WindowTime:
+		config.WindowType = urlConfig.CircuitBreakerWindowType
+	default:
+		return config
+	}
+
+	config.WindowSize = urlConfig.CircuitBreakerWindowSize
+	if config.WindowSize <= 0 {
+		config.WindowSize = defaultBreakerCountWindow
+		if config.WindowType == circuit.WindowTime {
+			config.WindowSize = defaultBreakerTimeWindow
+		}
+	}
+	config.MinimumCalls = urlConfig.CircuitBreakerMinimumCalls
+	if config.MinimumCalls <= 0 {
+		config.MinimumCalls = defaultBreakerMinimumCalls
+	}
+	config.FailureRateThreshold = urlConfig.CircuitBreakerFailureRate
+	if config.FailureRateThreshold <= 0 && urlConfig.CircuitBreakerSlowCallRate <= 0 {
+		config.FailureRateThreshold = defaultBreakerFailureRate
+	}
+	config.SlowCallRateThreshold = urlConfig.CircuitBreakerSlowCallRate
+	config.SlowCallDuration = time.Duration(urlConfig.CircuitBreakerSlowCallMillis) * time.Millisecond
+	if config.SlowCallDuration <= 0 {
+		config.SlowCallDuration = defaultBreakerSlowCall
+	}
+	return config
+}
+
+// CircuitBreakers returns the stats of every circuit breaker by name
+func (e *Executor) CircuitBreakers() map[string]interface{} {
+	return e.circuitBreaker.Stats()
+}
+
+// ControlBreaker forces the named breaker open or closed, or releases it back to its own state
+// Only breakers that have seen traffic exist, so a mistyped name is reported instead of creating a breaker nothing uses
+func (e *Executor) ControlBreaker(name, action string) (map[string]interface{}, error) {
+	switch action {
+	case BreakerForceOpen, BreakerForceClose, BreakerRelease:
+	default:
+		return nil, ErrUnknownBreakerAction
+	}
+	breaker, exists := e.circuitBreaker.Get(name)
+	if !exists {
+		return nil, ErrUnknownBreaker
+	}
+
+	switch action {
+	case BreakerForceOpen:
+		breaker.ForceOpen()
+	case BreakerForceClose:
+		breaker.ForceClose()
+	case BreakerRelease:
+		breaker.Reset()
+	}
+
+	e.logger.Info("Circuit breaker controlled",
+		zap.String("name", name),
+		zap.String("action", action),
+	)
+	return breaker.Stats(), nil
+}
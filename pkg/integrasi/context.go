@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
@@ -30,7 +31,7 @@ func NewContextAwareAPIRequestConfig(ctx context.Context, config APIRequestConfi
 }
 
 // DoRequestWithContext melakukan request dengan context
-func DoRequestWithContext(ctx context.Context, config APIRequestConfig) ([]byte, int, error) {
+func DoRequestWithContext(ctx context.Context, config APIRequestConfig) ([]byte, int, http.Header, error) {
 	// Add logging dengan context
 	logger.InfoWithContext(ctx, "Starting API request").
 		String("method", config.Method).
@@ -47,7 +48,7 @@ func DoRequestWithContext(ctx context.Context, config APIRequestConfig) ([]byte,
 		logger.WarnWithContext(ctx, "Context cancelled before API request").
 			Err(err).
 			Log()
-		return nil, 0, err
+		return nil, 0, nil, err
 	}
 
 	// Create context-aware config with timeout
@@ -62,7 +63,7 @@ func DoRequestWithContext(ctx context.Context, config APIRequestConfig) ([]byte,
 	}
 
 	// Call the original function with context
-	body, statusCode, err := DoRequestSafeWithRetry(requestConfig.Context, *requestConfig.APIRequestConfig)
+	body, statusCode, header, err := DoRequestSafeWithRetry(requestConfig.Context, *requestConfig.APIRequestConfig)
 
 	// Log result dengan context
 	if err != nil {
@@ -83,7 +84,7 @@ func DoRequestWithContext(ctx context.Context, config APIRequestConfig) ([]byte,
 			Log()
 	}
 
-	return body, statusCode, err
+	return body, statusCode, header, err
 }
 
 // ProcessIntegrationWithContext memproses integrasi dengan context
@@ -187,14 +188,14 @@ func (resp APIResponseConfig) BuildAPIRequestConfigWithContext(ctx context.Conte
 
 	// Process templates with context
 	finalURL := resolveTemplateWithContext(resp.URL, finalVars, ctx)
-	finalHeaders := make(map[string]string)
-	finalQuery := make(map[string]string)
+	finalHeaders := make(map[string][]string)
+	finalQuery := make(map[string][]string)
 
 	// Process headers
 	for k, v := range resp.Headers {
 		resolved := resolveTemplateWithContext(v, finalVars, ctx)
 		if resolved != "" {
-			finalHeaders[k] = resolved
+			finalHeaders[k] = []string{resolved}
 		}
 	}
 
@@ -202,7 +203,7 @@ func (resp APIResponseConfig) BuildAPIRequestConfigWithContext(ctx context.Conte
 	for k, v := range resp.QueryParams {
 		resolved := resolveTemplateWithContext(v, finalVars, ctx)
 		if resolved != "" {
-			finalQuery[k] = resolved
+			finalQuery[k] = []string{resolved}
 		}
 	}
 
@@ -339,22 +340,22 @@ func getGinContextFromContext(ctx context.Context) *gin.Context {
 func extractUserVarsFromContext(c *gin.Context, ctx context.Context) RequestParams {
 	params := RequestParams{
 		PathParams:   make(map[string]string),
-		QueryParams:  make(map[string]string),
+		QueryParams:  make(map[string][]string),
 		BodyParams:   make(map[string]interface{}),
-		HeaderParams: make(map[string]string),
+		HeaderParams: make(map[string][]string),
 	}
 
 	// Extract from context
 	if clientIP := ctxutil.GetClientIP(ctx); clientIP != "" {
-		params.HeaderParams["client_ip"] = clientIP
+		params.HeaderParams["client_ip"] = []string{clientIP}
 	}
 
 	if userAgent := ctxutil.GetUserAgent(ctx); userAgent != "" {
-		params.HeaderParams["user_agent"] = userAgent
+		params.HeaderParams["user_agent"] = []string{userAgent}
 	}
 
 	if requestID := ctxutil.GetRequestID(ctx); requestID != "" {
-		params.HeaderParams["request_id"] = requestID
+		params.HeaderParams["request_id"] = []string{requestID}
 	}
 
 	return params
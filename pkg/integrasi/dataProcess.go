@@ -1,12 +1,12 @@
 package integrasi
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
+	"mime/multipart"
+	"net/http"
 	"net/url"
 	"regexp"
 	"strconv"
@@ -15,6 +15,7 @@ import (
 
 	"github.com/Payphone-Digital/gateway/internal/constants"
 	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/codec"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -47,11 +48,12 @@ const (
 	EncodingURLEncode EncodingType = constants.EncodingURLEncode
 )
 
+// RequestParams are the client's request values, query parameters and headers with every value they were given
 type RequestParams struct {
 	PathParams   map[string]string
-	QueryParams  map[string]string
+	QueryParams  map[string][]string
 	BodyParams   map[string]interface{}
-	HeaderParams map[string]string
+	HeaderParams map[string][]string
 }
 
 // Structures
@@ -97,9 +99,9 @@ func getContextVariable(c *gin.Context, key string) (interface{}, bool) {
 func ExtractUserVars(c *gin.Context) RequestParams {
 	params := RequestParams{
 		PathParams:   make(map[string]string),
-		QueryParams:  make(map[string]string),
+		QueryParams:  make(map[string][]string),
 		BodyParams:   make(map[string]interface{}),
-		HeaderParams: make(map[string]string),
+		HeaderParams: make(map[string][]string),
 	}
 
 	// Extract path parameters from Gin's standard params
@@ -117,34 +119,24 @@ func ExtractUserVars(c *gin.Context) RequestParams {
 		}
 	}
 
-	// Extract query parameters
-	if c.Request.URL.Query() != nil {
-		for k, v := range c.Request.URL.Query() {
-			if len(v) > 0 {
-				params.QueryParams[k] = v[0]
-			}
+	// Extract query parameters, a parameter given more than once keeps all its values (?status=PAID&status=PENDING)
+	for k, v := range c.Request.URL.Query() {
+		if len(v) > 0 {
+			params.QueryParams[k] = v
 		}
 	}
 
-	// Extract headers
-	if c.Request.Header != nil {
-		for k := range c.Request.Header {
-			params.HeaderParams[k] = c.GetHeader(k)
+	// Extract headers, repeated ones with all their values
+	for k, v := range c.Request.Header {
+		if len(v) > 0 {
+			params.HeaderParams[k] = append([]string(nil), v...)
 		}
 	}
 
-	// Extract and preserve body parameters
-	if c.Request.Body != nil {
-		bodyData, err := c.GetRawData()
-		if err == nil {
-			// Restore the body for later use
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyData))
-
-			// Parse the body
-			var bodyMap map[string]interface{}
-			if err := json.Unmarshal(bodyData, &bodyMap); err == nil {
-				params.BodyParams = bodyMap
-			}
+	// Extract and preserve body parameters by Content-Type, streamed bodies go to the upstream unread
+	if c.Request.Body != nil && !c.GetBool(StreamBodyKey) {
+		if bodyMap := extractBodyParams(c); bodyMap != nil {
+			params.BodyParams = bodyMap
 		}
 	}
 
@@ -195,7 +187,8 @@ func convertToDataType(value string, dataType DataType) interface{} {
 		if err := json.Unmarshal([]byte(value), &arr); err == nil {
 			return arr
 		}
-		return make([]interface{}, 0)
+		// A single value that isn't a JSON array, e.g. a query parameter given once
+		return []interface{}{value}
 
 	case TypeDate:
 		// Attempt to parse as RFC3339 first
@@ -220,8 +213,9 @@ func resolveTemplate(text string, variables map[string]Variable, c *gin.Context)
 		key := strings.TrimSpace(re.FindStringSubmatch(match)[1])
 
 		if variable, exists := variables[key]; exists {
+			// Arrays, e.g. a repeated query parameter, are written as their comma-separated items
 			resolved := resolveVariable(variable, variables, c)
-			return fmt.Sprintf("%v", resolved)
+			return textValue(resolved)
 		}
 
 		if contextVal, exists := getContextVariable(c, key); exists {
@@ -385,17 +379,18 @@ func (resp APIResponseConfig) BuildAPIRequestConfig(c *gin.Context) APIRequestCo
 					}
 				}
 			case "query":
-				if queryValue, exists := userParams.QueryParams[key]; exists {
+				// Array variables take every value of a repeated parameter, others the first
+				if queryValues, exists := userParams.QueryParams[key]; exists {
 					finalVars[key] = Variable{
-						Value:    queryValue,
+						Value:    variableText(queryValues, variable.DataType),
 						Encoding: variable.Encoding,
 						DataType: variable.DataType,
 					}
 				}
 			case "header":
-				if headerValue, exists := userParams.HeaderParams[key]; exists {
+				if headerValues, exists := userParams.HeaderParams[http.CanonicalHeaderKey(key)]; exists {
 					finalVars[key] = Variable{
-						Value:    headerValue,
+						Value:    variableText(headerValues, variable.DataType),
 						Encoding: variable.Encoding,
 						DataType: variable.DataType,
 					}
@@ -453,24 +448,22 @@ func (resp APIResponseConfig) BuildAPIRequestConfig(c *gin.Context) APIRequestCo
 		zap.String("url", finalURL),
 	)
 
-	// Process Headers
-	finalHeaders := make(map[string]string)
+	// Process Headers, a header holding just an array variable is sent once per item
+	finalHeaders := make(map[string][]string)
 	for k, v := range resp.Headers {
-		resolved := resolveTemplate(v, finalVars, c)
-		if resolved != "" {
-			finalHeaders[k] = resolved
+		if values := resolveValues(v, finalVars, c); len(values) > 0 {
+			finalHeaders[k] = values
 		}
 	}
 	zapLogger.Info("Headers processed",
 		zap.Any("headers", finalHeaders),
 	)
 
-	// Process Query Parameters
-	finalQuery := make(map[string]string)
+	// Process Query Parameters, a parameter holding just an array variable is repeated per item
+	finalQuery := make(map[string][]string)
 	for k, v := range resp.QueryParams {
-		resolved := resolveTemplate(v, finalVars, c)
-		if resolved != "" {
-			finalQuery[k] = resolved
+		if values := resolveValues(v, finalVars, c); len(values) > 0 {
+			finalQuery[k] = values
 		}
 	}
 	zapLogger.Info("Query parameters processed",
@@ -501,6 +494,22 @@ func (resp APIResponseConfig) BuildAPIRequestConfig(c *gin.Context) APIRequestCo
 	// Note: If no configured body (resp.Body is empty), we don't send any body
 	// This enforces that body template must be configured to accept any body params
 
+	// Multipart routes pass on the uploaded files their template names, other types without
+	// a codec of their own (application/pdf, application/octet-stream...) the client's body as is
+	var files map[string][]*multipart.FileHeader
+	var raw []byte
+	switch codec.Kind(headerValue(finalHeaders, "Content-Type")) {
+	case codec.Multipart:
+		var template map[string]interface{}
+		_ = json.Unmarshal(resp.Body, &template)
+		if finalBody == nil {
+			finalBody = make(map[string]interface{})
+		}
+		files = fileParts(template, finalBody, c)
+	case codec.Binary:
+		raw = requestBody(c)
+	}
+
 	timeout := resp.Timeout
 	if timeout <= 0 {
 		timeout = DefaultTimeout
@@ -522,6 +531,8 @@ func (resp APIResponseConfig) BuildAPIRequestConfig(c *gin.Context) APIRequestCo
 		Headers:    finalHeaders,
 		Query:      finalQuery,
 		Body:       finalBody,
+		Files:      files,
+		Raw:        raw,
 		Timeout:    timeout,
 		MaxRetries: maxRetries,
 		RetryDelay: retryDelay,
@@ -563,7 +574,7 @@ func ConvertToAPIResponseConfig(resp *dto.APIConfigResponse) APIResponseConfig {
 }
 
 // DoRequestWithProtocol supports both HTTP and gRPC protocols
-func DoRequestWithProtocol(ctx context.Context, resp *dto.APIConfigResponse, c *gin.Context) ([]byte, int, error) {
+func DoRequestWithProtocol(ctx context.Context, resp *dto.APIConfigResponse, c *gin.Context) ([]byte, int, http.Header, error) {
 	zapLogger := logger.GetLogger().With(zap.String("slug", resp.Path))
 
 	switch resp.Protocol {
@@ -592,6 +603,6 @@ func DoRequestWithProtocol(ctx context.Context, resp *dto.APIConfigResponse, c *
 		return DoRequestSafeWithRetry(ctx, requestConfig)
 
 	default:
-		return nil, 400, fmt.Errorf("unsupported protocol: %s", resp.Protocol)
+		return nil, 400, nil, fmt.Errorf("unsupported protocol: %s", resp.Protocol)
 	}
 }
@@ -0,0 +1,71 @@
+package integrasi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/gin-gonic/gin"
+)
+
+// DirectTemplateVars returns the variables {{var}} placeholders of static and redirect routes resolve against
+// Declared variables with an empty value are filled from the request (path, then query, header, body)
+// and every path param is available without being declared, like on proxy routes
+func DirectTemplateVars(config *dto.APIConfigResponse, c *gin.Context) map[string]Variable {
+	userParams := ExtractUserVars(c)
+
+	vars := make(map[string]Variable, len(config.Variables)+len(userParams.PathParams))
+	for name, v := range config.Variables {
+		variable := Variable{
+			Value:    getValueString(v.Value),
+			Encoding: v.Encoding,
+			DataType: DataType(v.DataType),
+		}
+		if variable.Value == "" {
+			variable.Value = requestValue(userParams, name, variable.DataType)
+		}
+		vars[name] = variable
+	}
+
+	for name, value := range userParams.PathParams {
+		if _, exists := vars[name]; !exists {
+			vars[name] = Variable{Value: value, Encoding: string(EncodingNone), DataType: TypeString}
+		}
+	}
+
+	return vars
+}
+
+// ResolveText resolves {{var}} placeholders in text, unknown names are left as is
+func ResolveText(text string, vars map[string]Variable, c *gin.Context) string {
+	return resolveTemplate(text, vars, c)
+}
+
+// ResolveBody resolves {{var}} placeholders in every string of a JSON value
+// A string holding a single placeholder takes the variable's typed value
+func ResolveBody(value interface{}, vars map[string]Variable, c *gin.Context) interface{} {
+	return resolveBodyInterface(value, vars, c)
+}
+
+// requestValue looks name up in the request's path, query, header and body params, in that order
+// Repeated query parameters and headers give all their values to array variables, the first to others
+func requestValue(params RequestParams, name string, dataType DataType) string {
+	if value, ok := params.PathParams[name]; ok {
+		return value
+	}
+	if values, ok := params.QueryParams[name]; ok {
+		return variableText(values, dataType)
+	}
+	if values, ok := params.HeaderParams[http.CanonicalHeaderKey(name)]; ok {
+		return variableText(values, dataType)
+	}
+	if value, ok := params.BodyParams[name]; ok {
+		if s, ok := value.(string); ok {
+			return s
+		}
+		if b, err := json.Marshal(value); err == nil {
+			return string(b)
+		}
+	}
+	return ""
+}
@@ -4,18 +4,22 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
 	"github.com/Payphone-Digital/gateway/pkg/circuit"
 	"github.com/Payphone-Digital/gateway/pkg/health"
+	"github.com/Payphone-Digital/gateway/pkg/hedge"
+	"github.com/Payphone-Digital/gateway/pkg/outlier"
 	"github.com/Payphone-Digital/gateway/pkg/pool"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 )
 
 // Executor handles request execution with connection pooling, circuit breaker, and health monitoring
@@ -23,15 +27,25 @@ type Executor struct {
 	pool           *pool.ConnectionPool
 	circuitBreaker *circuit.BreakerRegistry
 	healthMonitor  *health.Monitor
+	balancer       *balancer.Balancer
+	outliers       *outlier.Detector
+	hedger         *hedge.Hedger
+	retryBudget    *retry.Budget
+	healthChecks   map[string]targetHealthChecks // By URL config address
+	config         ExecutorConfig
 	logger         *zap.Logger
 	mu             sync.RWMutex
 }
 
 // ExecutorConfig holds executor configuration
+// PoolConfig and CircuitConfig are the defaults a URL config's own connection and breaker settings override
 type ExecutorConfig struct {
-	PoolConfig     pool.PoolConfig
-	CircuitConfig  circuit.Config
-	HealthInterval time.Duration
+	PoolConfig      pool.PoolConfig
+	CircuitConfig   circuit.Config
+	OutlierConfig   outlier.Config
+	RetryBudget     retry.BudgetConfig
+	HealthInterval  time.Duration
+	ResponseHeaders HeaderPolicy // Upstream response headers passed on, URL configs add their own lists
 }
 
 // DefaultExecutorConfig returns sensible defaults
@@ -39,6 +53,8 @@ func DefaultExecutorConfig() ExecutorConfig {
 	return ExecutorConfig{
 		PoolConfig:     pool.DefaultPoolConfig(),
 		CircuitConfig:  circuit.DefaultConfig(),
+		OutlierConfig:  outlier.DefaultConfig(),
+		RetryBudget:    retry.DefaultBudgetConfig(),
 		HealthInterval: 30 * time.Second,
 	}
 }
@@ -53,6 +69,12 @@ func NewExecutor(config ExecutorConfig, logger *zap.Logger) *Executor {
 		pool:           pool.NewConnectionPool(config.PoolConfig, logger),
 		circuitBreaker: circuit.NewBreakerRegistry(config.CircuitConfig, logger),
 		healthMonitor:  health.NewMonitor(config.HealthInterval, logger),
+		balancer:       balancer.New(),
+		outliers:       outlier.NewDetector(config.OutlierConfig, logger),
+		hedger:         hedge.New(),
+		retryBudget:    retry.NewBudget(config.RetryBudget),
+		healthChecks:   make(map[string]targetHealthChecks),
+		config:         config,
 		logger:         logger,
 	}
 
@@ -63,65 +85,145 @@ func NewExecutor(config ExecutorConfig, logger *zap.Logger) *Executor {
 }
 
 // ExecuteRequest executes a request based on protocol with full resilience
-func (e *Executor) ExecuteRequest(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context) ([]byte, int, error) {
+func (e *Executor) ExecuteRequest(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context) ([]byte, int, http.Header, error) {
+	return e.Prepare(config, c)(ctx)
+}
+
+// Prepare resolves the outbound request of config from c and returns a function sending it with full resilience
+// URL configs with targets send it to the target their load balancing strategy picks among the healthy ones,
+// and HTTP retries to another healthy target.
+// Hedged routes send a second copy to another target when the first one is slow.
+// The returned function doesn't touch c, but multipart file parts are read from the upload's temporary files when
+// it runs; those are removed once the request completes, so only requests without file parts may be sent later
+func (e *Executor) Prepare(config *dto.APIConfigResponse, c *gin.Context) func(ctx context.Context) ([]byte, int, http.Header, error) {
+	routed, upstream, err := e.pickTarget(config, c)
+	if err != nil {
+		return func(ctx context.Context) ([]byte, int, http.Header, error) {
+			return nil, http.StatusServiceUnavailable, nil, fmt.Errorf("service unavailable: %w", err)
+		}
+	}
+
+	primary := e.prepareRetargeted(config, routed, upstream, c)
+	if primary == nil {
+		send, _, err := e.prepareSend(routed, c)
+		if err != nil {
+			return func(ctx context.Context) ([]byte, int, http.Header, error) {
+				return nil, http.StatusBadRequest, nil, err
+			}
+		}
+		primary = func(ctx context.Context) ([]byte, int, http.Header, error) {
+			return e.execute(ctx, routed, upstream, send)
+		}
+	}
+	if second := e.prepareHedge(config, routed, upstream, c); second != nil {
+		return e.hedged(config, primary, second)
+	}
+	return primary
+}
+
+// prepareSend builds the request of config by its protocol
+// idempotent reports whether the outbound request may be sent twice, see prepareHTTP and prepareGRPC
+func (e *Executor) prepareSend(config *dto.APIConfigResponse, c *gin.Context) (send func(ctx context.Context) ([]byte, int, http.Header, error), idempotent bool, err error) {
+	switch config.Protocol {
+	case "grpc":
+		send, idempotent = e.prepareGRPC(config, c)
+		return send, idempotent, nil
+	case "http", "":
+		send, idempotent = e.prepareHTTP(config, c)
+		return send, idempotent, nil
+	default:
+		return nil, false, fmt.Errorf("unsupported protocol: %s", config.Protocol)
+	}
+}
+
+// execute runs send behind the circuit breaker of config's upstream and records the outcome
+// The response headers returned are the ones the URL config's header policy passes on
+// upstream names the URL config a target was picked from, empty when config has no targets
+func (e *Executor) execute(ctx context.Context, config *dto.APIConfigResponse, upstream string, send func(ctx context.Context) ([]byte, int, http.Header, error)) ([]byte, int, http.Header, error) {
 	address := config.URLConfig.URL
-	protocol := config.Protocol
 
 	e.logger.Info("Executing request",
 		zap.String("slug", config.Path),
-		zap.String("protocol", protocol),
+		zap.String("protocol", config.Protocol),
 		zap.String("address", address),
 		zap.String("method", config.Method),
 	)
 
-	// Get circuit breaker for this backend
-	breaker := e.circuitBreaker.GetOrCreate(address)
-
-	// Check circuit breaker
-	if err := breaker.Allow(); err != nil {
-		e.logger.Warn("Circuit breaker blocked request",
-			zap.String("address", address),
-			zap.String("state", breaker.State().String()),
-			zap.Error(err),
-		)
-		return nil, http.StatusServiceUnavailable, fmt.Errorf("service unavailable: %w", err)
+	// Get circuit breaker for this backend, or this route on it, unless the URL config disables it
+	var breaker *circuit.Breaker
+	if config.URLConfig.CircuitBreakerEnabled {
+		breaker = e.circuitBreaker.GetOrCreateWithConfig(breakerName(address, config), e.circuitConfigFor(config.URLConfig))
+
+		if err := breaker.Allow(); err != nil {
+			e.logger.Warn("Circuit breaker blocked request",
+				zap.String("address", address),
+				zap.String("breaker", breakerName(address, config)),
+				zap.String("state", breaker.State().String()),
+				zap.Error(err),
+			)
+			return nil, http.StatusServiceUnavailable, nil, fmt.Errorf("service unavailable: %w", err)
+		}
 	}
 
-	// Execute based on protocol
-	var body []byte
-	var statusCode int
-	var err error
+	release := e.balancer.Acquire(address)
+	start := time.Now()
+	body, statusCode, header, err := send(ctx)
+	elapsed := time.Since(start)
+	release()
+	header = e.headerPolicyFor(config.URLConfig).Filter(header)
 
-	switch protocol {
-	case "grpc":
-		body, statusCode, err = e.executeGRPC(ctx, config, c)
-	case "http", "":
-		body, statusCode, err = e.executeHTTP(ctx, config, c)
-	default:
-		err = fmt.Errorf("unsupported protocol: %s", protocol)
-		statusCode = http.StatusBadRequest
+	// Cancelled by the caller, e.g. the losing copy of a hedged request, says nothing about the upstream
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return body, statusCode, header, err
+	}
+
+	// Record result with the target's peers for outlier detection
+	failed := err != nil || statusCode >= 500
+	if upstream != "" {
+		e.outliers.Record(upstream, address, failed)
 	}
 
 	// Record result in circuit breaker
-	if err != nil || statusCode >= 500 {
-		breaker.Record(fmt.Errorf("request failed: %v, status: %d", err, statusCode))
+	if failed {
+		if breaker != nil {
+			breaker.RecordCall(fmt.Errorf("request failed: %v, status: %d", err, statusCode), elapsed)
+		}
 		e.pool.RecordFailure(address, err)
 	} else {
-		breaker.Record(nil)
+		if breaker != nil {
+			breaker.RecordCall(nil, elapsed)
+		}
 		e.pool.RecordSuccess(address)
 	}
 
-	return body, statusCode, err
+	return body, statusCode, header, err
 }
 
-// executeHTTP executes an HTTP request
-func (e *Executor) executeHTTP(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context) ([]byte, int, error) {
+// prepareHTTP builds the HTTP request of config, with upstream authentication, sent through the pooled client
+// Like retries, the request is idempotent by its outbound method or an Idempotency-Key among its outbound headers
+func (e *Executor) prepareHTTP(config *dto.APIConfigResponse, c *gin.Context) (func(ctx context.Context) ([]byte, int, http.Header, error), bool) {
 	address := config.URLConfig.URL
 	tlsEnabled := config.URLConfig.TLSEnabled
+	requestConfig := e.buildHTTPRequest(config, c)
+	poolConfig := e.poolConfigFor(config.URLConfig)
+	idempotent := retry.Idempotent(requestConfig.Method) || headerValue(requestConfig.Headers, retry.IdempotencyKeyHeader) != ""
+
+	return func(ctx context.Context) ([]byte, int, http.Header, error) {
+		// Get HTTP client from pool
+		client := e.pool.GetHTTPClientWithConfig(config.URLConfig.ID, address, tlsEnabled, poolConfig)
+
+		e.logger.Debug("Executing HTTP request",
+			zap.String("method", requestConfig.Method),
+			zap.String("url", requestConfig.URL),
+			zap.Int("timeout", requestConfig.Timeout),
+		)
 
-	// Get HTTP client from pool
-	client := e.pool.GetHTTPClient(address, tlsEnabled)
+		return DoRequestWithClient(ctx, client, requestConfig)
+	}, idempotent
+}
 
+// buildHTTPRequest resolves the outbound HTTP request of config from c, with upstream authentication and its retry policy
+func (e *Executor) buildHTTPRequest(config *dto.APIConfigResponse, c *gin.Context) APIRequestConfig {
 	// Build request config
 	apiConfig := ConvertToAPIResponseConfig(config)
 	requestConfig := apiConfig.BuildAPIRequestConfig(c)
@@ -131,32 +233,16 @@ func (e *Executor) executeHTTP(ctx context.Context, config *dto.APIConfigRespons
 		applyUpstreamAuth(&requestConfig, config.URLConfig)
 	}
 
-	e.logger.Debug("Executing HTTP request",
-		zap.String("method", requestConfig.Method),
-		zap.String("url", requestConfig.URL),
-		zap.Int("timeout", requestConfig.Timeout),
-	)
-
-	// Execute with custom client
-	return e.doHTTPRequest(ctx, client, requestConfig)
-}
-
-// doHTTPRequest performs the actual HTTP request
-func (e *Executor) doHTTPRequest(ctx context.Context, client *http.Client, config APIRequestConfig) ([]byte, int, error) {
-	return DoRequestSafeWithRetry(ctx, config)
+	requestConfig.Retry = e.retryPolicyFor(config)
+	return requestConfig
 }
 
-// executeGRPC executes a gRPC request
-func (e *Executor) executeGRPC(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context) ([]byte, int, error) {
+// prepareGRPC builds the gRPC request of config, with upstream authentication as metadata, sent over the pooled connection
+// A unary call says nothing about its effect, it's only idempotent with an Idempotency-Key in its outbound metadata
+func (e *Executor) prepareGRPC(config *dto.APIConfigResponse, c *gin.Context) (func(ctx context.Context) ([]byte, int, http.Header, error), bool) {
 	address := config.URLConfig.URL
 	tlsEnabled := config.URLConfig.TLSEnabled
 
-	// Get gRPC connection from pool
-	conn, err := e.pool.GetGRPCConnection(ctx, address, tlsEnabled)
-	if err != nil {
-		return nil, http.StatusServiceUnavailable, fmt.Errorf("failed to get gRPC connection: %w", err)
-	}
-
 	// Build gRPC request config
 	vars := make(map[string]Variable)
 	for k, v := range config.Variables {
@@ -168,19 +254,71 @@ func (e *Executor) executeGRPC(ctx context.Context, config *dto.APIConfigRespons
 	}
 	grpcConfig := BuildGRPCRequestConfig(*config, vars, c)
 
-	e.logger.Debug("Executing gRPC request",
-		zap.String("service", grpcConfig.Service),
-		zap.String("method", grpcConfig.Method),
-		zap.String("address", grpcConfig.Address),
-	)
+	// Apply Upstream Authentication, query placement has no gRPC equivalent so it's only ever sent as metadata
+	if config.URLConfig.AuthType != "" && config.URLConfig.AuthType != "none" {
+		auth := APIRequestConfig{Headers: grpcConfig.Headers}
+		applyUpstreamAuth(&auth, config.URLConfig)
+		grpcConfig.Headers = auth.Headers
+	}
+
+	return func(ctx context.Context) ([]byte, int, http.Header, error) {
+		// Get gRPC connection from pool
+		conn, err := e.pool.GetGRPCConnection(ctx, address, tlsEnabled)
+		if err != nil {
+			return nil, http.StatusServiceUnavailable, nil, fmt.Errorf("failed to get gRPC connection: %w", err)
+		}
+
+		e.logger.Debug("Executing gRPC request",
+			zap.String("service", grpcConfig.Service),
+			zap.String("method", grpcConfig.Method),
+			zap.String("address", grpcConfig.Address),
+		)
+
+		return globalGRPCHandler.ExecuteGRPCRequestWithConn(ctx, conn, grpcConfig)
+	}, headerValue(grpcConfig.Headers, retry.IdempotencyKeyHeader) != ""
+}
 
-	// Execute gRPC request
-	return e.doGRPCRequest(ctx, conn, grpcConfig)
+// poolConfigFor returns the executor's pool config with the URL config's connection settings applied
+func (e *Executor) poolConfigFor(urlConfig dto.URLConfigResponse) pool.PoolConfig {
+	config := e.config.PoolConfig
+	if urlConfig.MaxConnections > 0 {
+		config.MaxConnections = urlConfig.MaxConnections
+	}
+	if urlConfig.ConnectionTimeout > 0 {
+		config.ConnectionTimeout = time.Duration(urlConfig.ConnectionTimeout) * time.Second
+	}
+	if urlConfig.ReadTimeout > 0 {
+		config.ReadTimeout = time.Duration(urlConfig.ReadTimeout) * time.Second
+	}
+	if urlConfig.WriteTimeout > 0 {
+		config.WriteTimeout = time.Duration(urlConfig.WriteTimeout) * time.Second
+	}
+	return config
 }
 
-// doGRPCRequest performs the actual gRPC request
-func (e *Executor) doGRPCRequest(ctx context.Context, conn *grpc.ClientConn, config GRPCRequestConfig) ([]byte, int, error) {
-	return globalGRPCHandler.ExecuteGRPCRequest(ctx, config)
+// retryPolicyFor returns the retry policy of config: retries and base delay from the route, retried
+// status codes and error classes and the per-try timeout from its URL config, within the executor's budget
+func (e *Executor) retryPolicyFor(config *dto.APIConfigResponse) *retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.Budget = e.retryBudget
+	if config.MaxRetries > 0 {
+		policy.MaxRetries = config.MaxRetries
+	}
+	if config.RetryDelay > 0 {
+		policy.BaseDelay = time.Duration(config.RetryDelay) * time.Second
+	}
+
+	urlConfig := config.URLConfig
+	if urlConfig.RetryOnStatusCodes != "" {
+		policy.RetryOnStatus = retry.ParseStatusCodes(urlConfig.RetryOnStatusCodes)
+	}
+	if urlConfig.RetryOnErrors != "" {
+		policy.RetryOn = retry.ParseClasses(urlConfig.RetryOnErrors)
+	}
+	if urlConfig.RetryPerTryTimeoutMillis > 0 {
+		policy.PerTryTimeout = time.Duration(urlConfig.RetryPerTryTimeoutMillis) * time.Millisecond
+	}
+	return &policy
 }
 
 // RegisterHealthCheck registers a backend for health checking
@@ -188,11 +326,11 @@ func (e *Executor) RegisterHealthCheck(address, protocol, path string) {
 	switch protocol {
 	case "http":
 		client := e.pool.GetHTTPClient(address, false)
-		e.healthMonitor.RegisterHTTPChecker(address, path, client)
+		e.healthMonitor.RegisterHTTPChecker(address, path, client, 0)
 	case "grpc":
 		conn, err := e.pool.GetGRPCConnection(context.Background(), address, false)
 		if err == nil {
-			e.healthMonitor.RegisterGRPCChecker(address, conn)
+			e.healthMonitor.RegisterGRPCChecker(address, conn, 0)
 		}
 	}
 }
@@ -204,6 +342,16 @@ func (e *Executor) IsBackendHealthy(address string) bool {
 		return false
 	}
 
+	// Check pool health
+	if !e.pool.IsHealthy(address) {
+		return false
+	}
+
+	// Check outlier ejection
+	if e.outliers.IsEjected(address) {
+		return false
+	}
+
 	// Check circuit breaker
 	if breaker, exists := e.circuitBreaker.Get(address); exists {
 		if breaker.IsOpen() {
@@ -220,6 +368,10 @@ func (e *Executor) GetStats() map[string]interface{} {
 		"pool":            e.pool.Stats(),
 		"circuit_breaker": e.circuitBreaker.Stats(),
 		"health":          e.healthMonitor.GetAllResults(),
+		"in_flight":       e.balancer.InFlight(),
+		"outlier":         e.outliers.Stats(),
+		"retry_budget":    e.retryBudget.Stats(),
+		"hedge":           e.hedger.Stats(),
 	}
 }
 
@@ -244,7 +396,7 @@ func GetGlobalExecutor(logger *zap.Logger) *Executor {
 }
 
 // ExecuteWithResilience is a convenience function for executing requests with full resilience
-func ExecuteWithResilience(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context, logger *zap.Logger) ([]byte, int, error) {
+func ExecuteWithResilience(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context, logger *zap.Logger) ([]byte, int, http.Header, error) {
 	executor := GetGlobalExecutor(logger)
 	return executor.ExecuteRequest(ctx, config, c)
 }
@@ -298,31 +450,31 @@ func WrapResponse(body []byte, statusCode int, config *dto.APIConfigResponse, la
 // applyUpstreamAuth injects authentication credentials into the request config
 func applyUpstreamAuth(reqConfig *APIRequestConfig, urlConfig dto.URLConfigResponse) {
 	if reqConfig.Headers == nil {
-		reqConfig.Headers = make(map[string]string)
+		reqConfig.Headers = make(map[string][]string)
 	}
 	if reqConfig.Query == nil {
-		reqConfig.Query = make(map[string]string)
+		reqConfig.Query = make(map[string][]string)
 	}
 
 	switch urlConfig.AuthType {
 	case "basic":
 		if urlConfig.AuthUsername != "" && urlConfig.AuthPassword != "" {
 			auth := urlConfig.AuthUsername + ":" + urlConfig.AuthPassword
-			reqConfig.Headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+			reqConfig.Headers["Authorization"] = []string{"Basic " + base64.StdEncoding.EncodeToString([]byte(auth))}
 		}
 	case "bearer":
 		if urlConfig.AuthToken != "" {
-			reqConfig.Headers["Authorization"] = "Bearer " + urlConfig.AuthToken
+			reqConfig.Headers["Authorization"] = []string{"Bearer " + urlConfig.AuthToken}
 		}
 	case "apikey":
 		key := urlConfig.AuthKey
 		value := urlConfig.AuthValue
 		if key != "" && value != "" {
 			if urlConfig.AuthAddTo == "query" {
-				reqConfig.Query[key] = value
+				reqConfig.Query[key] = []string{value}
 			} else {
 				// Default to header
-				reqConfig.Headers[key] = value
+				reqConfig.Headers[key] = []string{value}
 			}
 		}
 	}
@@ -0,0 +1,796 @@
+package integrasi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
+	"github.com/Payphone-Digital/gateway/pkg/health"
+	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	logger.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+func newTestContext(method, target string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(method, target, nil)
+	return c
+}
+
+func newTestRoute(url string, urlConfig dto.URLConfigResponse) *dto.APIConfigResponse {
+	urlConfig.URL = url
+	urlConfig.Protocol = "http"
+	urlConfig.IsActive = true
+	return &dto.APIConfigResponse{
+		Path:      "test-route",
+		Method:    http.MethodGet,
+		Protocol:  "http",
+		URL:       url + "/resource",
+		Timeout:   5,
+		URLConfig: urlConfig,
+	}
+}
+
+func TestExecutor_UpstreamAuthAndPooledClient(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{
+		AuthType:       "bearer",
+		AuthToken:      "secret",
+		MaxConnections: 7,
+	})
+
+	body, status, _, err := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if status != http.StatusOK || string(body) != `{"ok":true}` {
+		t.Errorf("Expected 200 {\"ok\":true}, got %d %s", status, body)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Expected upstream auth header, got %q", gotAuth)
+	}
+
+	client := executor.pool.GetHTTPClientWithConfig(route.URLConfig.ID, server.URL, false, executor.poolConfigFor(route.URLConfig))
+	if transport := client.Transport.(*http.Transport); transport.MaxConnsPerHost != 7 {
+		t.Errorf("Expected pooled client with 7 max conns per host, got %d", transport.MaxConnsPerHost)
+	}
+	if executor.pool.Stats()["http_clients"].(int) != 1 {
+		t.Errorf("Expected the request to use the pooled client")
+	}
+}
+
+func TestExecutor_CircuitBreakerFromURLConfig(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{
+		CircuitBreakerEnabled:   true,
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerTimeout:   60,
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, status, _, _ := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource")); status != http.StatusBadGateway {
+			t.Fatalf("Expected upstream 502, got %d", status)
+		}
+	}
+
+	_, status, _, err := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	if !errors.Is(err, circuit.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen after threshold failures, got %v", err)
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 from open breaker, got %d", status)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("Expected 2 upstream calls, got %d", got)
+	}
+
+	// Disabled breakers never block
+	route.URLConfig.CircuitBreakerEnabled = false
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource")); status != http.StatusBadGateway {
+		t.Errorf("Expected upstream 502 with breaker disabled, got %d", status)
+	}
+}
+
+func TestExecutor_BalancesOverTargets(t *testing.T) {
+	var healthyCalls, failingCalls int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Health checks of the targets may come in as well
+		if r.URL.Path == "/resource" {
+			atomic.AddInt32(&healthyCalls, 1)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/resource" {
+			atomic.AddInt32(&failingCalls, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer failing.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute("http://upstream.internal", dto.URLConfigResponse{
+		Targets:                 []dto.UpstreamTarget{{URL: healthy.URL}, {URL: failing.URL}},
+		LoadBalancingStrategy:   "round_robin",
+		CircuitBreakerEnabled:   true,
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerTimeout:   60,
+	})
+
+	for i := 0; i < 6; i++ {
+		executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	}
+
+	// The failing target's breaker opens on its first failure, it's skipped from then on
+	if got := atomic.LoadInt32(&failingCalls); got != 1 {
+		t.Errorf("Expected 1 call to the failing target, got %d", got)
+	}
+	if got := atomic.LoadInt32(&healthyCalls); got != 5 {
+		t.Errorf("Expected 5 calls to the healthy target, got %d", got)
+	}
+}
+
+func TestExecutor_TargetHealthChecks(t *testing.T) {
+	// Neither target has a health endpoint, both answer it with a 404
+	var healthChecks int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			atomic.AddInt32(&healthChecks, 1)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute("http://upstream.internal", dto.URLConfigResponse{
+		Targets:             []dto.UpstreamTarget{{URL: first.URL}, {URL: second.URL}},
+		HealthCheckPath:     "/health",
+		HealthCheckInterval: 60,
+	})
+	executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&healthChecks) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if result, ok := executor.healthMonitor.GetResult(first.URL); !ok || result.Status != health.StatusDegraded {
+		t.Fatalf("Expected the first target checked as degraded, got %+v", result)
+	}
+
+	// Degraded targets answer, they keep taking traffic
+	for i := 0; i < 4; i++ {
+		if _, status, _, err := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource")); err != nil || status != http.StatusOK {
+			t.Fatalf("Expected targets without a health endpoint to be routable, got %d (%v)", status, err)
+		}
+	}
+
+	// Checked once per interval, not once per request
+	if got := atomic.LoadInt32(&healthChecks); got != 2 {
+		t.Errorf("Expected one check per target within the interval, got %d", got)
+	}
+
+	// A target taken out of the URL config is no longer checked
+	route.URLConfig.Targets = []dto.UpstreamTarget{{URL: first.URL}}
+	executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	if _, ok := executor.healthMonitor.GetResult(second.URL); ok {
+		t.Error("Expected the removed target's health check to be unregistered")
+	}
+	if _, ok := executor.healthMonitor.GetResult(first.URL); !ok {
+		t.Error("Expected the remaining target to stay checked")
+	}
+}
+
+func TestExecutor_EjectsOutlierTargets(t *testing.T) {
+	var healthyCalls, failingCalls int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/resource" {
+			atomic.AddInt32(&healthyCalls, 1)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/resource" {
+			atomic.AddInt32(&failingCalls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer failing.Close()
+
+	config := DefaultExecutorConfig()
+	config.OutlierConfig.ConsecutiveErrors = 2
+	executor := NewExecutor(config, nil)
+	defer executor.Close()
+
+	route := newTestRoute("http://upstream.internal", dto.URLConfigResponse{
+		Targets:               []dto.UpstreamTarget{{URL: healthy.URL}, {URL: failing.URL}},
+		LoadBalancingStrategy: "round_robin",
+	})
+
+	for i := 0; i < 8; i++ {
+		executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	}
+
+	// Ejected after its second failure in a row, with the breaker off
+	if got := atomic.LoadInt32(&failingCalls); got != 2 {
+		t.Errorf("Expected 2 calls to the failing target, got %d", got)
+	}
+	if got := atomic.LoadInt32(&healthyCalls); got != 6 {
+		t.Errorf("Expected 6 calls to the healthy target, got %d", got)
+	}
+
+	stats := executor.GetStats()["outlier"].(map[string]interface{})
+	upstream := stats["http://upstream.internal"].(map[string]interface{})
+	if upstream["ejected"] != 1 {
+		t.Errorf("Expected 1 ejected target in stats, got %v", upstream["ejected"])
+	}
+}
+
+func TestExecutor_RouteScopedWindowBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	urlConfig := dto.URLConfigResponse{
+		CircuitBreakerEnabled:      true,
+		CircuitBreakerTimeout:      60,
+		CircuitBreakerScope:        BreakerScopeRoute,
+		CircuitBreakerWindowType:   circuit.WindowCount,
+		CircuitBreakerWindowSize:   4,
+		CircuitBreakerMinimumCalls: 4,
+		CircuitBreakerFailureRate:  50,
+	}
+	broken := newTestRoute(server.URL, urlConfig)
+	broken.Path, broken.URL = "broken-route", server.URL+"/broken"
+	healthy := newTestRoute(server.URL, urlConfig)
+	healthy.Path, healthy.URL = "healthy-route", server.URL+"/healthy"
+
+	for i := 0; i < 4; i++ {
+		executor.ExecuteRequest(context.Background(), broken, newTestContext(http.MethodGet, "/broken"))
+	}
+	if _, _, _, err := executor.ExecuteRequest(context.Background(), broken, newTestContext(http.MethodGet, "/broken")); !errors.Is(err, circuit.ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen for the failing route, got %v", err)
+	}
+
+	// The other route on the same upstream keeps its own breaker
+	if _, status, _, err := executor.ExecuteRequest(context.Background(), healthy, newTestContext(http.MethodGet, "/healthy")); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected 200 for the healthy route, got %d: %v", status, err)
+	}
+
+	// Forced open by an operator until released
+	name := server.URL + " GET healthy-route"
+	if _, err := executor.ControlBreaker(name, BreakerForceOpen); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), healthy, newTestContext(http.MethodGet, "/healthy")); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 from a forced open breaker, got %d", status)
+	}
+	if _, err := executor.ControlBreaker(name, BreakerRelease); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), healthy, newTestContext(http.MethodGet, "/healthy")); status != http.StatusOK {
+		t.Errorf("Expected 200 after release, got %d", status)
+	}
+
+	for _, action := range []string{BreakerForceOpen, BreakerForceClose, BreakerRelease} {
+		if _, err := executor.ControlBreaker("unknown", action); !errors.Is(err, ErrUnknownBreaker) {
+			t.Errorf("Expected ErrUnknownBreaker for %s, got %v", action, err)
+		}
+	}
+	if _, exists := executor.CircuitBreakers()["unknown"]; exists {
+		t.Error("Expected no breaker created for an unknown name")
+	}
+}
+
+func TestExecutor_BreakersPerURLConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	// Two URL configs on one address with different breaker settings
+	windowed := newTestRoute(server.URL, dto.URLConfigResponse{
+		ID:                         1,
+		CircuitBreakerEnabled:      true,
+		CircuitBreakerTimeout:      60,
+		CircuitBreakerWindowType:   circuit.WindowCount,
+		CircuitBreakerWindowSize:   4,
+		CircuitBreakerMinimumCalls: 4,
+		CircuitBreakerFailureRate:  50,
+	})
+	consecutive := newTestRoute(server.URL, dto.URLConfigResponse{
+		ID:                      2,
+		CircuitBreakerEnabled:   true,
+		CircuitBreakerThreshold: 100,
+	})
+
+	// Interleaved calls neither share nor reset the windowed breaker
+	for i := 0; i < 4; i++ {
+		executor.ExecuteRequest(context.Background(), windowed, newTestContext(http.MethodGet, "/resource"))
+		executor.ExecuteRequest(context.Background(), consecutive, newTestContext(http.MethodGet, "/resource"))
+	}
+	if _, _, _, err := executor.ExecuteRequest(context.Background(), windowed, newTestContext(http.MethodGet, "/resource")); !errors.Is(err, circuit.ErrCircuitOpen) {
+		t.Errorf("Expected the windowed URL config's breaker to open, got %v", err)
+	}
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), consecutive, newTestContext(http.MethodGet, "/resource")); status != http.StatusBadGateway {
+		t.Errorf("Expected the other URL config's breaker to stay closed, got %d", status)
+	}
+
+	breakers := executor.CircuitBreakers()
+	if _, ok := breakers["#1 "+server.URL]; !ok {
+		t.Errorf("Expected breakers named by URL config, got %v", breakers)
+	}
+}
+
+func TestExecutor_RetryPolicy(t *testing.T) {
+	var calls int32
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		// Unavailable on the first try of every request, asking for an immediate retry
+		mu.Lock()
+		first := !seen[r.Header.Get("X-Request")]
+		seen[r.Header.Get("X-Request")] = true
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{RetryOnStatusCodes: "503"})
+	route.MaxRetries = 2
+
+	route.Headers = map[string]string{"X-Request": "get"}
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource")); status != http.StatusOK {
+		t.Errorf("Expected GET to succeed on retry, got %d", status)
+	}
+
+	// Not idempotent: the 503 is returned as is
+	route.Method = http.MethodPost
+	route.Headers = map[string]string{"X-Request": "post"}
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodPost, "/resource")); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected POST not to be retried, got %d", status)
+	}
+
+	// Unless the upstream gets an idempotency key
+	route.Headers = map[string]string{"X-Request": "post-with-key", "Idempotency-Key": "order-1"}
+	if _, status, _, _ := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodPost, "/resource")); status != http.StatusOK {
+		t.Errorf("Expected POST with an idempotency key to succeed on retry, got %d", status)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 5 {
+		t.Errorf("Expected 5 upstream calls, got %d", got)
+	}
+}
+
+func TestExecutor_RetriesOnAnotherTarget(t *testing.T) {
+	var healthyCalls, failingCalls int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/resource" {
+			atomic.AddInt32(&healthyCalls, 1)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/resource" {
+			atomic.AddInt32(&failingCalls, 1)
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer failing.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute("http://upstream.internal", dto.URLConfigResponse{
+		Targets:               []dto.UpstreamTarget{{URL: failing.URL}, {URL: healthy.URL}},
+		LoadBalancingStrategy: "round_robin",
+	})
+	route.MaxRetries = 1
+
+	// A single retry is enough when it goes to the other target
+	for i := 0; i < 4; i++ {
+		if _, status, _, _ := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource")); status != http.StatusOK {
+			t.Errorf("Expected request %d to succeed on the healthy target, got %d", i, status)
+		}
+	}
+
+	if got := atomic.LoadInt32(&failingCalls); got == 0 {
+		t.Error("Expected the failing target to be tried first at least once")
+	}
+	if got := atomic.LoadInt32(&healthyCalls); got != 4 {
+		t.Errorf("Expected 4 calls to the healthy target, got %d", got)
+	}
+}
+
+func TestExecutor_HedgesSlowRequests(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/resource" {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"target":"fast"}`))
+	}))
+	defer fast.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute("http://upstream.internal", dto.URLConfigResponse{
+		Targets:               []dto.UpstreamTarget{{URL: slow.URL}, {URL: fast.URL}},
+		LoadBalancingStrategy: "round_robin",
+	})
+	route.ID = 7
+	route.Hedge = &dto.RouteHedge{DelayMs: 20, MaxPercent: 100}
+
+	// One of the two lands on the slow target first and is answered by the hedge
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		_, status, _, err := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("Expected 200, got %d (%v)", status, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the hedge to answer quickly, took %s", elapsed)
+		}
+	}
+
+	snap := executor.HedgeStats(7)
+	if snap.Requests != 2 || snap.Hedged != 1 || snap.HedgeWins != 1 {
+		t.Errorf("Unexpected hedge stats %+v", snap)
+	}
+}
+
+func TestExecutor_HedgesOnlyIdempotentOutboundRequests(t *testing.T) {
+	var calls int32
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/resource" {
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}
+	first := httptest.NewServer(http.HandlerFunc(slow))
+	defer first.Close()
+	second := httptest.NewServer(http.HandlerFunc(slow))
+	defer second.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute("http://upstream.internal", dto.URLConfigResponse{
+		Targets: []dto.UpstreamTarget{{URL: first.URL}, {URL: second.URL}},
+	})
+	route.ID = 8
+	route.Method = http.MethodPost
+	route.Hedge = &dto.RouteHedge{DelayMs: 20, MaxPercent: 100}
+
+	// The client's key isn't sent upstream, the backend couldn't tell the two copies apart
+	c := newTestContext(http.MethodPost, "/resource")
+	c.Request.Header.Set(retry.IdempotencyKeyHeader, "order-42")
+	if _, status, _, err := executor.ExecuteRequest(context.Background(), route, c); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d (%v)", status, err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("Expected a POST without an outbound Idempotency-Key to be sent once, got %d calls", got)
+	}
+
+	// Passed on by the route, the key makes the second copy safe
+	route.Headers = map[string]string{retry.IdempotencyKeyHeader: "{{" + retry.IdempotencyKeyHeader + "}}"}
+	if _, status, _, err := executor.ExecuteRequest(context.Background(), route, c); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d (%v)", status, err)
+	}
+	if snap := executor.HedgeStats(8); snap.Hedged != 1 {
+		t.Errorf("Expected only the request carrying its key upstream to be hedged, got %+v", snap)
+	}
+}
+
+func TestExecutor_StreamsBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/resource" {
+			return
+		}
+		// Echo the upload back as a CSV report
+		upload, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("X-Report", r.Header.Get("Content-Type"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write(upload)
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{})
+	route.Method = http.MethodPost
+	route.Stream = true
+
+	// A chunked upload of unknown length, never held by the gateway as a whole
+	upload := strings.Repeat("id,amount\n1,100\n", 64*1024)
+	reader, writer := io.Pipe()
+	go func() {
+		for i := 0; i < len(upload); i += 4096 {
+			writer.Write([]byte(upload[i : i+4096]))
+		}
+		writer.Close()
+	}()
+
+	c := newTestContext(http.MethodPost, "/resource")
+	c.Request = httptest.NewRequest(http.MethodPost, "/resource", reader)
+	c.Request.ContentLength = -1
+	c.Request.Header.Set("Content-Type", "application/octet-stream")
+	c.Set(StreamBodyKey, true)
+
+	resp, err := executor.Stream(context.Background(), route, c)
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Content-Type") != "text/csv" {
+		t.Errorf("Expected the upstream's status and headers, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if got := resp.Header.Get("X-Report"); got != "application/octet-stream" {
+		t.Errorf("Expected the client's Content-Type upstream, got %q", got)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != upload {
+		t.Errorf("Expected the upload echoed back (%d bytes), got %d bytes (%v)", len(upload), len(body), err)
+	}
+
+	header := http.Header{}
+	CopyResponseHeaders(header, http.Header{"Connection": {"close"}, "Content-Disposition": {"attachment"}})
+	if header.Get("Connection") != "" || header.Get("Content-Disposition") != "attachment" {
+		t.Errorf("Expected only end-to-end headers copied, got %v", header)
+	}
+}
+
+func TestExecutor_EncodesBodiesByContentType(t *testing.T) {
+	type received struct {
+		contentType string
+		fields      map[string][]string
+		file        string
+	}
+	got := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/resource" {
+			return
+		}
+		var rec received
+		rec.contentType = r.Header.Get("Content-Type")
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			rec.fields = r.MultipartForm.Value
+			if files := r.MultipartForm.File["document"]; len(files) == 1 {
+				f, _ := files[0].Open()
+				content, _ := io.ReadAll(f)
+				rec.file = files[0].Filename + ":" + string(content)
+			}
+		} else if err := r.ParseForm(); err == nil {
+			rec.fields = r.PostForm
+		}
+		got <- rec
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	// A KYC upload passed on as multipart, the file renamed by the body template
+	var upload bytes.Buffer
+	writer := multipart.NewWriter(&upload)
+	writer.WriteField("customer_id", "42")
+	part, _ := writer.CreateFormFile("ktp", "ktp.jpg")
+	part.Write([]byte("jpeg bytes"))
+	writer.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{})
+	route.Method = http.MethodPost
+	route.Headers = map[string]string{"Content-Type": "multipart/form-data"}
+	route.Body = map[string]interface{}{"cif": "{{customer_id}}", "document": "{{ktp}}"}
+	route.Variables = map[string]dto.Variable{"customer_id": {}, "ktp": {}}
+
+	c := newTestContext(http.MethodPost, "/resource")
+	c.Request = httptest.NewRequest(http.MethodPost, "/resource", &upload)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+	if _, status, _, err := executor.ExecuteRequest(context.Background(), route, c); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected the upload to succeed, got %d (%v)", status, err)
+	}
+	rec := <-got
+	if !strings.HasPrefix(rec.contentType, "multipart/form-data; boundary=") || rec.fields["cif"][0] != "42" || rec.file != "ktp.jpg:jpeg bytes" {
+		t.Errorf("Unexpected multipart request %+v", rec)
+	}
+
+	// A JSON request sent on to a form endpoint
+	route.Headers = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	route.Body = map[string]interface{}{"account": "{{customer_id}}"}
+	c = newTestContext(http.MethodPost, "/resource")
+	c.Request = httptest.NewRequest(http.MethodPost, "/resource", strings.NewReader(`{"customer_id": "42"}`))
+	if _, status, _, err := executor.ExecuteRequest(context.Background(), route, c); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected the form request to succeed, got %d (%v)", status, err)
+	}
+	rec = <-got
+	if rec.contentType != "application/x-www-form-urlencoded" || rec.fields["account"][0] != "42" {
+		t.Errorf("Unexpected form request %+v", rec)
+	}
+}
+
+func TestExecutor_PassesUpstreamHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="report.csv"`)
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		w.Header().Set("Server", "upstream/1.0")
+		w.Header().Set("X-Internal-Node", "node-3")
+		w.Header().Set("Connection", "X-Hop")
+		w.Header().Set("X-Hop", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("id,name\n1,a\n"))
+		w.Header().Set("X-Checksum", "abc")
+	}))
+	defer server.Close()
+
+	config := DefaultExecutorConfig()
+	config.ResponseHeaders = HeaderPolicy{Deny: []string{"Server"}}
+	executor := NewExecutor(config, nil)
+	defer executor.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{ResponseHeadersDeny: "x-internal-*"})
+	body, status, header, err := executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	if err != nil || status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %v", status, err)
+	}
+	if string(body) != "id,name\n1,a\n" {
+		t.Errorf("Expected the body as is, got %q", body)
+	}
+	if header.Get("Content-Type") != "text/csv" || header.Get("Content-Disposition") == "" {
+		t.Errorf("Expected Content-Type and Content-Disposition to pass, got %v", header)
+	}
+	if cookies := header.Values("Set-Cookie"); len(cookies) != 2 {
+		t.Errorf("Expected both cookies, got %v", cookies)
+	}
+	if header.Get(http.TrailerPrefix+"X-Checksum") != "abc" {
+		t.Errorf("Expected the trailer under the trailer prefix, got %v", header)
+	}
+	for _, name := range []string{"Server", "X-Internal-Node", "X-Hop", "Connection", "Trailer"} {
+		if header.Get(name) != "" {
+			t.Errorf("Expected %s to be dropped, got %q", name, header.Get(name))
+		}
+	}
+
+	// An allow list on the URL config replaces the executor's
+	route = newTestRoute(server.URL, dto.URLConfigResponse{ResponseHeadersAllow: "Content-Type"})
+	_, _, header, _ = executor.ExecuteRequest(context.Background(), route, newTestContext(http.MethodGet, "/resource"))
+	if header.Get("Content-Type") != "text/csv" || header.Get("Set-Cookie") != "" || header.Get(http.TrailerPrefix+"X-Checksum") != "" {
+		t.Errorf("Expected only Content-Type to pass, got %v", header)
+	}
+}
+
+func TestExecutor_PassesMultiValueQueryAndHeaders(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	executor := NewExecutor(DefaultExecutorConfig(), nil)
+	defer executor.Close()
+
+	route := newTestRoute(server.URL, dto.URLConfigResponse{})
+	route.QueryParams = map[string]string{"status": "{{status}}", "channel": "{{channel}}"}
+	route.Headers = map[string]string{"X-Roles": "{{X-Roles}}"}
+	route.Variables = map[string]dto.Variable{
+		"status":  {DataType: TypeArray},
+		"channel": {DataType: TypeString},
+		"X-Roles": {DataType: TypeArray},
+	}
+
+	c := newTestContext(http.MethodGet, "/resource?status=PAID&status=PENDING&channel=web&channel=app")
+	c.Request.Header.Add("X-Roles", "admin")
+	c.Request.Header.Add("X-Roles", "auditor")
+	if _, status, _, err := executor.ExecuteRequest(context.Background(), route, c); err != nil || status != http.StatusOK {
+		t.Fatalf("Expected the request to succeed, got %d (%v)", status, err)
+	}
+
+	r := <-got
+	if statuses := r.URL.Query()["status"]; len(statuses) != 2 || statuses[0] != "PAID" || statuses[1] != "PENDING" {
+		t.Errorf("Expected both statuses, got %v", statuses)
+	}
+	if channels := r.URL.Query()["channel"]; len(channels) != 1 || channels[0] != "web" {
+		t.Errorf("Expected a string variable to take the first value, got %v", channels)
+	}
+	if roles := r.Header.Values("X-Roles"); len(roles) != 2 || roles[1] != "auditor" {
+		t.Errorf("Expected both roles, got %v", roles)
+	}
+
+	// Templates read array variables as arrays, and as their comma-separated items inside text
+	vars := map[string]Variable{"status": {Value: `["PAID","PENDING"]`, DataType: TypeArray}}
+	if body := resolveBodyInterface(map[string]interface{}{"statuses": "{{status}}"}, vars, c); len(body.(map[string]interface{})["statuses"].([]interface{})) != 2 {
+		t.Errorf("Expected an array in the body, got %v", body)
+	}
+	if text := resolveTemplate("status in ({{status}})", vars, c); text != "status in (PAID,PENDING)" {
+		t.Errorf("Expected comma-separated items in text, got %q", text)
+	}
+}
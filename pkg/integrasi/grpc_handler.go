@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
@@ -36,14 +37,15 @@ type GRPCRequestConfig struct {
 	Address    string                 // gRPC server address
 	Service    string                 // gRPC service name
 	Method     string                 // gRPC method name
-	Headers    map[string]string      // gRPC metadata headers
+	Headers    map[string][]string    // gRPC metadata headers
 	Message    map[string]interface{} // Request message data
 	Timeout    int                    // Timeout in seconds
 	TLSEnabled bool                   // TLS enabled
 }
 
 // ExecuteGRPCRequest executes a gRPC request
-func (h *GRPCHandler) ExecuteGRPCRequest(ctx context.Context, config GRPCRequestConfig) ([]byte, int, error) {
+// Returns the response's header and trailer metadata as headers along with its JSON body and status
+func (h *GRPCHandler) ExecuteGRPCRequest(ctx context.Context, config GRPCRequestConfig) ([]byte, int, http.Header, error) {
 	zapLogger := logger.GetLogger().With(
 		zap.String("operation", "grpc_request"),
 		zap.String("service", config.Service),
@@ -65,13 +67,39 @@ func (h *GRPCHandler) ExecuteGRPCRequest(ctx context.Context, config GRPCRequest
 	conn, err := h.getOrCreateConnection(config.Address, config.TLSEnabled)
 	if err != nil {
 		zapLogger.Error("Failed to create gRPC connection", zap.Error(err))
-		return nil, 0, fmt.Errorf("failed to create connection: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to create connection: %w", err)
 	}
 
+	return h.invoke(requestCtx, conn, config, zapLogger)
+}
+
+// ExecuteGRPCRequestWithConn executes a gRPC request over conn, e.g. a pooled connection, instead of the handler's own
+func (h *GRPCHandler) ExecuteGRPCRequestWithConn(ctx context.Context, conn *grpc.ClientConn, config GRPCRequestConfig) ([]byte, int, http.Header, error) {
+	zapLogger := logger.GetLogger().With(
+		zap.String("operation", "grpc_request"),
+		zap.String("service", config.Service),
+		zap.String("method", config.Method),
+		zap.String("address", config.Address),
+	)
+
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return h.invoke(requestCtx, conn, config, zapLogger)
+}
+
+// invoke resolves the method through server reflection and calls it over conn
+func (h *GRPCHandler) invoke(requestCtx context.Context, conn *grpc.ClientConn, config GRPCRequestConfig, zapLogger *zap.Logger) ([]byte, int, http.Header, error) {
+
 	// 2. Prepare metadata
 	md := make(metadata.MD)
 	for k, v := range config.Headers {
-		md.Set(k, v)
+		md.Set(k, v...)
 	}
 	requestCtx = metadata.NewOutgoingContext(requestCtx, md)
 
@@ -89,14 +117,14 @@ func (h *GRPCHandler) ExecuteGRPCRequest(ctx context.Context, config GRPCRequest
 	svcDesc, err := refClient.ResolveService(config.Service)
 	if err != nil {
 		zapLogger.Error("Failed to resolve service via reflection", zap.Error(err))
-		return nil, 404, fmt.Errorf("service not found: %s (ensure reflection is enabled on server)", config.Service)
+		return nil, 404, nil, fmt.Errorf("service not found: %s (ensure reflection is enabled on server)", config.Service)
 	}
 
 	// Resolve Method
 	methodDesc := svcDesc.FindMethodByName(config.Method)
 	if methodDesc == nil {
 		zapLogger.Error("Method not found in service", zap.String("method", config.Method))
-		return nil, 404, fmt.Errorf("method not found: %s", config.Method)
+		return nil, 404, nil, fmt.Errorf("method not found: %s", config.Method)
 	}
 
 	// 4. Create Dynamic Message for Input
@@ -105,13 +133,13 @@ func (h *GRPCHandler) ExecuteGRPCRequest(ctx context.Context, config GRPCRequest
 	// Convert JSON map to JSON bytes first, then unmarshal into Dynamic Message
 	msgBytes, err := json.Marshal(config.Message)
 	if err != nil {
-		return nil, 400, fmt.Errorf("failed to marshal input message: %w", err)
+		return nil, 400, nil, fmt.Errorf("failed to marshal input message: %w", err)
 	}
 
 	err = inputMsg.UnmarshalJSON(msgBytes)
 	if err != nil {
 		zapLogger.Error("Failed to map JSON to Proto Message", zap.Error(err))
-		return nil, 400, fmt.Errorf("invalid input for method %s: %w", config.Method, err)
+		return nil, 400, nil, fmt.Errorf("invalid input for method %s: %w", config.Method, err)
 	}
 
 	// 5. Invoke RPC
@@ -121,21 +149,22 @@ func (h *GRPCHandler) ExecuteGRPCRequest(ctx context.Context, config GRPCRequest
 	// Native Invoke using the full method name: /package.Service/Method
 	fullMethodName := fmt.Sprintf("/%s/%s", config.Service, config.Method)
 
-	err = conn.Invoke(requestCtx, fullMethodName, inputMsg, outputMsg)
+	var header, trailer metadata.MD
+	err = conn.Invoke(requestCtx, fullMethodName, inputMsg, outputMsg, grpc.Header(&header), grpc.Trailer(&trailer))
 	if err != nil {
 		zapLogger.Error("gRPC execution failed", zap.Error(err))
-		return nil, 500, fmt.Errorf("rpc failure: %w", err)
+		return nil, 500, grpcHeaders(header, trailer), fmt.Errorf("rpc failure: %w", err)
 	}
 
 	// 6. Convert Output to JSON
 	outputJSON, err := outputMsg.MarshalJSON()
 	if err != nil {
 		zapLogger.Error("Failed to marshal response", zap.Error(err))
-		return nil, 500, fmt.Errorf("failed to process response: %w", err)
+		return nil, 500, grpcHeaders(header, trailer), fmt.Errorf("failed to process response: %w", err)
 	}
 
 	zapLogger.Info("gRPC request completed successfully")
-	return outputJSON, 200, nil
+	return outputJSON, 200, grpcHeaders(header, trailer), nil
 }
 
 // getOrCreateConnection gets or creates a gRPC connection
@@ -217,33 +246,25 @@ func BuildGRPCRequestConfig(config dto.APIConfigResponse, variables map[string]V
 	// This ensures user input (e.g. registration form) is included
 	if ctx, ok := c.(*gin.Context); ok {
 		if ctx.Request.Body != nil {
-			bodyData, err := ctx.GetRawData() // Reads and restores body
-			if err == nil && len(bodyData) > 0 {
-				var incomingBody map[string]interface{}
-				if err := json.Unmarshal(bodyData, &incomingBody); err == nil {
-					// Merge incoming body into message, overwriting defaults
-					for k, v := range incomingBody {
-						message[k] = v
-					}
-					zapLogger.Debug("Merged incoming request body", zap.Any("body", incomingBody))
-				} else {
-					zapLogger.Warn("Failed to unmarshal incoming request body", zap.Error(err))
+			// Decoded by Content-Type: JSON, form, multipart or XML
+			if incomingBody := extractBodyParams(ctx); len(incomingBody) > 0 {
+				// Merge incoming body into message, overwriting defaults
+				for k, v := range incomingBody {
+					message[k] = v
 				}
-			} else if err != nil {
-				zapLogger.Warn("Failed to read incoming request body", zap.Error(err))
+				zapLogger.Debug("Merged incoming request body", zap.Any("body", incomingBody))
 			}
-			// Important: GetRawData already restores the body, so we don't need to do it manually
 		}
 	}
 
 	// Process headers (for gRPC metadata)
-	headers := make(map[string]string)
+	headers := make(map[string][]string)
 	for k, v := range config.Headers {
 		if containsTemplate(v) {
 			resolved := resolveTemplateForGRPC(v, variables, c)
-			headers[k] = resolved
+			headers[k] = []string{resolved}
 		} else {
-			headers[k] = v
+			headers[k] = []string{v}
 		}
 	}
 
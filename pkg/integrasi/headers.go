@@ -0,0 +1,149 @@
+package integrasi
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"google.golang.org/grpc/metadata"
+)
+
+// hopByHopHeaders only apply to one connection and are never passed on
+var hopByHopHeaders = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+// HeaderPolicy picks the upstream response headers passed on to the client
+// Names are case-insensitive and a trailing * matches a prefix, e.g. X-Internal-*
+type HeaderPolicy struct {
+	Allow []string // Only these are passed on, all of them when empty
+	Deny  []string // Never passed on, even when allowed
+}
+
+// ParseHeaderNames splits a comma-separated list of header names, e.g. "Set-Cookie, X-Internal-*"
+func ParseHeaderNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Passes reports whether the header name is passed on
+func (p HeaderPolicy) Passes(name string) bool {
+	if matchesAny(p.Deny, name) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchesAny(p.Allow, name)
+}
+
+// Filter returns the headers of header the policy passes on, without hop-by-hop headers and the ones the
+// upstream named in its Connection header. Trailers, keyed by http.TrailerPrefix, are filtered by their own name
+func (p HeaderPolicy) Filter(header http.Header) http.Header {
+	connection := make(map[string]bool)
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			connection[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+
+	filtered := make(http.Header, len(header))
+	for key, values := range header {
+		name := http.CanonicalHeaderKey(strings.TrimPrefix(key, http.TrailerPrefix))
+		if hopByHopHeaders[name] || connection[name] || !p.Passes(name) {
+			continue
+		}
+		filtered[key] = append([]string(nil), values...)
+	}
+	return filtered
+}
+
+// matchesAny reports whether name is one of names, case-insensitive, or starts with one ending in *
+func matchesAny(names []string, name string) bool {
+	for _, pattern := range names {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerPolicyFor returns the executor's header policy with the URL config's lists applied:
+// its allow list replaces the executor's, its deny list adds to it
+func (e *Executor) headerPolicyFor(urlConfig dto.URLConfigResponse) HeaderPolicy {
+	policy := e.config.ResponseHeaders
+	if allow := ParseHeaderNames(urlConfig.ResponseHeadersAllow); len(allow) > 0 {
+		policy.Allow = allow
+	}
+	if deny := ParseHeaderNames(urlConfig.ResponseHeadersDeny); len(deny) > 0 {
+		policy.Deny = append(append([]string(nil), policy.Deny...), deny...)
+	}
+	return policy
+}
+
+// CopyResponseHeaders copies the upstream's response headers to dst, without hop-by-hop headers
+func CopyResponseHeaders(dst, src http.Header) {
+	for name, values := range src {
+		if hopByHopHeaders[http.CanonicalHeaderKey(strings.TrimPrefix(name, http.TrailerPrefix))] {
+			continue
+		}
+		dst[name] = append([]string(nil), values...)
+	}
+}
+
+// withTrailers returns header with the trailers of a read response added under http.TrailerPrefix,
+// so writing them to a gin response sends them as trailers again
+func withTrailers(header, trailer http.Header) http.Header {
+	if len(trailer) == 0 {
+		return header
+	}
+	merged := header.Clone()
+	if merged == nil {
+		merged = make(http.Header, len(trailer))
+	}
+	for name, values := range trailer {
+		merged[http.TrailerPrefix+http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	return merged
+}
+
+// grpcHeaders turns the header and trailer metadata of a gRPC call into response headers, trailers under
+// http.TrailerPrefix. Binary (-bin) values are base64 encoded. gRPC's own grpc-* keys and its content-type are
+// left out, the body passed on is JSON
+func grpcHeaders(header, trailer metadata.MD) http.Header {
+	headers := make(http.Header, len(header)+len(trailer))
+	add := func(md metadata.MD, prefix string) {
+		for key, values := range md {
+			if strings.HasPrefix(key, "grpc-") || strings.HasPrefix(key, ":") || key == "content-type" {
+				continue
+			}
+			name := prefix + http.CanonicalHeaderKey(key)
+			for _, value := range values {
+				if strings.HasSuffix(key, "-bin") {
+					value = base64.StdEncoding.EncodeToString([]byte(value))
+				}
+				headers[name] = append(headers[name], value)
+			}
+		}
+	}
+	add(header, "")
+	add(trailer, http.TrailerPrefix)
+	return headers
+}
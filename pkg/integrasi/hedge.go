@@ -0,0 +1,81 @@
+package integrasi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/Payphone-Digital/gateway/pkg/hedge"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// prepareHedge builds the second copy of a request of config, sent to another healthy target than the
+// routed one. Nil when config isn't hedged, the request isn't safe to send twice or there's no other target
+// Safe is decided on the outbound request, like retries: the client's method and Idempotency-Key don't reach the upstream
+func (e *Executor) prepareHedge(config, routed *dto.APIConfigResponse, upstream string, c *gin.Context) func(ctx context.Context) ([]byte, int, http.Header, error) {
+	if config.Hedge == nil || len(config.URLConfig.Targets) < 2 {
+		return nil
+	}
+	targets := make([]balancer.Target, 0, len(config.URLConfig.Targets))
+	for _, target := range config.URLConfig.Targets {
+		if target.URL != routed.URLConfig.URL {
+			targets = append(targets, balancer.Target{Address: target.URL, Weight: target.Weight})
+		}
+	}
+
+	// Random is stateless, picking the hedge target doesn't move the route's own strategy along
+	target, err := e.balancer.Pick(upstream, balancer.Random, targets, "", e.healthyFor(config))
+	if err != nil {
+		return nil
+	}
+
+	second := routeTo(config, target.Address)
+	send, idempotent, err := e.prepareSend(second, c)
+	if err != nil || !idempotent {
+		return nil
+	}
+
+	e.logger.Debug("Hedge target selected",
+		zap.String("slug", config.Path),
+		zap.String("upstream", upstream),
+		zap.String("primary", routed.URLConfig.URL),
+		zap.String("hedge", target.Address),
+	)
+
+	return func(ctx context.Context) ([]byte, int, http.Header, error) {
+		return e.execute(ctx, second, upstream, send)
+	}
+}
+
+// hedged races primary and second for a request of config by the route's hedge settings
+func (e *Executor) hedged(config *dto.APIConfigResponse, primary, second func(ctx context.Context) ([]byte, int, http.Header, error)) func(ctx context.Context) ([]byte, int, http.Header, error) {
+	options := hedge.Config{
+		Delay:      time.Duration(config.Hedge.DelayMs) * time.Millisecond,
+		Percentile: config.Hedge.Percentile,
+		MaxPercent: config.Hedge.MaxPercent,
+	}
+	toResult := func(send func(ctx context.Context) ([]byte, int, http.Header, error)) func(ctx context.Context) hedge.Result {
+		return func(ctx context.Context) hedge.Result {
+			body, status, header, err := send(ctx)
+			return hedge.Result{Body: body, Status: status, Header: header, Err: err}
+		}
+	}
+
+	return func(ctx context.Context) ([]byte, int, http.Header, error) {
+		result := e.hedger.Do(ctx, config.ID, options, toResult(primary), toResult(second))
+		return result.Body, result.Status, result.Header, result.Err
+	}
+}
+
+// HedgeStats returns the hedging of route id so far
+func (e *Executor) HedgeStats(id uint) hedge.Snapshot {
+	return e.hedger.Route(id)
+}
+
+// ResetHedge forgets the latencies and counters of route id, e.g. after its hedge settings changed
+func (e *Executor) ResetHedge(id uint) {
+	e.hedger.Reset(id)
+}
@@ -1,30 +1,34 @@
 package integrasi
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"time"
 
+	"github.com/Payphone-Digital/gateway/pkg/codec"
 	"github.com/Payphone-Digital/gateway/pkg/logger"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
 	"go.uber.org/zap"
 )
 
 type APIRequestConfig struct {
-	Method     string                 // "GET", "POST", etc.
-	URL        string                 // Full URL
-	Headers    map[string]string      // Custom headers like Authorization
-	Query      map[string]string      // Query string
-	Body       map[string]interface{} // Body (marshalled to JSON if not nil)
-	Timeout    int                    // Timeout in seconds
-	MaxRetries int                    // Retry count
-	RetryDelay int                    // Retry delay in seconds
-	LogFile    string                 // Log file path
-	LogLevel   string                 // Log level: info, warn, error
+	Method     string                             // "GET", "POST", etc.
+	URL        string                             // Full URL
+	Headers    map[string][]string                // Custom headers like Authorization, repeated when given several values
+	Query      map[string][]string                // Query string, a parameter with several values is repeated
+	Body       map[string]interface{}             // Body, encoded by the Content-Type header (JSON by default) if not nil
+	Files      map[string][]*multipart.FileHeader // Multipart file parts passed on from the client's upload
+	Raw        []byte                             // Body sent as is for Content-Types without a codec, e.g. application/pdf
+	Timeout    int                                // Timeout in seconds
+	MaxRetries int                                // Retry count
+	RetryDelay int                                // Retry delay in seconds
+	Retry      *retry.Policy                      // Retry policy, nil = the default one with MaxRetries and RetryDelay
+	LogFile    string                             // Log file path
+	LogLevel   string                             // Log level: info, warn, error
 }
 
 // Global gRPC handler
@@ -35,8 +39,15 @@ func init() {
 	globalGRPCHandler = NewGRPCHandler()
 }
 
-// The main function to call with context and exponential backoff
-func DoRequestSafeWithRetry(ctx context.Context, config APIRequestConfig) ([]byte, int, error) {
+// The main function to call with context and full jitter backoff
+// Returns the upstream's response headers along with its body and status, its trailers under http.TrailerPrefix
+func DoRequestSafeWithRetry(ctx context.Context, config APIRequestConfig) ([]byte, int, http.Header, error) {
+	return DoRequestWithClient(ctx, nil, config)
+}
+
+// DoRequestWithClient is DoRequestSafeWithRetry sending every attempt through client, e.g. a pooled one
+// A nil client makes a new client per attempt
+func DoRequestWithClient(ctx context.Context, client *http.Client, config APIRequestConfig) ([]byte, int, http.Header, error) {
 	zapLogger := logger.GetLogger().With(
 		zap.String("operation", "http_request"),
 		zap.String("log_file", config.LogFile),
@@ -47,100 +58,168 @@ func DoRequestSafeWithRetry(ctx context.Context, config APIRequestConfig) ([]byt
 	if timeout == 0 {
 		timeout = 10 * time.Second
 	}
-	maxRetries := config.MaxRetries
-	if maxRetries < 0 {
-		maxRetries = 0
+	hasIdempotencyKey := headerValue(config.Headers, retry.IdempotencyKeyHeader) != ""
+	respBody, statusCode, header, lastErr := retryAttempts(ctx, requestPolicy(config), config.Method, hasIdempotencyKey, timeout, zapLogger,
+		func(ctx context.Context, attempt int, tryTimeout time.Duration) ([]byte, int, http.Header, error) {
+			return doSingleRequest(ctx, client, config, tryTimeout, zapLogger)
+		})
+
+	if lastErr != nil {
+		zapLogger.Error("Final request failed",
+			zap.Error(lastErr),
+			zap.Int("status_code", statusCode),
+		)
+	} else {
+		zapLogger.Info("Request successful",
+			zap.Int("status_code", statusCode),
+		)
 	}
-	retryDelay := time.Duration(config.RetryDelay) * time.Second
-	if retryDelay == 0 {
-		retryDelay = 1 * time.Second
+
+	return respBody, statusCode, header, lastErr
+}
+
+// retryAttempts sends tries of a request until one isn't retryable under policy or the retries run out, backing off
+// in between within the policy's budget. send gets the try's number, 0 for the first, and the timeout of that try
+func retryAttempts(ctx context.Context, policy retry.Policy, method string, hasIdempotencyKey bool, timeout time.Duration, zapLogger *zap.Logger,
+	send func(ctx context.Context, attempt int, tryTimeout time.Duration) ([]byte, int, http.Header, error)) ([]byte, int, http.Header, error) {
+	// With a per-try timeout the request's own timeout bounds all tries together
+	tryTimeout := timeout
+	if policy.PerTryTimeout > 0 {
+		tryTimeout = policy.PerTryTimeout
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if policy.Budget != nil {
+		policy.Budget.Request()
 	}
 
 	var respBody []byte
 	var statusCode int
+	var header http.Header
 	var lastErr error
 
-	backoff := retryDelay
-
-	for i := 0; i <= maxRetries; i++ {
+	for attempt := 0; ; attempt++ {
 		// Check context before each try
 		if ctx.Err() != nil {
 			zapLogger.Warn("Context done before request attempt",
 				zap.Error(ctx.Err()),
 			)
-			return nil, 0, ctx.Err()
+			return nil, 0, nil, ctx.Err()
+		}
+
+		respBody, statusCode, header, lastErr = send(ctx, attempt, tryTimeout)
+		if attempt >= policy.MaxRetries {
+			break
+		}
+		reason, retryable := policy.Retryable(method, hasIdempotencyKey, statusCode, lastErr)
+		if !retryable || ctx.Err() != nil {
+			break
 		}
 
-		respBody, statusCode, lastErr = doSingleRequest(ctx, config, timeout, zapLogger)
-		if lastErr == nil || (statusCode >= 400 && statusCode < 500) {
+		delay := retry.Backoff(attempt, policy.BaseDelay, policy.MaxDelay)
+		if after, ok := retry.RetryAfter(header, time.Now()); ok {
+			if policy.MaxDelay > 0 && after > policy.MaxDelay {
+				zapLogger.Warn("Upstream asked to retry later than the maximum delay, not retrying",
+					zap.Duration("retry_after", after),
+					zap.Duration("max_delay", policy.MaxDelay),
+				)
+				break
+			}
+			delay = after
+		}
+
+		if policy.Budget != nil && !policy.Budget.Withdraw() {
+			zapLogger.Warn("Retry budget exhausted, not retrying",
+				zap.String("reason", reason),
+				zap.Int("attempt", attempt+1),
+			)
 			break
 		}
+
 		zapLogger.Warn("Retry failed",
-			zap.Int("attempt", i+1),
-			zap.Int("max_retries", maxRetries),
+			zap.Int("attempt", attempt+1),
+			zap.Int("max_retries", policy.MaxRetries),
+			zap.String("reason", reason),
+			zap.Duration("backoff", delay),
 			zap.Error(lastErr),
 		)
 
-		// Exponential backoff with context cancellation check
+		// Backoff with context cancellation check
 		select {
-		case <-time.After(backoff):
+		case <-time.After(delay):
 			// continue retry
 		case <-ctx.Done():
 			zapLogger.Warn("Context done during backoff",
 				zap.Error(ctx.Err()),
 			)
-			return nil, 0, ctx.Err()
-		}
-		backoff *= 2
-		if backoff > 30*time.Second {
-			backoff = 30 * time.Second // max backoff cap
+			return nil, 0, nil, ctx.Err()
 		}
 	}
 
-	if lastErr != nil {
-		zapLogger.Error("Final request failed",
-			zap.Error(lastErr),
-			zap.Int("status_code", statusCode),
-		)
-	} else {
-		zapLogger.Info("Request successful",
-			zap.Int("status_code", statusCode),
-		)
+	return respBody, statusCode, header, lastErr
+}
+
+// requestPolicy returns the retry policy of config: its own, or the default one with its retries and delay
+func requestPolicy(config APIRequestConfig) retry.Policy {
+	if config.Retry != nil {
+		return *config.Retry
 	}
 
-	return respBody, statusCode, lastErr
+	policy := retry.DefaultPolicy()
+	if config.MaxRetries > 0 {
+		policy.MaxRetries = config.MaxRetries
+	}
+	if config.RetryDelay > 0 {
+		policy.BaseDelay = time.Duration(config.RetryDelay) * time.Second
+	}
+	return policy
 }
 
-func doSingleRequest(ctx context.Context, config APIRequestConfig, timeout time.Duration, zapLogger *zap.Logger) ([]byte, int, error) {
+// doSingleRequest sends one attempt of config, returning the upstream's response headers and trailers along with its body and status
+func doSingleRequest(ctx context.Context, client *http.Client, config APIRequestConfig, timeout time.Duration, zapLogger *zap.Logger) ([]byte, int, http.Header, error) {
 	u, err := url.Parse(config.URL)
 	if err != nil {
-		return nil, 0, fmt.Errorf("invalid URL: %w", err)
+		return nil, 0, nil, fmt.Errorf("invalid URL: %w", err)
 	}
 	q := u.Query()
 	for k, v := range config.Query {
-		q.Set(k, v)
+		q[k] = v
 	}
 	u.RawQuery = q.Encode()
 
+	// Encoded per attempt, so multipart file parts are read again on a retry
 	var bodyReader io.Reader
-	if config.Body != nil {
-		bodyBytes, err := json.Marshal(config.Body)
+	contentType := headerValue(config.Headers, "Content-Type")
+	hasBody := config.Body != nil || len(config.Files) > 0 || config.Raw != nil
+	if hasBody {
+		bodyReader, contentType, err = codec.Encode(contentType, config.Body, config.Files, config.Raw)
 		if err != nil {
-			return nil, 0, fmt.Errorf("marshal body: %w", err)
+			return nil, 0, nil, fmt.Errorf("encode body: %w", err)
 		}
-		bodyReader = bytes.NewBuffer(bodyBytes)
 	}
 
+	// Shared clients can't carry the per-route timeout, the attempt's context does
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, config.Method, u.String(), bodyReader)
 	if err != nil {
-		return nil, 0, fmt.Errorf("build request: %w", err)
+		if closer, ok := bodyReader.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, 0, nil, fmt.Errorf("build request: %w", err)
 	}
 
-	for k, v := range config.Headers {
-		req.Header.Set(k, v)
+	for k, values := range config.Headers {
+		req.Header.Del(k)
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
 	}
-	if config.Body != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
+	if hasBody {
+		req.Header.Set("Content-Type", contentType)
 	}
 
 	zapLogger.Info("Making HTTP request",
@@ -159,7 +238,9 @@ func doSingleRequest(ctx context.Context, config APIRequestConfig, timeout time.
 		)
 	}
 
-	client := &http.Client{Timeout: timeout}
+	if client == nil {
+		client = &http.Client{Timeout: timeout}
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		zapLogger.Error("HTTP request failed",
@@ -167,7 +248,7 @@ func doSingleRequest(ctx context.Context, config APIRequestConfig, timeout time.
 			zap.String("method", config.Method),
 			zap.String("url", u.String()),
 		)
-		return nil, 0, fmt.Errorf("http error: %w", err)
+		return nil, 0, nil, fmt.Errorf("http error: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -176,7 +257,7 @@ func doSingleRequest(ctx context.Context, config APIRequestConfig, timeout time.
 		zapLogger.Error("Failed to read response body",
 			zap.Error(err),
 		)
-		return nil, resp.StatusCode, fmt.Errorf("read body: %w", err)
+		return nil, resp.StatusCode, resp.Header, fmt.Errorf("read body: %w", err)
 	}
 
 	zapLogger.Info("HTTP response received",
@@ -195,5 +276,6 @@ func doSingleRequest(ctx context.Context, config APIRequestConfig, timeout time.
 		// return respData, resp.StatusCode, fmt.Errorf("HTTP error: %s", resp.Status)
 	}
 
-	return respData, resp.StatusCode, nil
+	// Trailers are only in once the body has been read
+	return respData, resp.StatusCode, withTrailers(resp.Header, resp.Trailer), nil
 }
@@ -0,0 +1,75 @@
+package integrasi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// placeholderPattern matches a template holding a single {{var}} placeholder and nothing else
+var placeholderPattern = regexp.MustCompile(`^\{\{(.*?)\}\}$`)
+
+// ParamValue is the value of a query parameter or header given values, for a variable of dataType:
+// every value as an array for arrays, the first one for any other type
+func ParamValue(values []string, dataType DataType) interface{} {
+	if dataType == TypeArray {
+		items := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			items = append(items, value)
+		}
+		return items
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// variableText is the Variable.Value of values for a variable of dataType, a JSON array for arrays
+func variableText(values []string, dataType DataType) string {
+	value := ParamValue(values, dataType)
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// textValue formats a resolved variable inside text, arrays as their comma-separated items
+func textValue(value interface{}) string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return fmt.Sprintf("%v", value)
+	}
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		texts = append(texts, textValue(item))
+	}
+	return strings.Join(texts, ",")
+}
+
+// resolveValues resolves a query parameter or header template into its values
+// A template holding just the placeholder of an array variable gives one value per item,
+// so {"status": "{{status}}"} sends status=PAID&status=PENDING; any other template resolves to one value
+func resolveValues(template string, variables map[string]Variable, c *gin.Context) []string {
+	if matches := placeholderPattern.FindStringSubmatch(template); len(matches) > 1 {
+		if variable, exists := variables[strings.TrimSpace(matches[1])]; exists && variable.DataType == TypeArray {
+			items, _ := resolveVariable(variable, variables, c).([]interface{})
+			values := make([]string, 0, len(items))
+			for _, item := range items {
+				if text := textValue(item); text != "" {
+					values = append(values, text)
+				}
+			}
+			return values
+		}
+	}
+
+	if resolved := resolveTemplate(template, variables, c); resolved != "" {
+		return []string{resolved}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package integrasi
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/balancer"
+	"github.com/Payphone-Digital/gateway/pkg/retry"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// prepareRetargeted builds the HTTP request of config routed to a target with its retries run by the executor instead
+// of the HTTP client, so every retry goes to a target picked again among the healthy ones, behind that target's breaker.
+// Nil when config isn't sent over HTTP, isn't retried or its URL config has fewer than two targets
+func (e *Executor) prepareRetargeted(config, routed *dto.APIConfigResponse, upstream string, c *gin.Context) func(ctx context.Context) ([]byte, int, http.Header, error) {
+	if upstream == "" || len(config.URLConfig.Targets) < 2 || (routed.Protocol != "http" && routed.Protocol != "") {
+		return nil
+	}
+	request := e.buildHTTPRequest(routed, c)
+	policy := requestPolicy(request)
+	if policy.MaxRetries <= 0 {
+		return nil
+	}
+
+	timeout := time.Duration(request.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	hasIdempotencyKey := headerValue(request.Headers, retry.IdempotencyKeyHeader) != ""
+	poolConfig := e.poolConfigFor(config.URLConfig)
+
+	return func(ctx context.Context) ([]byte, int, http.Header, error) {
+		current := routed
+		tried := make(map[string]bool, len(config.URLConfig.Targets))
+
+		return retryAttempts(ctx, policy, request.Method, hasIdempotencyKey, timeout, e.logger,
+			func(ctx context.Context, attempt int, tryTimeout time.Duration) ([]byte, int, http.Header, error) {
+				if attempt > 0 {
+					tried[current.URLConfig.URL] = true
+					current = e.retarget(config, current, upstream, tried)
+				}
+
+				target := current
+				attemptRequest := request
+				attemptRequest.URL = targetURL(request.URL, routed.URLConfig.URL, target.URLConfig.URL)
+				client := e.pool.GetHTTPClientWithConfig(target.URLConfig.ID, target.URLConfig.URL, target.URLConfig.TLSEnabled, poolConfig)
+
+				return e.execute(ctx, target, upstream, func(ctx context.Context) ([]byte, int, http.Header, error) {
+					return doSingleRequest(ctx, client, attemptRequest, tryTimeout, e.logger)
+				})
+			})
+	}
+}
+
+// retarget picks the target a retry of config goes to: a healthy one not tried yet, any healthy one once every
+// target was tried, current when none is healthy. Random, like hedging, so retries don't move the route's own strategy along
+func (e *Executor) retarget(config, current *dto.APIConfigResponse, upstream string, tried map[string]bool) *dto.APIConfigResponse {
+	untried := make([]balancer.Target, 0, len(config.URLConfig.Targets))
+	all := make([]balancer.Target, 0, len(config.URLConfig.Targets))
+	for _, target := range config.URLConfig.Targets {
+		all = append(all, balancer.Target{Address: target.URL, Weight: target.Weight})
+		if !tried[target.URL] {
+			untried = append(untried, balancer.Target{Address: target.URL, Weight: target.Weight})
+		}
+	}
+
+	for _, candidates := range [][]balancer.Target{untried, all} {
+		target, err := e.balancer.Pick(upstream, balancer.Random, candidates, "", e.healthyFor(config))
+		if err != nil {
+			continue
+		}
+		e.logger.Debug("Retry target selected",
+			zap.String("slug", config.Path),
+			zap.String("upstream", upstream),
+			zap.String("previous", current.URLConfig.URL),
+			zap.String("target", target.Address),
+		)
+		return routeTo(config, target.Address)
+	}
+	return current
+}
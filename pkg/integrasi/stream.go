@@ -0,0 +1,199 @@
+package integrasi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/circuit"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StreamBodyKey marks a request whose body is streamed to the upstream as is, the gateway never reads it
+const StreamBodyKey = "stream_body"
+
+// streamRequestHeaders are the client's headers a streamed request passes on with its body
+var streamRequestHeaders = []string{
+	"Content-Type",
+	"Content-Encoding",
+	"Accept",
+	"Accept-Encoding",
+	"Range",
+	"If-Range",
+	"If-None-Match",
+	"If-Modified-Since",
+}
+
+// streamBody calls done once when the response body is closed
+type streamBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// OpenStream sends config's method, URL, query and headers with in's body as is through client and returns the
+// response unread. timeout bounds the wait for the response headers only, the body is read for as long as it takes.
+// Closing the response body ends the request
+func OpenStream(ctx context.Context, client *http.Client, config APIRequestConfig, in *http.Request) (*http.Response, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	q := u.Query()
+	for k, v := range config.Query {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(timeout, cancel)
+
+	req, err := http.NewRequestWithContext(ctx, config.Method, u.String(), in.Body)
+	if err != nil {
+		timer.Stop()
+		cancel()
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	if in.Body != nil && in.Body != http.NoBody {
+		req.ContentLength = in.ContentLength
+	}
+	for _, name := range streamRequestHeaders {
+		if values := in.Header.Values(name); len(values) > 0 {
+			req.Header[name] = values
+		}
+	}
+	for k, values := range config.Headers {
+		req.Header.Del(k)
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	if client == nil {
+		client = &http.Client{}
+	}
+	resp, err := client.Do(req)
+	if !timer.Stop() {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		cancel()
+		return nil, fmt.Errorf("no response within %s: %w", timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("http error: %w", err)
+	}
+
+	resp.Body = &streamBody{ReadCloser: resp.Body, done: cancel}
+	return resp, nil
+}
+
+// Stream sends the request of config with c's body as is and returns the upstream response unread, for the
+// caller to copy and close. Streamed requests go through target selection, circuit breakers and outlier
+// detection but are never retried or hedged, their body can only be sent once.
+// The response headers are filtered by the URL config's header policy, see StreamTrailers for the trailers
+func (e *Executor) Stream(ctx context.Context, config *dto.APIConfigResponse, c *gin.Context) (*http.Response, error) {
+	routed, upstream, err := e.pickTarget(config, c)
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable: %w", err)
+	}
+	if routed.Protocol != "http" && routed.Protocol != "" {
+		return nil, fmt.Errorf("streaming is not supported for protocol: %s", routed.Protocol)
+	}
+	address := routed.URLConfig.URL
+
+	requestConfig := ConvertToAPIResponseConfig(routed).BuildAPIRequestConfig(c)
+	if routed.URLConfig.AuthType != "" && routed.URLConfig.AuthType != "none" {
+		applyUpstreamAuth(&requestConfig, routed.URLConfig)
+	}
+
+	var breaker *circuit.Breaker
+	if routed.URLConfig.CircuitBreakerEnabled {
+		breaker = e.circuitBreaker.GetOrCreateWithConfig(breakerName(address, routed), e.circuitConfigFor(routed.URLConfig))
+		if err := breaker.Allow(); err != nil {
+			e.logger.Warn("Circuit breaker blocked streamed request",
+				zap.String("address", address),
+				zap.String("breaker", breakerName(address, routed)),
+				zap.String("state", breaker.State().String()),
+				zap.Error(err),
+			)
+			return nil, fmt.Errorf("service unavailable: %w", err)
+		}
+	}
+
+	e.logger.Info("Streaming request",
+		zap.String("slug", routed.Path),
+		zap.String("address", address),
+		zap.String("method", requestConfig.Method),
+		zap.String("url", requestConfig.URL),
+	)
+
+	// Pooled clients time out whole exchanges, a streamed body may take longer than that
+	client := *e.pool.GetHTTPClientWithConfig(routed.URLConfig.ID, address, routed.URLConfig.TLSEnabled, e.poolConfigFor(routed.URLConfig))
+	client.Timeout = 0
+
+	release := e.balancer.Acquire(address)
+	start := time.Now()
+	resp, err := OpenStream(ctx, &client, requestConfig, c.Request)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		release()
+		// A client gone before the response says nothing about the upstream
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, err
+		}
+	}
+
+	// Outcomes are recorded once the headers are in, the time to copy the body says nothing about the upstream
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	failed := err != nil || statusCode >= 500
+	if upstream != "" {
+		e.outliers.Record(upstream, address, failed)
+	}
+	if failed {
+		if breaker != nil {
+			breaker.RecordCall(fmt.Errorf("request failed: %v, status: %d", err, statusCode), elapsed)
+		}
+		e.pool.RecordFailure(address, err)
+	} else {
+		if breaker != nil {
+			breaker.RecordCall(nil, elapsed)
+		}
+		e.pool.RecordSuccess(address)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	resp.Header = e.headerPolicyFor(routed.URLConfig).Filter(resp.Header)
+	resp.Body = &streamBody{ReadCloser: resp.Body, done: release}
+	return resp, nil
+}
+
+// StreamTrailers returns the trailers of a streamed response of config under http.TrailerPrefix, filtered
+// by the URL config's header policy. They're only known once the body has been read to the end
+func (e *Executor) StreamTrailers(config *dto.APIConfigResponse, resp *http.Response) http.Header {
+	return e.headerPolicyFor(config.URLConfig).Filter(withTrailers(nil, resp.Trailer))
+}
@@ -0,0 +1,109 @@
+package mirror
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxDifferences caps the differing paths reported for one comparison
+const maxDifferences = 20
+
+// Diff is the outcome of comparing a mirrored response with the primary one
+type Diff struct {
+	StatusMatch bool
+	BodyMatch   bool
+	Differences []string // Dotted paths of differing JSON fields, "body" for non-JSON bodies
+}
+
+// Compare compares status and body of the primary and mirrored responses
+// JSON bodies are compared field by field, skipping ignore paths such as "data.created_at"
+// (array elements are addressed by index, e.g. "items.0.id"); other bodies byte by byte
+func Compare(primaryStatus int, primaryBody []byte, mirrorStatus int, mirrorBody []byte, ignore []string) Diff {
+	diff := Diff{StatusMatch: primaryStatus == mirrorStatus}
+
+	var primaryJSON, mirrorJSON interface{}
+	if json.Unmarshal(primaryBody, &primaryJSON) != nil || json.Unmarshal(mirrorBody, &mirrorJSON) != nil {
+		diff.BodyMatch = bytes.Equal(primaryBody, mirrorBody)
+		if !diff.BodyMatch {
+			diff.Differences = []string{"body"}
+		}
+		return diff
+	}
+
+	ignored := make(map[string]bool, len(ignore))
+	for _, path := range ignore {
+		ignored[strings.TrimPrefix(path, "$.")] = true
+	}
+
+	compareValues("", primaryJSON, mirrorJSON, ignored, &diff.Differences)
+	diff.BodyMatch = len(diff.Differences) == 0
+	return diff
+}
+
+// compareValues appends the paths where a and b differ
+func compareValues(path string, a, b interface{}, ignored map[string]bool, differences *[]string) {
+	if ignored[path] || len(*differences) >= maxDifferences {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			*differences = append(*differences, displayPath(path))
+			return
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for key := range av {
+			keys = append(keys, key)
+		}
+		for key := range bv {
+			if _, ok := av[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			compareValues(joinPath(path, key), av[key], bv[key], ignored, differences)
+		}
+
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			*differences = append(*differences, displayPath(path))
+			return
+		}
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			if i >= len(av) || i >= len(bv) {
+				*differences = append(*differences, displayPath(joinPath(path, fmt.Sprint(i))))
+				continue
+			}
+			compareValues(joinPath(path, fmt.Sprint(i)), av[i], bv[i], ignored, differences)
+		}
+
+	default:
+		if !reflect.DeepEqual(a, b) {
+			*differences = append(*differences, displayPath(path))
+		}
+	}
+}
+
+// joinPath appends key to a dotted path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// displayPath names the root of the body "$"
+func displayPath(path string) string {
+	if path == "" {
+		return "$"
+	}
+	return path
+}
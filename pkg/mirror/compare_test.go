@@ -0,0 +1,90 @@
+package mirror
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name          string
+		primaryStatus int
+		primaryBody   string
+		mirrorStatus  int
+		mirrorBody    string
+		ignore        []string
+		statusMatch   bool
+		bodyMatch     bool
+		differences   []string
+	}{
+		{
+			name:          "identical JSON with different key order",
+			primaryStatus: 200, primaryBody: `{"a":1,"b":{"c":"x"}}`,
+			mirrorStatus: 200, mirrorBody: `{"b":{"c":"x"},"a":1}`,
+			statusMatch: true, bodyMatch: true,
+		},
+		{
+			name:          "nested field and array element differ",
+			primaryStatus: 200, primaryBody: `{"data":{"id":1,"tags":["a","b"]},"ok":true}`,
+			mirrorStatus: 201, mirrorBody: `{"data":{"id":2,"tags":["a"]},"ok":true,"extra":1}`,
+			statusMatch: false, bodyMatch: false,
+			differences: []string{"data.id", "data.tags.1", "extra"},
+		},
+		{
+			name:          "ignored paths",
+			primaryStatus: 200, primaryBody: `{"data":{"id":1,"created_at":"t1"},"trace":"a"}`,
+			mirrorStatus: 200, mirrorBody: `{"data":{"id":1,"created_at":"t2"},"trace":"b"}`,
+			ignore:      []string{"data.created_at", "$.trace"},
+			statusMatch: true, bodyMatch: true,
+		},
+		{
+			name:          "type change",
+			primaryStatus: 200, primaryBody: `{"data":{"id":1}}`,
+			mirrorStatus: 200, mirrorBody: `{"data":[1]}`,
+			statusMatch: true, bodyMatch: false,
+			differences: []string{"data"},
+		},
+		{
+			name:          "root value differs",
+			primaryStatus: 200, primaryBody: `1`,
+			mirrorStatus: 200, mirrorBody: `2`,
+			statusMatch: true, bodyMatch: false,
+			differences: []string{"$"},
+		},
+		{
+			name:          "non-JSON bodies",
+			primaryStatus: 500, primaryBody: `upstream error`,
+			mirrorStatus: 500, mirrorBody: `upstream error`,
+			statusMatch: true, bodyMatch: true,
+		},
+		{
+			name:          "non-JSON against JSON",
+			primaryStatus: 200, primaryBody: `{"a":1}`,
+			mirrorStatus: 502, mirrorBody: `bad gateway`,
+			statusMatch: false, bodyMatch: false,
+			differences: []string{"body"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Compare(tt.primaryStatus, []byte(tt.primaryBody), tt.mirrorStatus, []byte(tt.mirrorBody), tt.ignore)
+			if diff.StatusMatch != tt.statusMatch || diff.BodyMatch != tt.bodyMatch {
+				t.Errorf("Expected status match %v and body match %v, got %+v", tt.statusMatch, tt.bodyMatch, diff)
+			}
+			if !reflect.DeepEqual(diff.Differences, tt.differences) {
+				t.Errorf("Expected differences %v, got %v", tt.differences, diff.Differences)
+			}
+		})
+	}
+}
+
+func TestCompare_CapsDifferences(t *testing.T) {
+	primary := []byte(`[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]`)
+	mirror := []byte(`[1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1,1]`)
+
+	diff := Compare(200, primary, 200, mirror, nil)
+	if len(diff.Differences) != maxDifferences {
+		t.Errorf("Expected %d differences, got %d", maxDifferences, len(diff.Differences))
+	}
+}
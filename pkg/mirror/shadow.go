@@ -0,0 +1,215 @@
+package mirror
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// recentResults is how many mismatches and failures are kept per route
+const recentResults = 50
+
+// SendFunc sends a prepared copy of a request and returns the upstream's body and status
+type SendFunc func(ctx context.Context) ([]byte, int, error)
+
+// Result is a mirrored request that failed or didn't match the primary response
+type Result struct {
+	At            time.Time
+	PrimaryStatus int
+	MirrorStatus  int
+	Latency       time.Duration
+	Error         string
+	Differences   []string
+}
+
+// Report summarizes the mirrored traffic of a route since Since
+type Report struct {
+	Sent             int64
+	Failed           int64
+	Dropped          int64 // Not sent because too many mirrored requests were in flight
+	Compared         int64
+	StatusMismatches int64
+	BodyMismatches   int64
+	Recent           []Result // Newest first
+	Since            time.Time
+}
+
+// Shadow sends mirrored requests in the background and keeps per-route reports
+// Mirrored responses are only compared and discarded, they never reach the client
+type Shadow struct {
+	slots   chan struct{}
+	timeout time.Duration
+
+	mu     sync.Mutex
+	routes map[uint]*routeReport
+}
+
+type routeReport struct {
+	report Report
+	recent []Result // Ring of recentResults, next is the slot written next
+	next   int
+}
+
+// NewShadow creates a Shadow running at most maxInFlight mirrored requests at once,
+// each given timeout to complete
+func NewShadow(maxInFlight int, timeout time.Duration) *Shadow {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Shadow{
+		slots:   make(chan struct{}, maxInFlight),
+		timeout: timeout,
+		routes:  make(map[uint]*routeReport),
+	}
+}
+
+// Sampled reports whether a request is mirrored at percent (0-100)
+func Sampled(percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	return percent >= 100 || rand.Intn(100) < percent
+}
+
+// Pending is a mirrored request in flight, waiting for the primary response when comparing
+type Pending struct {
+	primary chan primaryResponse
+}
+
+type primaryResponse struct {
+	status int
+	body   []byte
+}
+
+// Primary hands the primary response over for comparison, body must not be modified afterwards
+// Safe to call on a nil Pending and more than once, only the first call counts
+func (p *Pending) Primary(status int, body []byte) {
+	if p == nil {
+		return
+	}
+	select {
+	case p.primary <- primaryResponse{status: status, body: body}:
+	default:
+	}
+}
+
+// Start sends a mirrored request for routeID in the background
+// With compare set the mirrored response is compared to the one handed to Primary, skipping ignore paths;
+// if the primary doesn't arrive before the mirror's timeout, the request is counted as sent only
+// Returns nil when the request is dropped because too many are in flight
+func (s *Shadow) Start(routeID uint, send SendFunc, compare bool, ignore []string) *Pending {
+	select {
+	case s.slots <- struct{}{}:
+	default:
+		s.update(routeID, func(r *routeReport) { r.report.Dropped++ })
+		return nil
+	}
+
+	pending := &Pending{primary: make(chan primaryResponse, 1)}
+	go func() {
+		defer func() { <-s.slots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+
+		start := time.Now()
+		body, status, err := send(ctx)
+		latency := time.Since(start)
+
+		if err != nil {
+			s.update(routeID, func(r *routeReport) {
+				r.report.Sent++
+				r.report.Failed++
+				r.add(Result{At: start, MirrorStatus: status, Latency: latency, Error: err.Error()})
+			})
+			return
+		}
+		if !compare {
+			s.update(routeID, func(r *routeReport) { r.report.Sent++ })
+			return
+		}
+
+		var primary primaryResponse
+		select {
+		case primary = <-pending.primary:
+		case <-ctx.Done():
+			s.update(routeID, func(r *routeReport) { r.report.Sent++ })
+			return
+		}
+
+		diff := Compare(primary.status, primary.body, status, body, ignore)
+		s.update(routeID, func(r *routeReport) {
+			r.report.Sent++
+			r.report.Compared++
+			if !diff.StatusMatch {
+				r.report.StatusMismatches++
+			}
+			if !diff.BodyMatch {
+				r.report.BodyMismatches++
+			}
+			if !diff.StatusMatch || !diff.BodyMatch {
+				r.add(Result{
+					At:            start,
+					PrimaryStatus: primary.status,
+					MirrorStatus:  status,
+					Latency:       latency,
+					Differences:   diff.Differences,
+				})
+			}
+		})
+	}()
+	return pending
+}
+
+// Route returns a copy of the report of routeID
+func (s *Shadow) Route(routeID uint) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.routes[routeID]
+	if !ok {
+		return Report{}
+	}
+
+	report := r.report
+	report.Recent = make([]Result, 0, len(r.recent))
+	for i := 1; i <= len(r.recent); i++ {
+		report.Recent = append(report.Recent, r.recent[(r.next-i+len(r.recent))%len(r.recent)])
+	}
+	return report
+}
+
+// Reset forgets the report of routeID
+func (s *Shadow) Reset(routeID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.routes, routeID)
+}
+
+// update applies fn to the report of routeID under the lock
+func (s *Shadow) update(routeID uint, fn func(r *routeReport)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.routes[routeID]
+	if !ok {
+		r = &routeReport{report: Report{Since: time.Now()}}
+		s.routes[routeID] = r
+	}
+	fn(r)
+}
+
+// add keeps result in the ring, overwriting the oldest once full
+func (r *routeReport) add(result Result) {
+	if len(r.recent) < recentResults {
+		r.recent = append(r.recent, result)
+		r.next = len(r.recent) % recentResults
+		return
+	}
+	r.recent[r.next] = result
+	r.next = (r.next + 1) % recentResults
+}
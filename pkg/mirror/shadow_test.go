@@ -0,0 +1,120 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// waitFor polls until cond holds, mirrored requests complete in the background
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for mirrored requests")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func respond(body string, status int, err error) SendFunc {
+	return func(ctx context.Context) ([]byte, int, error) {
+		return []byte(body), status, err
+	}
+}
+
+func TestShadow_Report(t *testing.T) {
+	shadow := NewShadow(10, time.Second)
+
+	shadow.Start(1, respond(`{"id":1}`, 200, nil), true, nil).Primary(200, []byte(`{"id":1}`))
+	shadow.Start(1, respond(`{"id":2}`, 500, nil), true, nil).Primary(200, []byte(`{"id":1}`))
+	shadow.Start(1, respond(``, 0, errors.New("connection refused")), true, nil).Primary(200, []byte(`{"id":1}`))
+	shadow.Start(1, respond(`{"id":3}`, 200, nil), false, nil)
+
+	waitFor(t, func() bool { return shadow.Route(1).Sent == 4 })
+
+	report := shadow.Route(1)
+	if report.Failed != 1 || report.Compared != 2 || report.StatusMismatches != 1 || report.BodyMismatches != 1 {
+		t.Errorf("Unexpected report counts: %+v", report)
+	}
+	if len(report.Recent) != 2 {
+		t.Fatalf("Expected the mismatch and the failure to be kept, got %d results", len(report.Recent))
+	}
+	for _, result := range report.Recent {
+		if result.Error == "" && (result.PrimaryStatus != 200 || result.MirrorStatus != 500 || len(result.Differences) != 1) {
+			t.Errorf("Unexpected mismatch result: %+v", result)
+		}
+	}
+
+	shadow.Reset(1)
+	if shadow.Route(1).Sent != 0 {
+		t.Error("Expected route 1 report to be reset")
+	}
+}
+
+func TestShadow_DropsWhenSaturated(t *testing.T) {
+	shadow := NewShadow(1, time.Second)
+
+	release := make(chan struct{})
+	blocked := func(ctx context.Context) ([]byte, int, error) {
+		<-release
+		return nil, 200, nil
+	}
+
+	if shadow.Start(1, blocked, false, nil) == nil {
+		t.Fatal("Expected the first mirrored request to be sent")
+	}
+	if shadow.Start(1, blocked, false, nil) != nil {
+		t.Fatal("Expected the second mirrored request to be dropped")
+	}
+	close(release)
+
+	waitFor(t, func() bool { return len(shadow.slots) == 0 })
+	if dropped := shadow.Route(1).Dropped; dropped != 1 {
+		t.Errorf("Expected 1 dropped request, got %d", dropped)
+	}
+
+	if shadow.Start(1, respond(`{}`, 200, nil), false, nil) == nil {
+		t.Error("Expected a mirrored request to be sent after the slot was released")
+	}
+}
+
+func TestShadow_PrimaryNeverArrives(t *testing.T) {
+	shadow := NewShadow(1, 20*time.Millisecond)
+
+	shadow.Start(1, respond(`{}`, 200, nil), true, nil)
+
+	waitFor(t, func() bool { return shadow.Route(1).Sent == 1 })
+	if report := shadow.Route(1); report.Compared != 0 {
+		t.Errorf("Expected no comparison without a primary response, got %+v", report)
+	}
+}
+
+func TestShadow_RecentRing(t *testing.T) {
+	shadow := NewShadow(1, time.Second)
+
+	for i := 0; i < recentResults+5; i++ {
+		shadow.update(1, func(r *routeReport) { r.add(Result{MirrorStatus: i}) })
+	}
+
+	recent := shadow.Route(1).Recent
+	if len(recent) != recentResults {
+		t.Fatalf("Expected %d results, got %d", recentResults, len(recent))
+	}
+	if recent[0].MirrorStatus != recentResults+4 || recent[len(recent)-1].MirrorStatus != 5 {
+		t.Errorf("Expected newest first, got %d..%d", recent[0].MirrorStatus, recent[len(recent)-1].MirrorStatus)
+	}
+}
+
+func TestSampled(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if Sampled(0) {
+			t.Fatal("Expected 0 percent to never sample")
+		}
+		if !Sampled(100) {
+			t.Fatal("Expected 100 percent to always sample")
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package outlier
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Config defines when an upstream instance is ejected and for how long
+type Config struct {
+	ConsecutiveErrors   int           // 5xx responses or errors (timeouts, refused connections) in a row ejecting an instance, 0 = off
+	Interval            time.Duration // Success rates are compared once per interval
+	BaseEjectionTime    time.Duration // Multiplied by the number of times the instance was ejected
+	MaxEjectionTime     time.Duration // Cap of the ejection time
+	MaxEjectionPercent  int           // Instances of an upstream ejected at once; one can always be ejected
+	SuccessRateMinHosts int           // Instances with enough requests needed to compare success rates, 0 = off
+	SuccessRateMinCalls int           // Requests an instance needs in an interval to take part in the comparison
+	SuccessRateStdev    float64       // Instances below mean - SuccessRateStdev * stdev of their peers' success rate are ejected
+}
+
+// DefaultConfig returns sensible defaults
+func DefaultConfig() Config {
+	return Config{
+		ConsecutiveErrors:   5,
+		Interval:            10 * time.Second,
+		BaseEjectionTime:    30 * time.Second,
+		MaxEjectionTime:     5 * time.Minute,
+		MaxEjectionPercent:  50,
+		SuccessRateMinHosts: 3,
+		SuccessRateMinCalls: 20,
+		SuccessRateStdev:    1.9,
+	}
+}
+
+// host is the outlier state of one instance
+type host struct {
+	consecutiveErrors int
+	calls             int // In the current interval
+	successes         int // In the current interval
+	ejections         int // Times ejected, decays by one per interval spent healthy
+	ejectedUntil      time.Time
+	lastReason        string
+}
+
+// group holds the instances of one upstream, ejection caps and success rates are per group
+type group struct {
+	hosts       map[string]*host
+	windowStart time.Time
+}
+
+// Detector ejects instances of an upstream that fail more than their peers, passively from request outcomes
+type Detector struct {
+	mu     sync.Mutex
+	groups map[string]*group
+	config Config
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewDetector creates an outlier detector
+func NewDetector(config Config, logger *zap.Logger) *Detector {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &Detector{
+		groups: make(map[string]*group),
+		config: config,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// SetHosts sets the instances of upstream, instances no longer listed are forgotten
+func (d *Detector) SetHosts(upstream string, addresses []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	g := d.group(upstream)
+	if len(g.hosts) == len(addresses) {
+		same := true
+		for _, address := range addresses {
+			if _, ok := g.hosts[address]; !ok {
+				same = false
+				break
+			}
+		}
+		if same {
+			return
+		}
+	}
+
+	hosts := make(map[string]*host, len(addresses))
+	for _, address := range addresses {
+		if h, ok := g.hosts[address]; ok {
+			hosts[address] = h
+		} else {
+			hosts[address] = &host{}
+		}
+	}
+	g.hosts = hosts
+}
+
+// Record records the outcome of a request to an instance of upstream
+// failed is a 5xx response or an error such as a timeout or a refused connection
+func (d *Detector) Record(upstream, address string, failed bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	g := d.group(upstream)
+	if now.Sub(g.windowStart) >= d.config.Interval {
+		d.evaluate(upstream, g, now)
+	}
+
+	h, ok := g.hosts[address]
+	if !ok {
+		h = &host{}
+		g.hosts[address] = h
+	}
+
+	h.calls++
+	if !failed {
+		h.successes++
+		h.consecutiveErrors = 0
+		return
+	}
+
+	h.consecutiveErrors++
+	if d.config.ConsecutiveErrors > 0 && h.consecutiveErrors >= d.config.ConsecutiveErrors && !h.ejectedUntil.After(now) {
+		d.eject(upstream, g, address, h, now, "consecutive_errors")
+	}
+}
+
+// IsEjected reports whether address is currently ejected from any upstream
+func (d *Detector) IsEjected(address string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for _, g := range d.groups {
+		if h, ok := g.hosts[address]; ok && h.ejectedUntil.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns the instances of every upstream with their ejection status
+func (d *Detector) Stats() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	stats := make(map[string]interface{}, len(d.groups))
+	for upstream, g := range d.groups {
+		hosts := make(map[string]interface{}, len(g.hosts))
+		for address, h := range g.hosts {
+			ejected := h.ejectedUntil.After(now)
+			hostStats := map[string]interface{}{
+				"ejected":            ejected,
+				"ejections":          h.ejections,
+				"consecutive_errors": h.consecutiveErrors,
+				"calls":              h.calls,
+				"successes":          h.successes,
+			}
+			if ejected {
+				hostStats["ejected_until"] = h.ejectedUntil
+				hostStats["reason"] = h.lastReason
+			}
+			hosts[address] = hostStats
+		}
+		stats[upstream] = map[string]interface{}{
+			"hosts":   hosts,
+			"ejected": g.ejectedCount(now),
+		}
+	}
+	return stats
+}
+
+// group returns the group of upstream, creating it (must hold lock)
+func (d *Detector) group(upstream string) *group {
+	g, ok := d.groups[upstream]
+	if !ok {
+		g = &group{hosts: make(map[string]*host), windowStart: d.now()}
+		d.groups[upstream] = g
+	}
+	return g
+}
+
+// evaluate closes the interval of g: instances whose success rate is an outlier among their peers are
+// ejected, healthy ones work off a past ejection, and the counters start over (must hold lock)
+func (d *Detector) evaluate(upstream string, g *group, now time.Time) {
+	defer func() {
+		for _, h := range g.hosts {
+			h.calls = 0
+			h.successes = 0
+		}
+		g.windowStart = now
+	}()
+
+	for _, h := range g.hosts {
+		if h.ejections > 0 && !h.ejectedUntil.After(now) {
+			h.ejections--
+		}
+	}
+
+	if d.config.SuccessRateMinHosts <= 0 {
+		return
+	}
+
+	rates := make(map[string]float64)
+	for address, h := range g.hosts {
+		if h.calls >= d.config.SuccessRateMinCalls && !h.ejectedUntil.After(now) {
+			rates[address] = float64(h.successes) / float64(h.calls)
+		}
+	}
+	if len(rates) < d.config.SuccessRateMinHosts {
+		return
+	}
+
+	mean := 0.0
+	for _, rate := range rates {
+		mean += rate
+	}
+	mean /= float64(len(rates))
+
+	variance := 0.0
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	stdev := math.Sqrt(variance / float64(len(rates)))
+
+	threshold := mean - d.config.SuccessRateStdev*stdev
+	for address, rate := range rates {
+		if rate < threshold {
+			d.eject(upstream, g, address, g.hosts[address], now, "success_rate")
+		}
+	}
+}
+
+// eject ejects an instance unless the group already has its maximum ejected (must hold lock)
+// The ejection lasts BaseEjectionTime times the number of times the instance was ejected, up to MaxEjectionTime
+func (d *Detector) eject(upstream string, g *group, address string, h *host, now time.Time, reason string) {
+	// Ejecting must keep the group within MaxEjectionPercent, unless nothing is ejected yet
+	ejected := g.ejectedCount(now)
+	if ejected > 0 && (ejected+1)*100 > d.config.MaxEjectionPercent*len(g.hosts) {
+		d.logger.Warn("Outlier ejection skipped, too many instances ejected",
+			zap.String("upstream", upstream),
+			zap.String("address", address),
+			zap.String("reason", reason),
+			zap.Int("ejected", ejected),
+			zap.Int("hosts", len(g.hosts)),
+		)
+		return
+	}
+
+	h.ejections++
+	duration := d.config.BaseEjectionTime * time.Duration(h.ejections)
+	if d.config.MaxEjectionTime > 0 && duration > d.config.MaxEjectionTime {
+		duration = d.config.MaxEjectionTime
+	}
+	h.ejectedUntil = now.Add(duration)
+	h.consecutiveErrors = 0
+	h.lastReason = reason
+
+	d.logger.Warn("Upstream instance ejected",
+		zap.String("upstream", upstream),
+		zap.String("address", address),
+		zap.String("reason", reason),
+		zap.Int("ejections", h.ejections),
+		zap.Duration("duration", duration),
+	)
+}
+
+// ejectedCount returns the instances of g currently ejected
+func (g *group) ejectedCount(now time.Time) int {
+	count := 0
+	for _, h := range g.hosts {
+		if h.ejectedUntil.After(now) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,193 @@
+package outlier
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTestDetector returns a detector on a clock the test moves forward
+func newTestDetector(config Config) (*Detector, *time.Time) {
+	d := NewDetector(config, nil)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func TestDetector_ConsecutiveErrors(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 3
+	d, now := newTestDetector(config)
+	d.SetHosts("upstream", []string{"a", "b", "c"})
+
+	d.Record("upstream", "a", true)
+	d.Record("upstream", "a", true)
+	d.Record("upstream", "a", false) // A success resets the streak
+	d.Record("upstream", "a", true)
+	d.Record("upstream", "a", true)
+	if d.IsEjected("a") {
+		t.Fatal("Expected no ejection without 3 errors in a row")
+	}
+
+	d.Record("upstream", "a", true)
+	if !d.IsEjected("a") {
+		t.Fatal("Expected ejection after 3 errors in a row")
+	}
+
+	*now = now.Add(config.BaseEjectionTime)
+	if d.IsEjected("a") {
+		t.Error("Expected the instance back after the base ejection time")
+	}
+}
+
+func TestDetector_EjectionBackoff(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 1
+	config.Interval = time.Hour // No decay during the test
+	d, now := newTestDetector(config)
+	d.SetHosts("upstream", []string{"a", "b"})
+
+	d.Record("upstream", "a", true)
+	*now = now.Add(config.BaseEjectionTime)
+
+	// Ejected a second time, for twice as long
+	d.Record("upstream", "a", true)
+	*now = now.Add(config.BaseEjectionTime)
+	if !d.IsEjected("a") {
+		t.Error("Expected the second ejection to last twice the base ejection time")
+	}
+	*now = now.Add(config.BaseEjectionTime)
+	if d.IsEjected("a") {
+		t.Error("Expected the instance back after twice the base ejection time")
+	}
+}
+
+func TestDetector_MaxEjectionPercent(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 1
+	config.MaxEjectionPercent = 50
+	d, _ := newTestDetector(config)
+	d.SetHosts("upstream", []string{"a", "b", "c", "d"})
+
+	for _, address := range []string{"a", "b", "c", "d"} {
+		d.Record("upstream", address, true)
+	}
+
+	ejected := 0
+	for _, address := range []string{"a", "b", "c", "d"} {
+		if d.IsEjected(address) {
+			ejected++
+		}
+	}
+	if ejected != 2 {
+		t.Errorf("Expected 2 of 4 instances ejected at 50%%, got %d", ejected)
+	}
+}
+
+func TestDetector_MaxEjectionPercentOfThree(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 1
+	config.MaxEjectionPercent = 50
+	d, _ := newTestDetector(config)
+	d.SetHosts("upstream", []string{"a", "b", "c"})
+
+	for _, address := range []string{"a", "b", "c"} {
+		d.Record("upstream", address, true)
+	}
+
+	// A second ejection would take 67% of the instances out
+	if !d.IsEjected("a") || d.IsEjected("b") || d.IsEjected("c") {
+		t.Error("Expected only 1 of 3 instances ejected at 50%")
+	}
+}
+
+func TestDetector_AlwaysEjectsOne(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 1
+	config.MaxEjectionPercent = 10
+	d, _ := newTestDetector(config)
+	d.SetHosts("upstream", []string{"a", "b", "c"})
+
+	d.Record("upstream", "a", true)
+	d.Record("upstream", "b", true)
+	if !d.IsEjected("a") || d.IsEjected("b") {
+		t.Error("Expected exactly the first failing instance ejected")
+	}
+}
+
+func TestDetector_SuccessRate(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 0
+	config.SuccessRateMinHosts = 3
+	config.SuccessRateMinCalls = 10
+	config.SuccessRateStdev = 1
+	d, now := newTestDetector(config)
+
+	hosts := []string{"a", "b", "c", "d", "e"}
+	d.SetHosts("upstream", hosts)
+	for i := 0; i < 100; i++ {
+		for _, address := range hosts {
+			// e fails half its requests, its peers almost none
+			failed := address == "e" && i%2 == 0 || i == 0
+			d.Record("upstream", address, failed)
+		}
+	}
+
+	// The next request after the interval evaluates it
+	*now = now.Add(config.Interval)
+	d.Record("upstream", "a", false)
+
+	for _, address := range hosts {
+		if got := d.IsEjected(address); got != (address == "e") {
+			t.Errorf("IsEjected(%s) = %v", address, got)
+		}
+	}
+
+	stats := d.Stats()["upstream"].(map[string]interface{})
+	if stats["ejected"].(int) != 1 {
+		t.Errorf("Expected 1 ejected instance in stats, got %v", stats["ejected"])
+	}
+	e := stats["hosts"].(map[string]interface{})["e"].(map[string]interface{})
+	if e["reason"] != "success_rate" {
+		t.Errorf("Expected success_rate reason, got %v", e["reason"])
+	}
+}
+
+func TestDetector_SuccessRateNeedsVolume(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 0
+	config.SuccessRateMinCalls = 50
+	d, now := newTestDetector(config)
+
+	hosts := []string{"a", "b", "c"}
+	d.SetHosts("upstream", hosts)
+	for i := 0; i < 10; i++ {
+		for _, address := range hosts {
+			d.Record("upstream", address, address == "c")
+		}
+	}
+
+	*now = now.Add(config.Interval)
+	d.Record("upstream", "a", false)
+	if d.IsEjected("c") {
+		t.Error("Expected no success rate ejection below the minimum request volume")
+	}
+}
+
+func TestDetector_SetHostsForgetsRemoved(t *testing.T) {
+	config := DefaultConfig()
+	config.ConsecutiveErrors = 1
+	d, _ := newTestDetector(config)
+	d.SetHosts("upstream", []string{"a", "b"})
+
+	d.Record("upstream", "a", true)
+	d.SetHosts("upstream", []string{"b", "c"})
+	if d.IsEjected("a") {
+		t.Error("Expected a removed instance to be forgotten")
+	}
+
+	hosts := d.Stats()["upstream"].(map[string]interface{})["hosts"].(map[string]interface{})
+	if len(hosts) != 2 {
+		t.Errorf("Expected 2 hosts, got %s", fmt.Sprint(hosts))
+	}
+}
@@ -51,10 +51,19 @@ type BackendHealth struct {
 	SuccessCount int
 }
 
+// httpKey identifies a pooled HTTP client: the URL config owning it (0 for the gateway's own), the address and TLS
+// Two URL configs on one address get their own clients, so their settings never replace each other's
+type httpKey struct {
+	owner      uint
+	address    string
+	tlsEnabled bool
+}
+
 // ConnectionPool manages HTTP and gRPC connections
 type ConnectionPool struct {
 	mu          sync.RWMutex
-	httpClients map[string]*http.Client
+	httpClients map[httpKey]*http.Client
+	httpConfigs map[httpKey]PoolConfig
 	grpcConns   map[string]*grpc.ClientConn
 	healthStats map[string]*BackendHealth
 	config      PoolConfig
@@ -68,7 +77,8 @@ func NewConnectionPool(config PoolConfig, logger *zap.Logger) *ConnectionPool {
 	}
 
 	return &ConnectionPool{
-		httpClients: make(map[string]*http.Client),
+		httpClients: make(map[httpKey]*http.Client),
+		httpConfigs: make(map[httpKey]PoolConfig),
 		grpcConns:   make(map[string]*grpc.ClientConn),
 		healthStats: make(map[string]*BackendHealth),
 		config:      config,
@@ -78,11 +88,20 @@ func NewConnectionPool(config PoolConfig, logger *zap.Logger) *ConnectionPool {
 
 // GetHTTPClient returns an HTTP client for the given address
 func (p *ConnectionPool) GetHTTPClient(address string, tlsEnabled bool) *http.Client {
+	return p.GetHTTPClientWithConfig(0, address, tlsEnabled, p.config)
+}
+
+// GetHTTPClientWithConfig returns the HTTP client of URL config owner for the given address built from config
+// The cached client is replaced when its config changed since it was created, e.g. after a URL config edit
+func (p *ConnectionPool) GetHTTPClientWithConfig(owner uint, address string, tlsEnabled bool, config PoolConfig) *http.Client {
+	key := httpKey{owner: owner, address: address, tlsEnabled: tlsEnabled}
+
 	p.mu.RLock()
-	client, exists := p.httpClients[address]
+	client, exists := p.httpClients[key]
+	current := p.httpConfigs[key]
 	p.mu.RUnlock()
 
-	if exists {
+	if exists && current == config {
 		return client
 	}
 
@@ -90,19 +109,25 @@ func (p *ConnectionPool) GetHTTPClient(address string, tlsEnabled bool) *http.Cl
 	defer p.mu.Unlock()
 
 	// Double check after acquiring write lock
-	if client, exists = p.httpClients[address]; exists {
-		return client
+	if client, exists = p.httpClients[key]; exists {
+		if p.httpConfigs[key] == config {
+			return client
+		}
+		if transport, ok := client.Transport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
 	}
 
 	// Create new HTTP client with connection pooling
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
-			Timeout:   p.config.ConnectionTimeout,
+			Timeout:   config.ConnectionTimeout,
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
-		MaxIdleConns:          p.config.MaxIdleConns,
-		MaxIdleConnsPerHost:   p.config.MaxIdleConnsPerHost,
-		IdleConnTimeout:       p.config.IdleTimeout,
+		MaxConnsPerHost:       config.MaxConnections,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.IdleTimeout,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 		ForceAttemptHTTP2:     true,
@@ -116,19 +141,25 @@ func (p *ConnectionPool) GetHTTPClient(address string, tlsEnabled bool) *http.Cl
 
 	client = &http.Client{
 		Transport: transport,
-		Timeout:   p.config.ReadTimeout + p.config.WriteTimeout,
+		Timeout:   config.ReadTimeout + config.WriteTimeout,
 	}
 
-	p.httpClients[address] = client
-	p.healthStats[address] = &BackendHealth{
-		Address:   address,
-		IsHealthy: true,
-		LastCheck: time.Now(),
+	p.httpClients[key] = client
+	p.httpConfigs[key] = config
+	if _, tracked := p.healthStats[address]; !tracked {
+		p.healthStats[address] = &BackendHealth{
+			Address:   address,
+			IsHealthy: true,
+			LastCheck: time.Now(),
+		}
 	}
 
 	p.logger.Info("Created new HTTP client",
 		zap.String("address", address),
+		zap.Uint("url_config_id", owner),
 		zap.Bool("tls_enabled", tlsEnabled),
+		zap.Int("max_connections", config.MaxConnections),
+		zap.Duration("connection_timeout", config.ConnectionTimeout),
 	)
 
 	return client
@@ -272,11 +303,12 @@ func (p *ConnectionPool) CloseAllConnections() error {
 	}
 
 	// Close HTTP transports
-	for addr, client := range p.httpClients {
+	for key, client := range p.httpClients {
 		if transport, ok := client.Transport.(*http.Transport); ok {
 			transport.CloseIdleConnections()
 		}
-		delete(p.httpClients, addr)
+		delete(p.httpClients, key)
+		delete(p.httpConfigs, key)
 	}
 
 	p.logger.Info("Closed all connections")
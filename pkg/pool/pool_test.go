@@ -2,6 +2,7 @@ package pool
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
@@ -135,3 +136,73 @@ func TestGRPCConnection_InvalidAddress(t *testing.T) {
 		t.Error("Expected non-nil connection or error")
 	}
 }
+
+func TestConnectionPool_GetHTTPClientWithConfig(t *testing.T) {
+	pool := NewConnectionPool(DefaultPoolConfig(), nil)
+	address := "http://configured-backend.com"
+
+	config := DefaultPoolConfig()
+	config.MaxConnections = 5
+	config.ReadTimeout = 2 * time.Second
+	config.WriteTimeout = 1 * time.Second
+
+	client1 := pool.GetHTTPClientWithConfig(1, address, false, config)
+	if client1.Timeout != 3*time.Second {
+		t.Errorf("Expected client timeout 3s, got %s", client1.Timeout)
+	}
+	if transport := client1.Transport.(*http.Transport); transport.MaxConnsPerHost != 5 {
+		t.Errorf("Expected 5 max conns per host, got %d", transport.MaxConnsPerHost)
+	}
+
+	// Same config - should return cached
+	if client2 := pool.GetHTTPClientWithConfig(1, address, false, config); client1 != client2 {
+		t.Error("Expected same client instance for unchanged config")
+	}
+
+	// Changed config - should replace the client
+	config.MaxConnections = 10
+	client3 := pool.GetHTTPClientWithConfig(1, address, false, config)
+	if client3 == client1 {
+		t.Error("Expected new client instance after config change")
+	}
+	if transport := client3.Transport.(*http.Transport); transport.MaxConnsPerHost != 10 {
+		t.Errorf("Expected 10 max conns per host, got %d", transport.MaxConnsPerHost)
+	}
+
+	stats := pool.Stats()
+	if stats["http_clients"].(int) != 1 {
+		t.Errorf("Expected 1 http client, got %d", stats["http_clients"].(int))
+	}
+}
+
+func TestConnectionPool_HTTPClientsPerURLConfig(t *testing.T) {
+	pool := NewConnectionPool(DefaultPoolConfig(), nil)
+	address := "http://shared-backend.com"
+
+	small := DefaultPoolConfig()
+	small.MaxConnections = 5
+	large := DefaultPoolConfig()
+	large.MaxConnections = 50
+
+	// Two URL configs on one host keep their own clients instead of replacing each other's
+	first := pool.GetHTTPClientWithConfig(1, address, false, small)
+	second := pool.GetHTTPClientWithConfig(2, address, false, large)
+	if first == second {
+		t.Fatal("Expected a client per URL config")
+	}
+	if again := pool.GetHTTPClientWithConfig(1, address, false, small); again != first {
+		t.Error("Expected the first URL config's client to survive the second one's")
+	}
+	if transport := first.Transport.(*http.Transport); transport.MaxConnsPerHost != 5 {
+		t.Errorf("Expected 5 max conns per host, got %d", transport.MaxConnsPerHost)
+	}
+
+	// TLS is part of the key too
+	if tls := pool.GetHTTPClientWithConfig(1, address, true, small); tls == first || tls.Transport.(*http.Transport).TLSClientConfig == nil {
+		t.Error("Expected a separate TLS client")
+	}
+
+	if stats := pool.Stats(); stats["http_clients"].(int) != 3 {
+		t.Errorf("Expected 3 http clients, got %d", stats["http_clients"].(int))
+	}
+}
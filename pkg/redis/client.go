@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -23,8 +24,12 @@ type Client interface {
 	IsEnabled() bool
 	Close() error
 
+	// Pub/Sub for cluster-wide notifications
+	Publish(ctx context.Context, channel string, message interface{}) error
+	Subscribe(ctx context.Context, channel string) (<-chan []byte, error)
+
 	// Integration-specific methods for backward compatibility
-	SetIntegrationResponse(ctx context.Context, key string, data []byte, status int, headers map[string]string, ttl time.Duration) error
+	SetIntegrationResponse(ctx context.Context, key string, data []byte, status int, headers http.Header, ttl time.Duration) error
 	GetIntegrationResponse(ctx context.Context, key string) (*CacheItem, error)
 }
 
@@ -40,11 +45,13 @@ type Config struct {
 }
 
 // CacheItem represents cached integration response
+// Body holds the response as is, Data only the responses cached before bodies were stored as bytes
 type CacheItem struct {
-	Data      interface{}       `json:"data"`
-	ExpiresAt time.Time         `json:"expires_at"`
-	Status    int               `json:"status"`
-	Headers   map[string]string `json:"headers,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Body      []byte      `json:"body,omitempty"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Status    int         `json:"status"`
+	Headers   http.Header `json:"headers,omitempty"`
 }
 
 // RedisClient implements Client using Redis
@@ -265,9 +272,9 @@ func (c *RedisClient) FlushAll(ctx context.Context) error {
 }
 
 // SetIntegrationResponse caches response from integration API (backward compatibility)
-func (c *RedisClient) SetIntegrationResponse(ctx context.Context, key string, data []byte, status int, headers map[string]string, ttl time.Duration) error {
+func (c *RedisClient) SetIntegrationResponse(ctx context.Context, key string, data []byte, status int, headers http.Header, ttl time.Duration) error {
 	item := CacheItem{
-		Data:      string(data),
+		Body:      data,
 		ExpiresAt: time.Now().Add(ttl),
 		Status:    status,
 		Headers:   headers,
@@ -307,6 +314,77 @@ func (c *RedisClient) GetIntegrationResponse(ctx context.Context, key string) (*
 	return &item, nil
 }
 
+// Publish sends message on channel, non []byte/string messages are JSON encoded
+func (c *RedisClient) Publish(ctx context.Context, channel string, message interface{}) error {
+	if !c.enabled {
+		return fmt.Errorf("cache disabled")
+	}
+
+	var payload []byte
+	switch m := message.(type) {
+	case []byte:
+		payload = m
+	case string:
+		payload = []byte(m)
+	default:
+		data, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		payload = data
+	}
+
+	if err := c.client.Publish(ctx, channel, payload).Err(); err != nil {
+		c.logger.Error("Publish error", zap.String("channel", channel), zap.Error(err))
+		return err
+	}
+
+	c.logger.Debug("Message published", zap.String("channel", channel), zap.Int("size", len(payload)))
+	return nil
+}
+
+// Subscribe delivers payloads published on channel until ctx is cancelled
+// The returned channel is closed when the subscription ends; dropped connections are re-established by the driver
+func (c *RedisClient) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	if !c.enabled {
+		return nil, fmt.Errorf("cache disabled")
+	}
+
+	pubsub := c.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		c.logger.Error("Subscribe error", zap.String("channel", channel), zap.Error(err))
+		return nil, err
+	}
+
+	c.logger.Info("Subscribed to channel", zap.String("channel", channel))
+
+	out := make(chan []byte, 64)
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case out <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
 // Close closes the Redis connection
 func (c *RedisClient) Close() error {
 	if c.enabled && c.client != nil {
@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// BudgetConfig caps retries across all upstreams, so a brownout doesn't multiply the load it's under
+type BudgetConfig struct {
+	Percent      float64       // Retries allowed as a percentage of requests in the window
+	MinPerSecond int           // Retries always allowed per second, for low traffic
+	Window       time.Duration // Requests and retries are counted over this sliding window
+}
+
+// DefaultBudgetConfig returns sensible defaults
+func DefaultBudgetConfig() BudgetConfig {
+	return BudgetConfig{
+		Percent:      20,
+		MinPerSecond: 10,
+		Window:       10 * time.Second,
+	}
+}
+
+// budgetBucket counts one second of a budget's window
+type budgetBucket struct {
+	second   int64
+	requests int
+	retries  int
+}
+
+// Budget allows a retry while retries in the window stay under the configured share of requests
+type Budget struct {
+	mu        sync.Mutex
+	buckets   []budgetBucket
+	config    BudgetConfig
+	exhausted int64
+	now       func() time.Time
+}
+
+// NewBudget creates a retry budget
+func NewBudget(config BudgetConfig) *Budget {
+	seconds := int(config.Window / time.Second)
+	if seconds <= 0 {
+		seconds = 1
+	}
+	return &Budget{
+		buckets: make([]budgetBucket, seconds),
+		config:  config,
+		now:     time.Now,
+	}
+}
+
+// Request counts a request towards the budget, retries not included
+func (b *Budget) Request() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket().requests++
+}
+
+// Withdraw takes a retry from the budget, false when it is exhausted
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	requests, retries := b.totals()
+	allowed := float64(requests) * b.config.Percent / 100
+	if min := float64(b.config.MinPerSecond * len(b.buckets)); allowed < min {
+		allowed = min
+	}
+	if float64(retries) >= allowed {
+		b.exhausted++
+		return false
+	}
+
+	b.bucket().retries++
+	return true
+}
+
+// Stats returns the requests and retries in the window and the retries refused so far
+func (b *Budget) Stats() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	requests, retries := b.totals()
+	return map[string]interface{}{
+		"requests":  requests,
+		"retries":   retries,
+		"exhausted": b.exhausted,
+		"percent":   b.config.Percent,
+		"window":    b.config.Window.String(),
+	}
+}
+
+// bucket returns the bucket of the current second, emptied if it held an older one (must hold lock)
+func (b *Budget) bucket() *budgetBucket {
+	second := b.now().Unix()
+	bucket := &b.buckets[int(second%int64(len(b.buckets)))]
+	if bucket.second != second {
+		*bucket = budgetBucket{second: second}
+	}
+	return bucket
+}
+
+// totals returns the requests and retries in the window (must hold lock)
+func (b *Budget) totals() (requests, retries int) {
+	second := b.now().Unix()
+	for _, bucket := range b.buckets {
+		if second-bucket.second < int64(len(b.buckets)) {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	return requests, retries
+}
@@ -0,0 +1,41 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(BudgetConfig{Percent: 10, MinPerSecond: 1, Window: 2 * time.Second})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	budget.now = func() time.Time { return now }
+
+	// Low traffic: the minimum of 1 per second over 2 seconds
+	if !budget.Withdraw() || !budget.Withdraw() {
+		t.Fatal("Expected the minimum retries to be allowed")
+	}
+	if budget.Withdraw() {
+		t.Fatal("Expected the budget to be exhausted")
+	}
+
+	// 100 requests allow 10 retries in the window
+	for i := 0; i < 100; i++ {
+		budget.Request()
+	}
+	allowed := 0
+	for budget.Withdraw() {
+		allowed++
+	}
+	if allowed != 8 {
+		t.Errorf("Expected 8 more retries (10 minus 2 taken), got %d", allowed)
+	}
+
+	// The window slides past it all
+	now = now.Add(2 * time.Second)
+	if !budget.Withdraw() {
+		t.Error("Expected the budget to recover once the window moved on")
+	}
+	if got := budget.Stats()["exhausted"].(int64); got != 2 {
+		t.Errorf("Expected 2 exhausted withdrawals, got %d", got)
+	}
+}
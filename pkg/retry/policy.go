@@ -0,0 +1,180 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Classes of errors a failed attempt falls into
+const (
+	ConnectFailure = "connect_failure" // Refused, unresolvable or unreachable, the request never reached the upstream
+	Reset          = "reset"           // Connection reset or closed before a response, the upstream may have seen the request
+	Timeout        = "timeout"         // No response within the try's timeout, the upstream may still be processing it
+)
+
+// IdempotencyKeyHeader makes a non-idempotent request safe to retry, the upstream deduplicates on it
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Policy decides whether and when a failed attempt is tried again
+type Policy struct {
+	MaxRetries    int
+	BaseDelay     time.Duration // Full jitter backoff: a random wait up to BaseDelay * 2^attempt
+	MaxDelay      time.Duration // Cap of the backoff, Retry-After asking for longer ends the retries
+	PerTryTimeout time.Duration // 0 = the request's own timeout for every try
+	RetryOnStatus []int         // Response status codes retried
+	RetryOn       []string      // Error classes retried
+	Budget        *Budget       // Shared cap on retries, nil = no cap
+}
+
+// DefaultPolicy returns sensible defaults
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries:    0,
+		BaseDelay:     time.Second,
+		MaxDelay:      30 * time.Second,
+		RetryOnStatus: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryOn:       []string{ConnectFailure, Reset},
+	}
+}
+
+// ValidClass reports whether class is a known error class
+func ValidClass(class string) bool {
+	return class == ConnectFailure || class == Reset || class == Timeout
+}
+
+// ParseStatusCodes parses a comma separated list of status codes like "502,503,504", skipping invalid entries
+func ParseStatusCodes(value string) []int {
+	codes := make([]int, 0)
+	for _, item := range strings.Split(value, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(item))
+		if err == nil && code >= 100 && code <= 599 {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// ParseClasses parses a comma separated list of error classes like "connect_failure,reset", skipping unknown entries
+func ParseClasses(value string) []string {
+	classes := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if class := strings.TrimSpace(item); ValidClass(class) {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// Idempotent reports whether a request with method may be sent twice without changing its effect
+func Idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Retryable reports whether an attempt ending in status or err is tried again, with the reason
+// Only connect failures, which never reached the upstream, are retried for non-idempotent requests
+// without an idempotency key
+func (p Policy) Retryable(method string, hasIdempotencyKey bool, status int, err error) (string, bool) {
+	safe := Idempotent(method) || hasIdempotencyKey
+
+	if err != nil {
+		class := Classify(err)
+		if class == "" || !contains(p.RetryOn, class) {
+			return class, false
+		}
+		return class, safe || class == ConnectFailure
+	}
+
+	for _, code := range p.RetryOnStatus {
+		if code == status {
+			return "status_" + strconv.Itoa(status), safe
+		}
+	}
+	return "", false
+}
+
+// Classify returns the class of a failed attempt's error, empty when it isn't worth retrying
+func Classify(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return ""
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ConnectFailure
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ConnectFailure
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) {
+		return ConnectFailure
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return Reset
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Timeout
+	}
+	return ""
+}
+
+// Backoff returns the full jitter wait before retry number attempt (0 based): uniformly random
+// between 0 and min(max, base * 2^attempt), so clients retrying together spread out
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	ceiling := base
+	for i := 0; i < attempt && ceiling < max; i++ {
+		ceiling *= 2
+	}
+	if max > 0 && ceiling > max {
+		ceiling = max
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// RetryAfter returns the wait an upstream asked for in its Retry-After header, in seconds or as an HTTP date
+func RetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := strings.TrimSpace(header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func contains(items []string, item string) bool {
+	for _, candidate := range items {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
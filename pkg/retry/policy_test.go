@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, ConnectFailure},
+		{"dns", fmt.Errorf("http error: %w", &net.DNSError{Name: "upstream.invalid"}), ConnectFailure},
+		{"reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, Reset},
+		{"eof", fmt.Errorf("http error: %w", io.EOF), Reset},
+		{"timeout", fmt.Errorf("http error: %w", context.DeadlineExceeded), Timeout},
+		{"canceled", context.Canceled, ""},
+		{"other", errors.New("marshal body"), ""},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.expected {
+			t.Errorf("Classify(%s) = %q, want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestPolicy_Retryable(t *testing.T) {
+	policy := DefaultPolicy()
+	refused := &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}
+	reset := &net.OpError{Op: "read", Err: syscall.ECONNRESET}
+
+	tests := []struct {
+		name     string
+		method   string
+		key      bool
+		status   int
+		err      error
+		expected bool
+	}{
+		{"GET 503", http.MethodGet, false, http.StatusServiceUnavailable, nil, true},
+		{"GET 500 not listed", http.MethodGet, false, http.StatusInternalServerError, nil, false},
+		{"POST 503", http.MethodPost, false, http.StatusServiceUnavailable, nil, false},
+		{"POST 503 with key", http.MethodPost, true, http.StatusServiceUnavailable, nil, true},
+		{"POST refused", http.MethodPost, false, 0, refused, true},
+		{"POST reset", http.MethodPost, false, 0, reset, false},
+		{"GET reset", http.MethodGet, false, 0, reset, true},
+		{"GET timeout not listed", http.MethodGet, false, 0, context.DeadlineExceeded, false},
+	}
+
+	for _, tt := range tests {
+		if _, got := policy.Retryable(tt.method, tt.key, tt.status, tt.err); got != tt.expected {
+			t.Errorf("%s: Retryable = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if got := ParseStatusCodes(" 502, 503,abc,99"); fmt.Sprint(got) != "[502 503]" {
+		t.Errorf("Unexpected status codes %v", got)
+	}
+	if got := ParseClasses("timeout, bogus,reset"); fmt.Sprint(got) != "[timeout reset]" {
+		t.Errorf("Unexpected classes %v", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := time.Second << attempt
+		if ceiling > 5*time.Second {
+			ceiling = 5 * time.Second
+		}
+		for i := 0; i < 100; i++ {
+			if got := Backoff(attempt, time.Second, 5*time.Second); got < 0 || got > ceiling {
+				t.Fatalf("Backoff(%d) = %s, outside [0, %s]", attempt, got, ceiling)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	if got, ok := RetryAfter(header, now); !ok || got != 3*time.Second {
+		t.Errorf("Expected 3s, got %s %v", got, ok)
+	}
+
+	header.Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+	if got, ok := RetryAfter(header, now); !ok || got != time.Minute {
+		t.Errorf("Expected 1m, got %s %v", got, ok)
+	}
+
+	header.Set("Retry-After", "soon")
+	if _, ok := RetryAfter(header, now); ok {
+		t.Error("Expected an invalid Retry-After to be ignored")
+	}
+}
@@ -0,0 +1,262 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+// Segment kinds in the order collectMatches tries them
+const (
+	kindStatic = iota
+	kindConstrained
+	kindParam
+	kindWildcard
+	kindCatchAll
+)
+
+// Clone returns an independent registry holding the same routes
+// The clone can be modified freely without affecting lookups on r
+func (r *RouteRegistry) Clone() *RouteRegistry {
+	return r.cloneExcluding(0)
+}
+
+// cloneExcluding clones the registry without the config whose ID is excludeID (0 keeps all)
+func (r *RouteRegistry) cloneExcluding(excludeID uint) *RouteRegistry {
+	clone := NewRouteRegistry(r.logger)
+	for _, config := range r.snapshot() {
+		if excludeID != 0 && config.ID == excludeID {
+			continue
+		}
+		_ = clone.AddRoute(config)
+	}
+	return clone
+}
+
+// AnalyzeRoute simulates adding config to a clone of the registry without touching live routes
+// excludeID skips the stored version of a config being edited (0 for new configs)
+// Returns:
+//   - conflicts: reasons config cannot be added (invalid pattern, duplicate, equivalent pattern)
+//   - shadowedBy: existing routes that win every request config would match
+//   - shadows: existing routes config would make unreachable
+func (r *RouteRegistry) AnalyzeRoute(config *dto.APIConfigResponse, excludeID uint) (conflicts, shadowedBy, shadows []dto.RouteIssue) {
+	clone := r.cloneExcluding(excludeID)
+	existing := clone.snapshot()
+
+	if err := clone.AddRoute(config); err != nil {
+		reason := err.Error()
+		if errors.Is(err, ErrRouteAlreadyExists) {
+			reason = "duplicate route: " + reason
+		}
+		conflicts = append(conflicts, dto.RouteIssue{Path: config.Path, Method: config.Method, Reason: reason})
+		return conflicts, nil, nil
+	}
+
+	// Compare public paths, routes are matched under their URL config base path
+	newPath := MountPath(config)
+	newSegments := ParseURI(newPath)
+	for _, other := range existing {
+		if !methodsOverlap(other.Method, config.Method) {
+			continue
+		}
+		otherPath := MountPath(other)
+		otherSegments := ParseURI(otherPath)
+
+		// Same shape with different parameter names, e.g. /v1/{a} vs /v1/{b}
+		if other.Method == config.Method && otherPath != newPath && equivalentPatterns(otherSegments, newSegments) && predicateKey(other) == predicateKey(config) {
+			conflicts = append(conflicts, routeIssue(other, fmt.Sprintf("equivalent pattern: %s and %s match the same requests", otherPath, newPath)))
+			continue
+		}
+
+		if methodCovers(other.Method, config.Method) && predicatesCover(other, config) && coversPattern(otherSegments, newSegments) && wins(other, otherSegments, config, newSegments, true) {
+			shadowedBy = append(shadowedBy, routeIssue(other, fmt.Sprintf("%s matches every request of %s and takes precedence", otherPath, newPath)))
+			continue
+		}
+
+		if methodCovers(config.Method, other.Method) && predicatesCover(config, other) && coversPattern(newSegments, otherSegments) && wins(config, newSegments, other, otherSegments, false) {
+			shadows = append(shadows, routeIssue(other, fmt.Sprintf("%s matches every request of %s and takes precedence", newPath, otherPath)))
+		}
+	}
+
+	return conflicts, shadowedBy, shadows
+}
+
+// snapshot returns every registered config
+func (r *RouteRegistry) snapshot() []*dto.APIConfigResponse {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	configs := make([]*dto.APIConfigResponse, 0, len(r.routes))
+	for _, config := range r.routes {
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// routeIssue describes config in an analysis result
+func routeIssue(config *dto.APIConfigResponse, reason string) dto.RouteIssue {
+	return dto.RouteIssue{
+		ID:       config.ID,
+		Path:     config.Path,
+		Method:   config.Method,
+		Priority: config.Priority,
+		Reason:   reason,
+	}
+}
+
+// segmentKind classifies a pattern segment
+func segmentKind(segment string) int {
+	switch {
+	case IsCatchAllSegment(segment):
+		return kindCatchAll
+	case IsWildcardSegment(segment):
+		return kindWildcard
+	case IsParameterSegment(segment):
+		if _, expr := splitParamSegment(segment); expr != "" {
+			return kindConstrained
+		}
+		return kindParam
+	default:
+		return kindStatic
+	}
+}
+
+// equivalentPatterns reports whether a and b accept exactly the same paths
+func equivalentPatterns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		ka, kb := segmentKind(a[i]), segmentKind(b[i])
+		switch {
+		case ka == kindStatic || kb == kindStatic:
+			if a[i] != b[i] {
+				return false
+			}
+		case (ka == kindParam || ka == kindWildcard) && (kb == kindParam || kb == kindWildcard):
+		case ka == kindConstrained && kb == kindConstrained:
+			_, ea := splitParamSegment(a[i])
+			_, eb := splitParamSegment(b[i])
+			if ea != eb {
+				return false
+			}
+		case ka == kindCatchAll && kb == kindCatchAll:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// coversPattern reports whether every path accepted by inner is also accepted by outer
+func coversPattern(outer, inner []string) bool {
+	for i, segment := range inner {
+		if i >= len(outer) {
+			return false
+		}
+		if segmentKind(outer[i]) == kindCatchAll {
+			return true
+		}
+		if !coversSegment(outer[i], segment) {
+			return false
+		}
+	}
+
+	// outer may only be longer by a trailing catch-all (which also matches an empty remainder)
+	if len(outer) == len(inner) {
+		return true
+	}
+	return len(outer) == len(inner)+1 && segmentKind(outer[len(inner)]) == kindCatchAll
+}
+
+// coversSegment reports whether every value accepted by inner is accepted by outer
+func coversSegment(outer, inner string) bool {
+	innerKind := segmentKind(inner)
+	if innerKind == kindCatchAll {
+		return false
+	}
+
+	switch segmentKind(outer) {
+	case kindParam, kindWildcard:
+		return true
+	case kindConstrained:
+		_, outerExpr := splitParamSegment(outer)
+		switch innerKind {
+		case kindStatic:
+			re, err := compileConstraint(outerExpr)
+			return err == nil && re.MatchString(inner)
+		case kindConstrained:
+			_, innerExpr := splitParamSegment(inner)
+			return outerExpr == innerExpr
+		}
+		return false
+	case kindStatic:
+		return innerKind == kindStatic && outer == inner
+	}
+	return false
+}
+
+// wins reports whether a is selected over b for a request both match
+// Higher priority wins; on a tie the match found first by collectMatches wins, which is the
+// one whose first differing segment is more specific. aRegisteredFirst breaks ties between
+// segments of the same kind, since earlier registrations are tried first
+func wins(a *dto.APIConfigResponse, aSegments []string, b *dto.APIConfigResponse, bSegments []string, aRegisteredFirst bool) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+
+	for i := 0; i < len(aSegments) && i < len(bSegments); i++ {
+		if aSegments[i] == bSegments[i] {
+			continue
+		}
+		ka, kb := segmentKind(aSegments[i]), segmentKind(bSegments[i])
+		if ka != kb {
+			return ka < kb
+		}
+		return aRegisteredFirst
+	}
+
+	// One pattern ends where the other continues with a catch-all: the shorter one is found first
+	if len(aSegments) != len(bSegments) {
+		return len(aSegments) < len(bSegments)
+	}
+
+	// Same node: the exact method is tried before ANY
+	if (a.Method == MethodAny) != (b.Method == MethodAny) {
+		return b.Method == MethodAny
+	}
+
+	// Then configs with more match predicates
+	if predicateCount(a) != predicateCount(b) {
+		return predicateCount(a) > predicateCount(b)
+	}
+	return aRegisteredFirst
+}
+
+// methodsOverlap reports whether configs for methods a and b can serve the same request
+// OPTIONS is never served by ANY
+func methodsOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if a == MethodAny {
+		return b != http.MethodOptions
+	}
+	if b == MethodAny {
+		return a != http.MethodOptions
+	}
+	return false
+}
+
+// methodCovers reports whether a config for outer serves every request a config for inner does
+func methodCovers(outer, inner string) bool {
+	return outer == inner || (outer == MethodAny && inner != http.MethodOptions)
+}
+
+// predicatesCover reports whether outer's match predicates accept every request inner's do
+// Only the simple cases are recognized: no predicates, or identical predicates
+func predicatesCover(outer, inner *dto.APIConfigResponse) bool {
+	return !hasPredicates(outer) || predicateKey(outer) == predicateKey(inner)
+}
@@ -0,0 +1,69 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+func TestRouteRegistry_AnalyzeRoute(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	existing := []*dto.APIConfigResponse{
+		{ID: 1, Path: "/v1/users/{id}", Method: "GET"},
+		{ID: 2, Path: "/v1/orders/{id:int}", Method: "GET"},
+		{ID: 3, Path: "/v1/files/{rest...}", Method: "GET", Priority: 10},
+		{ID: 4, Path: "/v1/items/export", Method: "GET"},
+	}
+	for _, config := range existing {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		config     *dto.APIConfigResponse
+		excludeID  uint
+		conflicts  int
+		shadowedBy int
+		shadows    int
+	}{
+		{"no overlap", &dto.APIConfigResponse{Path: "/v1/products/{id}", Method: "GET"}, 0, 0, 0, 0},
+		{"exact duplicate", &dto.APIConfigResponse{Path: "/v1/users/{id}", Method: "GET"}, 0, 1, 0, 0},
+		{"editing itself is not a duplicate", &dto.APIConfigResponse{ID: 1, Path: "/v1/users/{id}", Method: "GET"}, 1, 0, 0, 0},
+		{"equivalent pattern", &dto.APIConfigResponse{Path: "/v1/users/{userId}", Method: "GET"}, 0, 1, 0, 0},
+		{"other method", &dto.APIConfigResponse{Path: "/v1/users/{userId}", Method: "POST"}, 0, 0, 0, 0},
+		{"ANY with higher priority shadows exact method", &dto.APIConfigResponse{Path: "/v1/items/{id}", Method: MethodAny, Priority: 5}, 0, 0, 0, 1},
+		{"ANY next to exact method is reachable", &dto.APIConfigResponse{Path: "/v1/items/export", Method: MethodAny}, 0, 0, 0, 0},
+		{"ANY is not shadowed by a single method catch-all", &dto.APIConfigResponse{Path: "/v1/files/report", Method: MethodAny}, 0, 0, 0, 0},
+		{"static under param is reachable", &dto.APIConfigResponse{Path: "/v1/users/me", Method: "GET"}, 0, 0, 0, 0},
+		{"static under param with lower priority is shadowed", &dto.APIConfigResponse{Path: "/v1/users/me", Method: "GET", Priority: -1}, 0, 0, 1, 0},
+		{"catch-all with higher priority shadows", &dto.APIConfigResponse{Path: "/v1/files/report", Method: "GET"}, 0, 0, 1, 0},
+		{"param shadows static with higher priority", &dto.APIConfigResponse{Path: "/v1/items/{id}", Method: "GET", Priority: 5}, 0, 0, 0, 1},
+		{"constrained param covers matching static", &dto.APIConfigResponse{Path: "/v1/orders/42", Method: "GET", Priority: -1}, 0, 0, 1, 0},
+		{"constrained param does not cover other static", &dto.APIConfigResponse{Path: "/v1/orders/latest", Method: "GET", Priority: -1}, 0, 0, 0, 0},
+		{"invalid constraint", &dto.APIConfigResponse{Path: "/v1/bad/{id:[}", Method: "GET"}, 0, 1, 0, 0},
+		{"predicate variant is reachable", &dto.APIConfigResponse{Path: "/v1/users/{id}", Method: "GET", MatchHeaders: map[string]string{"X-API-Version": "2"}}, 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts, shadowedBy, shadows := registry.AnalyzeRoute(tt.config, tt.excludeID)
+			if len(conflicts) != tt.conflicts {
+				t.Errorf("Expected %d conflicts, got %v", tt.conflicts, conflicts)
+			}
+			if len(shadowedBy) != tt.shadowedBy {
+				t.Errorf("Expected %d shadowed_by, got %v", tt.shadowedBy, shadowedBy)
+			}
+			if len(shadows) != tt.shadows {
+				t.Errorf("Expected %d shadows, got %v", tt.shadows, shadows)
+			}
+		})
+	}
+
+	// The live registry is untouched
+	if registry.Count() != len(existing) {
+		t.Errorf("Expected count %d after analysis, got %d", len(existing), registry.Count())
+	}
+}
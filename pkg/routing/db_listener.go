@@ -0,0 +1,249 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// maxSingleRefreshes is the number of distinct routes in one batch above which a full refresh is cheaper
+const maxSingleRefreshes = 20
+
+// DBChange is a row change notified by the api_configs/url_configs triggers
+type DBChange struct {
+	Table     string `json:"table"` // "api_configs", "url_configs"; empty = resync everything
+	Op        string `json:"op"`    // INSERT, UPDATE, DELETE
+	ID        uint   `json:"id"`
+	Path      string `json:"path,omitempty"`
+	Method    string `json:"method,omitempty"`
+	OldPath   string `json:"old_path,omitempty"`
+	OldMethod string `json:"old_method,omitempty"`
+}
+
+// DBListener hot reloads routes from Postgres LISTEN/NOTIFY, so direct writes to the config
+// tables reach the registry without going through the admin API
+// Bursts of notifications are debounced into a single batch of refreshes
+type DBListener struct {
+	dsn       string
+	channel   string
+	refresher *Refresher
+	debounce  time.Duration // Quiet period before a batch is applied
+	maxWait   time.Duration // Upper bound on how long a batch may be held back
+	logger    *zap.Logger
+}
+
+// NewDBListener creates a new database change listener
+func NewDBListener(dsn, channel string, refresher *Refresher, debounce time.Duration, logger *zap.Logger) *DBListener {
+	if debounce <= 0 {
+		debounce = 500 * time.Millisecond
+	}
+
+	return &DBListener{
+		dsn:       dsn,
+		channel:   channel,
+		refresher: refresher,
+		debounce:  debounce,
+		maxWait:   10 * debounce,
+		logger:    logger,
+	}
+}
+
+// Start listens for notifications until ctx is cancelled, reconnecting on failure
+func (l *DBListener) Start(ctx context.Context) {
+	changes := make(chan DBChange, 256)
+	go l.debounceLoop(ctx, changes)
+	go l.listenLoop(ctx, changes)
+}
+
+// listenLoop keeps a LISTEN connection open, re-connecting with backoff
+func (l *DBListener) listenLoop(ctx context.Context, changes chan<- DBChange) {
+	backoff := time.Second
+	connected := false
+
+	for {
+		err := l.listen(ctx, changes, func() {
+			// Notifications sent while disconnected are lost, resync once the listener is back
+			if connected {
+				select {
+				case changes <- DBChange{}:
+				case <-ctx.Done():
+				}
+			}
+			connected = true
+			backoff = time.Second
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.logger.Warn("Config change listener disconnected",
+			zap.String("channel", l.channel),
+			zap.Duration("retry_in", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// listen runs a single LISTEN session, onListening is called once the channel is subscribed
+func (l *DBListener) listen(ctx context.Context, changes chan<- DBChange, onListening func()) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	l.logger.Info("Listening for config changes", zap.String("channel", l.channel))
+	onListening()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var change DBChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			l.logger.Warn("Invalid config change notification, resyncing",
+				zap.String("payload", notification.Payload),
+				zap.Error(err),
+			)
+			change = DBChange{}
+		}
+
+		select {
+		case changes <- change:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// debounceLoop batches changes until the channel is quiet for debounce (or maxWait elapsed) and applies them
+func (l *DBListener) debounceLoop(ctx context.Context, changes <-chan DBChange) {
+	batch := newChangeBatch()
+	var firstAt time.Time
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+
+		case change := <-changes:
+			if batch.empty() {
+				firstAt = time.Now()
+			}
+			batch.add(change)
+
+			wait := l.debounce
+			if remaining := l.maxWait - time.Since(firstAt); remaining < wait {
+				wait = remaining
+			}
+			timer.Reset(wait)
+
+		case <-timer.C:
+			l.apply(ctx, batch)
+			batch = newChangeBatch()
+		}
+	}
+}
+
+// apply refreshes the registry for a batch of changes
+func (l *DBListener) apply(ctx context.Context, batch *changeBatch) {
+	refreshCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if batch.full {
+		l.logger.Info("Config changes detected in database, refreshing all routes")
+		if err := l.refresher.Refresh(refreshCtx); err != nil {
+			l.logger.Warn("Failed to refresh routes after database change", zap.Error(err))
+		}
+		return
+	}
+
+	for _, route := range batch.routes {
+		l.logger.Info("Config change detected in database, refreshing route",
+			zap.String("path", route.path),
+			zap.String("method", route.method),
+		)
+		if err := l.refresher.RefreshSingle(refreshCtx, route.path, route.method); err != nil {
+			l.logger.Warn("Failed to refresh route after database change",
+				zap.String("path", route.path),
+				zap.String("method", route.method),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// routeRef identifies a path+method pair
+type routeRef struct {
+	path   string
+	method string
+}
+
+// changeBatch accumulates debounced changes
+// Route-level api_configs changes are refreshed one by one; anything else forces a full refresh
+type changeBatch struct {
+	full   bool
+	routes map[string]routeRef
+}
+
+// newChangeBatch creates an empty batch
+func newChangeBatch() *changeBatch {
+	return &changeBatch{routes: make(map[string]routeRef)}
+}
+
+// empty reports whether nothing was added yet
+func (b *changeBatch) empty() bool {
+	return !b.full && len(b.routes) == 0
+}
+
+// add records change, escalating to a full refresh when route-level refreshes don't suffice
+func (b *changeBatch) add(change DBChange) {
+	if b.full {
+		return
+	}
+
+	// url_configs changes affect every route using them; unknown payloads resync everything
+	if change.Table != "api_configs" {
+		b.full = true
+		b.routes = make(map[string]routeRef)
+		return
+	}
+
+	b.addRoute(change.Path, change.Method)
+	b.addRoute(change.OldPath, change.OldMethod)
+
+	if len(b.routes) > maxSingleRefreshes {
+		b.full = true
+		b.routes = make(map[string]routeRef)
+	}
+}
+
+// addRoute records path+method once
+func (b *changeBatch) addRoute(path, method string) {
+	if path == "" || method == "" {
+		return
+	}
+	b.routes[path+":"+method] = routeRef{path: path, method: method}
+}
@@ -0,0 +1,138 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChangeBatch_RouteChanges(t *testing.T) {
+	batch := newChangeBatch()
+	if !batch.empty() {
+		t.Fatal("Expected new batch to be empty")
+	}
+
+	batch.add(DBChange{Table: "api_configs", Op: "INSERT", Path: "/v1/users", Method: "GET"})
+	batch.add(DBChange{Table: "api_configs", Op: "UPDATE", Path: "/v1/users", Method: "GET", OldPath: "/v1/users", OldMethod: "GET"})
+	batch.add(DBChange{Table: "api_configs", Op: "UPDATE", Path: "/v2/users", Method: "GET", OldPath: "/v1/members", OldMethod: "GET"})
+	batch.add(DBChange{Table: "api_configs", Op: "DELETE", OldPath: "/v1/orders", OldMethod: "POST"})
+
+	if batch.full {
+		t.Fatal("Expected route-level batch, got full refresh")
+	}
+
+	expected := []string{"/v1/users:GET", "/v2/users:GET", "/v1/members:GET", "/v1/orders:POST"}
+	if len(batch.routes) != len(expected) {
+		t.Fatalf("Expected %d routes, got %d: %v", len(expected), len(batch.routes), batch.routes)
+	}
+	for _, key := range expected {
+		if _, ok := batch.routes[key]; !ok {
+			t.Errorf("Expected route %s in batch", key)
+		}
+	}
+}
+
+func TestChangeBatch_EscalatesToFullRefresh(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []DBChange
+	}{
+		{"url config change", []DBChange{{Table: "url_configs", Op: "UPDATE", ID: 1}}},
+		{"resync marker", []DBChange{{}}},
+		{"too many routes", func() []DBChange {
+			changes := make([]DBChange, 0, maxSingleRefreshes+1)
+			for i := 0; i <= maxSingleRefreshes; i++ {
+				changes = append(changes, DBChange{Table: "api_configs", Op: "INSERT", Path: "/r/" + string(rune('a'+i)), Method: "GET"})
+			}
+			return changes
+		}()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := newChangeBatch()
+			batch.add(DBChange{Table: "api_configs", Op: "INSERT", Path: "/v1/users", Method: "GET"})
+			for _, change := range tt.changes {
+				batch.add(change)
+			}
+
+			if !batch.full {
+				t.Error("Expected full refresh")
+			}
+			if len(batch.routes) != 0 {
+				t.Errorf("Expected no single routes once full, got %d", len(batch.routes))
+			}
+		})
+	}
+}
+
+func TestDBListener_DebouncesBursts(t *testing.T) {
+	source := &fakeSource{}
+	source.set(versioned(1, "/balance", ""), versioned(2, "/users", ""))
+	refresher := NewRefresher(NewRouteRegistry(setupTestLogger()), source, setupTestLogger())
+	listener := NewDBListener("", "changes", refresher, 20*time.Millisecond, setupTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan DBChange)
+	go listener.debounceLoop(ctx, changes)
+
+	// waitFor polls the source until it saw the expected loads and lookups, then checks nothing more comes
+	waitFor := func(loads, lookups int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			gotLoads, gotLookups := source.counts()
+			if gotLoads == loads && gotLookups == lookups {
+				break
+			}
+			if time.Now().After(deadline) || gotLoads > loads || gotLookups > lookups {
+				t.Fatalf("Expected %d loads and %d lookups, got %d and %d", loads, lookups, gotLoads, gotLookups)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if gotLoads, gotLookups := source.counts(); gotLoads != loads || gotLookups != lookups {
+			t.Fatalf("Expected no further refresh, got %d loads and %d lookups", gotLoads, gotLookups)
+		}
+	}
+
+	// A burst on one route is a single route refresh once quiet
+	for i := 0; i < 5; i++ {
+		changes <- DBChange{Table: "api_configs", Op: "UPDATE", Path: "/balance", Method: "GET", OldPath: "/balance", OldMethod: "GET"}
+	}
+	waitFor(0, 1)
+
+	// Two routes are refreshed one by one
+	changes <- DBChange{Table: "api_configs", Op: "INSERT", Path: "/balance", Method: "GET"}
+	changes <- DBChange{Table: "api_configs", Op: "DELETE", OldPath: "/users", OldMethod: "GET"}
+	waitFor(0, 3)
+
+	// A URL config change in the burst turns it into one full refresh
+	changes <- DBChange{Table: "api_configs", Op: "INSERT", Path: "/balance", Method: "GET"}
+	changes <- DBChange{Table: "url_configs", Op: "UPDATE", ID: 1}
+	waitFor(1, 3)
+}
+
+func TestDBListener_MaxWaitBoundsDebounce(t *testing.T) {
+	source := &fakeSource{}
+	source.set(versioned(1, "/balance", ""))
+	refresher := NewRefresher(NewRouteRegistry(setupTestLogger()), source, setupTestLogger())
+	listener := NewDBListener("", "changes", refresher, 20*time.Millisecond, setupTestLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan DBChange)
+	go listener.debounceLoop(ctx, changes)
+
+	// Changes keep coming faster than the quiet period for longer than maxWait (200ms)
+	stop := time.Now().Add(400 * time.Millisecond)
+	for time.Now().Before(stop) {
+		changes <- DBChange{Table: "api_configs", Op: "UPDATE", Path: "/balance", Method: "GET"}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, lookups := source.counts(); lookups == 0 {
+		t.Error("Expected the batch to be applied within maxWait despite a steady stream of changes")
+	}
+}
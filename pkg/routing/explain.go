@@ -0,0 +1,108 @@
+package routing
+
+import (
+	"errors"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+// matchTrace records trie walk decisions and candidate selection for one lookup
+// All methods are no-ops on a nil trace, so the hot path pays only a nil check
+type matchTrace struct {
+	steps      []dto.RouteTraceStep
+	candidates []dto.RouteCandidate
+}
+
+// step records a trie decision
+func (t *matchTrace) step(depth int, segment, pattern, kind, decision string) {
+	if t == nil {
+		return
+	}
+	t.steps = append(t.steps, dto.RouteTraceStep{
+		Depth:    depth,
+		Segment:  segment,
+		Pattern:  pattern,
+		Kind:     kind,
+		Decision: decision,
+	})
+}
+
+// candidate records a config considered during selection
+func (t *matchTrace) candidate(config *dto.APIConfigResponse, predicatesMatched, available bool) {
+	if t == nil {
+		return
+	}
+	t.candidates = append(t.candidates, dto.RouteCandidate{
+		ID:                config.ID,
+		Path:              config.Path,
+		Method:            config.Method,
+		Priority:          config.Priority,
+		PredicatesMatched: predicatesMatched,
+		Available:         available,
+	})
+}
+
+// selected marks the winning config
+func (t *matchTrace) selected(config *dto.APIConfigResponse) {
+	if t == nil || config == nil {
+		return
+	}
+	for i := range t.candidates {
+		c := &t.candidates[i]
+		if c.ID == config.ID && c.Path == config.Path && c.Method == config.Method && c.Priority == config.Priority {
+			c.Selected = true
+			return
+		}
+	}
+}
+
+// Explain runs MatchRequest with tracing enabled
+// Returns the traced attempt alongside the usual match results
+func (r *RouteRegistry) Explain(req *RouteRequest) (dto.RouteMatchAttempt, *dto.APIConfigResponse, map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trace := &matchTrace{}
+	attempt := dto.RouteMatchAttempt{Path: req.Path}
+
+	var matches []routeMatch
+	collectMatches(r.root, ParseURI(req.Path), 0, make(map[string]string), &matches, trace)
+	attempt.Methods = matchedMethods(matches)
+
+	var (
+		config *dto.APIConfigResponse
+		params map[string]string
+		err    error
+	)
+	if len(matches) == 0 {
+		err = ErrRouteNotFound
+	} else {
+		var methodFound, available bool
+		config, params, methodFound, available = selectConfig(matches, req, r.now(), trace)
+		switch {
+		case config != nil && !available:
+			err = ErrRouteUnavailable
+		case config != nil:
+		case methodFound:
+			err = ErrRouteNotFound
+		default:
+			err = ErrMethodNotAllowed
+		}
+	}
+
+	attempt.Steps = trace.steps
+	attempt.Candidates = trace.candidates
+	attempt.URIParams = params
+	switch {
+	case err == nil:
+		attempt.Result = "matched"
+	case errors.Is(err, ErrMethodNotAllowed):
+		attempt.Result = "method not allowed"
+	case errors.Is(err, ErrRouteUnavailable):
+		attempt.Result = "unavailable"
+	default:
+		attempt.Result = "route not found"
+	}
+
+	return attempt, config, params, err
+}
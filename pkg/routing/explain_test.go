@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+func TestRouteRegistry_Explain(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	users := newPriorityConfig("users", "/v1/users/{id:int}", "GET", 0)
+	users.ID = 1
+	fallback := newPriorityConfig("fallback", "/v1/{rest...}", "GET", 0)
+	fallback.ID = 2
+	for _, config := range []*dto.APIConfigResponse{users, fallback} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	t.Run("constrained param matched", func(t *testing.T) {
+		attempt, config, params, err := registry.Explain(&RouteRequest{Path: "/v1/users/42", Method: "GET"})
+		if err != nil {
+			t.Fatalf("Expected match, got %v", err)
+		}
+		if config.ID != 1 || params["id"] != "42" {
+			t.Errorf("Expected users route with id=42, got %d %v", config.ID, params)
+		}
+		if attempt.Result != "matched" || len(attempt.Steps) == 0 {
+			t.Errorf("Expected traced match, got %+v", attempt)
+		}
+		if len(attempt.Candidates) != 2 || !attempt.Candidates[0].Selected || attempt.Candidates[1].Selected {
+			t.Errorf("Expected users route selected over fallback, got %+v", attempt.Candidates)
+		}
+	})
+
+	t.Run("constraint rejection is traced", func(t *testing.T) {
+		attempt, config, _, err := registry.Explain(&RouteRequest{Path: "/v1/users/abc", Method: "GET"})
+		if err != nil || config.ID != 2 {
+			t.Fatalf("Expected fallback route, got %v %v", config, err)
+		}
+		rejected := false
+		for _, step := range attempt.Steps {
+			if step.Decision == "rejected by constraint" && step.Segment == "abc" {
+				rejected = true
+			}
+		}
+		if !rejected {
+			t.Errorf("Expected a constraint rejection step, got %+v", attempt.Steps)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		attempt, _, _, err := registry.Explain(&RouteRequest{Path: "/v1/users/42", Method: "DELETE"})
+		if !errors.Is(err, ErrMethodNotAllowed) || attempt.Result != "method not allowed" {
+			t.Errorf("Expected method not allowed, got %v %q", err, attempt.Result)
+		}
+	})
+
+	t.Run("route not found", func(t *testing.T) {
+		attempt, _, _, err := registry.Explain(&RouteRequest{Path: "/v2/users", Method: "GET"})
+		if !errors.Is(err, ErrRouteNotFound) || attempt.Result != "route not found" {
+			t.Errorf("Expected route not found, got %v %q", err, attempt.Result)
+		}
+	})
+}
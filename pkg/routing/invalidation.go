@@ -0,0 +1,295 @@
+package routing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/service"
+	"github.com/Payphone-Digital/gateway/pkg/redis"
+	"go.uber.org/zap"
+)
+
+// Change event types published on the invalidation bus
+const (
+	EventRouteChanged = "route_changed" // Path+method was created or updated
+	EventRouteRemoved = "route_removed" // Path+method no longer exists
+	EventFullRefresh  = "full_refresh"  // Reload every route (URL config changes)
+)
+
+// ChangeEvent describes a route change that every gateway instance must apply
+type ChangeEvent struct {
+	Type      string    `json:"type"`
+	Path      string    `json:"path,omitempty"`
+	Method    string    `json:"method,omitempty"`
+	OldPath   string    `json:"old_path,omitempty"`   // Previous path when an update moved the route
+	OldMethod string    `json:"old_method,omitempty"` // Previous method when an update moved the route
+	Origin    string    `json:"origin"`               // Instance that published the event
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// InvalidationBus propagates route and cache invalidations to every instance via Redis pub/sub
+// A periodic full reconcile repairs instances that missed events (restarts, Redis outages)
+type InvalidationBus struct {
+	redisClient       redis.Client
+	refresher         *Refresher
+	cacheService      *service.CacheService
+	channel           string
+	instanceID        string
+	reconcileInterval time.Duration
+	logger            *zap.Logger
+}
+
+// NewInvalidationBus creates a new invalidation bus
+// cacheService is optional; a zero reconcileInterval disables periodic reconciliation
+func NewInvalidationBus(
+	redisClient redis.Client,
+	refresher *Refresher,
+	cacheService *service.CacheService,
+	channel string,
+	reconcileInterval time.Duration,
+	logger *zap.Logger,
+) *InvalidationBus {
+	return &InvalidationBus{
+		redisClient:       redisClient,
+		refresher:         refresher,
+		cacheService:      cacheService,
+		channel:           channel,
+		instanceID:        newInstanceID(),
+		reconcileInterval: reconcileInterval,
+		logger:            logger,
+	}
+}
+
+// InstanceID returns the identifier stamped on events published by this instance
+func (b *InvalidationBus) InstanceID() string {
+	return b.instanceID
+}
+
+// Start subscribes to the bus and runs the reconcile loop until ctx is cancelled
+func (b *InvalidationBus) Start(ctx context.Context) {
+	if b.redisClient != nil && b.redisClient.IsEnabled() {
+		go b.subscribeLoop(ctx)
+	} else {
+		b.logger.Warn("Redis disabled, cluster-wide route invalidation is off; relying on periodic reconcile")
+	}
+
+	if b.reconcileInterval > 0 {
+		go b.reconcileLoop(ctx)
+	}
+}
+
+// PublishRouteChanged announces that path+method was created or updated
+// oldPath/oldMethod are the previous values when an update moved the route, empty otherwise
+func (b *InvalidationBus) PublishRouteChanged(ctx context.Context, path, method, oldPath, oldMethod string) error {
+	return b.publish(ctx, ChangeEvent{
+		Type:      EventRouteChanged,
+		Path:      path,
+		Method:    method,
+		OldPath:   oldPath,
+		OldMethod: oldMethod,
+	})
+}
+
+// PublishRouteRemoved announces that path+method was removed
+func (b *InvalidationBus) PublishRouteRemoved(ctx context.Context, path, method string) error {
+	return b.publish(ctx, ChangeEvent{Type: EventRouteRemoved, Path: path, Method: method})
+}
+
+// PublishFullRefresh asks every instance to reload all routes
+func (b *InvalidationBus) PublishFullRefresh(ctx context.Context) error {
+	return b.publish(ctx, ChangeEvent{Type: EventFullRefresh})
+}
+
+// publish stamps and sends event on the bus
+func (b *InvalidationBus) publish(ctx context.Context, event ChangeEvent) error {
+	if b.redisClient == nil || !b.redisClient.IsEnabled() {
+		return nil
+	}
+
+	event.Origin = b.instanceID
+	event.Timestamp = time.Now()
+
+	if err := b.redisClient.Publish(ctx, b.channel, event); err != nil {
+		b.logger.Warn("Failed to publish route change event",
+			zap.String("type", event.Type),
+			zap.String("path", event.Path),
+			zap.String("method", event.Method),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	b.logger.Debug("Route change event published",
+		zap.String("type", event.Type),
+		zap.String("path", event.Path),
+		zap.String("method", event.Method),
+	)
+	return nil
+}
+
+// subscribeLoop keeps a subscription open, re-subscribing with backoff when it drops
+func (b *InvalidationBus) subscribeLoop(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		messages, err := b.redisClient.Subscribe(ctx, b.channel)
+		if err != nil {
+			b.logger.Warn("Failed to subscribe to route change events",
+				zap.String("channel", b.channel),
+				zap.Duration("retry_in", backoff),
+				zap.Error(err),
+			)
+		} else {
+			backoff = time.Second
+			for payload := range messages {
+				b.handlePayload(ctx, payload)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+
+		// Events may have been missed while unsubscribed
+		b.reconcile(ctx)
+	}
+}
+
+// handlePayload decodes and applies a single event
+func (b *InvalidationBus) handlePayload(ctx context.Context, payload []byte) {
+	var event ChangeEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		b.logger.Warn("Invalid route change event", zap.ByteString("payload", payload), zap.Error(err))
+		return
+	}
+
+	// The publishing instance already applied the change locally
+	if event.Origin == b.instanceID {
+		return
+	}
+
+	applyCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	b.Apply(applyCtx, event)
+}
+
+// Apply updates the local registry and cache for event
+func (b *InvalidationBus) Apply(ctx context.Context, event ChangeEvent) {
+	b.logger.Info("Applying route change event",
+		zap.String("type", event.Type),
+		zap.String("path", event.Path),
+		zap.String("method", event.Method),
+		zap.String("origin", event.Origin),
+	)
+
+	switch event.Type {
+	case EventRouteChanged:
+		// The old path+method is reloaded rather than dropped, other configs may still share it
+		if event.OldPath != "" && (event.OldPath != event.Path || event.OldMethod != event.Method) {
+			b.refreshRoute(ctx, event.OldPath, event.OldMethod)
+			b.invalidateCache(ctx, event.OldPath)
+		}
+		b.refreshRoute(ctx, event.Path, event.Method)
+		b.invalidateCache(ctx, event.Path)
+
+	case EventRouteRemoved:
+		b.refreshRoute(ctx, event.Path, event.Method)
+		b.invalidateCache(ctx, event.Path)
+
+	case EventFullRefresh:
+		b.reconcile(ctx)
+
+	default:
+		b.logger.Warn("Unknown route change event type", zap.String("type", event.Type))
+	}
+}
+
+// refreshRoute reloads every config of path+method, removing the route once none is left
+func (b *InvalidationBus) refreshRoute(ctx context.Context, path, method string) {
+	if err := b.refresher.RefreshSingle(ctx, path, method); err != nil {
+		b.logger.Warn("Failed to refresh route from change event",
+			zap.String("path", path),
+			zap.String("method", method),
+			zap.Error(err),
+		)
+	}
+}
+
+// invalidateCache drops cached responses for path
+func (b *InvalidationBus) invalidateCache(ctx context.Context, path string) {
+	if b.cacheService == nil || path == "" {
+		return
+	}
+	if err := b.cacheService.InvalidateCache(ctx, path); err != nil {
+		b.logger.Warn("Failed to invalidate cache from change event",
+			zap.String("path", path),
+			zap.Error(err),
+		)
+	}
+}
+
+// reconcileLoop runs a full refresh every reconcileInterval
+func (b *InvalidationBus) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(b.reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile reloads every route from the database and drops the cached responses of routes that are gone
+func (b *InvalidationBus) reconcile(ctx context.Context) {
+	refreshCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	registry := b.refresher.Registry()
+	before := registry.snapshot()
+
+	if err := b.refresher.Refresh(refreshCtx); err != nil {
+		b.logger.Warn("Route reconcile failed", zap.Error(err))
+		return
+	}
+
+	remaining := make(map[string]bool)
+	for _, config := range registry.snapshot() {
+		remaining[config.Path] = true
+	}
+	for _, config := range before {
+		if !remaining[config.Path] {
+			// Several configs may share a removed path, its cache is dropped once
+			remaining[config.Path] = true
+			b.invalidateCache(refreshCtx, config.Path)
+		}
+	}
+	b.logger.Debug("Route reconcile completed")
+}
+
+// newInstanceID returns hostname plus a random suffix so restarted pods get a fresh identity
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "gateway"
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return host
+	}
+	return host + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,230 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/internal/service"
+	"github.com/Payphone-Digital/gateway/pkg/redis"
+)
+
+// fakeSource serves configs from memory in place of the database
+type fakeSource struct {
+	mu      sync.Mutex
+	configs []*dto.APIConfigResponse
+	loads   int // Full loads
+	lookups int // Single path+method lookups
+}
+
+func (s *fakeSource) set(configs ...*dto.APIConfigResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = configs
+}
+
+func (s *fakeSource) counts() (loads, lookups int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loads, s.lookups
+}
+
+func (s *fakeSource) LoadAllActiveConfigs(ctx context.Context) ([]*dto.APIConfigResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	return append([]*dto.APIConfigResponse(nil), s.configs...), nil
+}
+
+func (s *fakeSource) GetAllByPathAndMethodConfig(ctx context.Context, path, method string) ([]*dto.APIConfigResponse, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	var configs []*dto.APIConfigResponse
+	for _, config := range s.configs {
+		if config.Path == path && config.Method == method {
+			configs = append(configs, config)
+		}
+	}
+	if len(configs) == 0 {
+		return nil, http.StatusNotFound, errors.New("API config not found")
+	}
+	return configs, http.StatusOK, nil
+}
+
+// fakeRedis records published events and deleted cache patterns
+type fakeRedis struct {
+	mu        sync.Mutex
+	published []ChangeEvent
+	deleted   []string
+}
+
+var _ redis.Client = (*fakeRedis)(nil)
+
+func (r *fakeRedis) Get(ctx context.Context, key string, dest interface{}) error { return nil }
+func (r *fakeRedis) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return nil
+}
+func (r *fakeRedis) Delete(ctx context.Context, key string) error { return nil }
+func (r *fakeRedis) DeleteByPattern(ctx context.Context, pattern string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, pattern)
+	return nil
+}
+func (r *fakeRedis) Exists(ctx context.Context, key string) (bool, error) { return false, nil }
+func (r *fakeRedis) GetStats(ctx context.Context) (map[string]interface{}, error) {
+	return nil, nil
+}
+func (r *fakeRedis) FlushAll(ctx context.Context) error { return nil }
+func (r *fakeRedis) Ping(ctx context.Context) error     { return nil }
+func (r *fakeRedis) IsEnabled() bool                    { return true }
+func (r *fakeRedis) Close() error                       { return nil }
+func (r *fakeRedis) Publish(ctx context.Context, channel string, message interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, message.(ChangeEvent))
+	return nil
+}
+func (r *fakeRedis) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	return make(chan []byte), nil
+}
+func (r *fakeRedis) SetIntegrationResponse(ctx context.Context, key string, data []byte, status int, headers http.Header, ttl time.Duration) error {
+	return nil
+}
+func (r *fakeRedis) GetIntegrationResponse(ctx context.Context, key string) (*redis.CacheItem, error) {
+	return nil, nil
+}
+
+func (r *fakeRedis) deletedPatterns() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.deleted...)
+}
+
+// newTestBus returns a bus over a registry loaded with configs, the source serving the same configs
+func newTestBus(t *testing.T, configs ...*dto.APIConfigResponse) (*InvalidationBus, *fakeSource, *fakeRedis) {
+	t.Helper()
+
+	source := &fakeSource{}
+	source.set(configs...)
+	refresher := NewRefresher(NewRouteRegistry(setupTestLogger()), source, setupTestLogger())
+	if err := refresher.Refresh(context.Background()); err != nil {
+		t.Fatalf("Failed to load routes: %v", err)
+	}
+
+	client := &fakeRedis{}
+	bus := NewInvalidationBus(client, refresher, service.NewCacheService(client), "routes", 0, setupTestLogger())
+	return bus, source, client
+}
+
+func versioned(id uint, path, version string) *dto.APIConfigResponse {
+	config := createTestConfig("v"+version, path, http.MethodGet)
+	config.ID = id
+	if version != "" {
+		config.MatchHeaders = map[string]string{"X-API-Version": version}
+	}
+	return config
+}
+
+func matchVersion(t *testing.T, registry *RouteRegistry, path, version string) (*dto.APIConfigResponse, error) {
+	t.Helper()
+	headers := http.Header{}
+	if version != "" {
+		headers.Set("X-API-Version", version)
+	}
+	config, _, err := registry.MatchRequest(&RouteRequest{Path: path, Method: http.MethodGet, Headers: headers})
+	return config, err
+}
+
+func TestInvalidationBus_RouteRemovedKeepsSiblings(t *testing.T) {
+	plain, v2 := versioned(1, "/balance", ""), versioned(2, "/balance", "2")
+	users := versioned(3, "/users", "")
+	bus, source, client := newTestBus(t, plain, v2, users)
+
+	// The v2 config is deleted, the plain one still serves the path
+	source.set(plain, users)
+	bus.Apply(context.Background(), ChangeEvent{Type: EventRouteRemoved, Path: "/balance", Method: http.MethodGet})
+
+	registry := bus.refresher.Registry()
+	if config, err := matchVersion(t, registry, "/balance", "2"); err != nil || config.ID != 1 {
+		t.Errorf("Expected the plain config to serve v2 requests now, got %v %v", config, err)
+	}
+	if got := client.deletedPatterns(); len(got) != 1 || got[0] != "integration:/balance:*" {
+		t.Errorf("Expected the path's cached responses to be dropped, got %v", got)
+	}
+
+	// Once the last config is deleted the route is gone
+	source.set(users)
+	bus.Apply(context.Background(), ChangeEvent{Type: EventRouteRemoved, Path: "/balance", Method: http.MethodGet})
+	if _, err := matchVersion(t, registry, "/balance", ""); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected the route to be removed, got %v", err)
+	}
+}
+
+func TestInvalidationBus_RouteMovedKeepsSiblingsOfOldPath(t *testing.T) {
+	plain, v2 := versioned(1, "/balance", ""), versioned(2, "/balance", "2")
+	bus, source, client := newTestBus(t, plain, v2)
+
+	moved := versioned(2, "/v2/balance", "2")
+	source.set(plain, moved)
+	bus.Apply(context.Background(), ChangeEvent{
+		Type: EventRouteChanged, Path: "/v2/balance", Method: http.MethodGet, OldPath: "/balance", OldMethod: http.MethodGet,
+	})
+
+	registry := bus.refresher.Registry()
+	if config, err := matchVersion(t, registry, "/balance", ""); err != nil || config.ID != 1 {
+		t.Errorf("Expected the old path to keep its other config, got %v %v", config, err)
+	}
+	if config, err := matchVersion(t, registry, "/v2/balance", "2"); err != nil || config.ID != 2 {
+		t.Errorf("Expected the moved config on its new path, got %v %v", config, err)
+	}
+	if got := client.deletedPatterns(); len(got) != 2 {
+		t.Errorf("Expected the cache of both paths to be dropped, got %v", got)
+	}
+}
+
+func TestInvalidationBus_FullRefreshDropsCacheOfRemovedRoutes(t *testing.T) {
+	bus, source, client := newTestBus(t, versioned(1, "/balance", ""), versioned(2, "/balance", "2"), versioned(3, "/users", ""))
+
+	source.set(versioned(3, "/users", ""))
+	bus.Apply(context.Background(), ChangeEvent{Type: EventFullRefresh})
+
+	if _, err := matchVersion(t, bus.refresher.Registry(), "/balance", ""); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected the removed route to be gone, got %v", err)
+	}
+	if got := client.deletedPatterns(); len(got) != 1 || got[0] != "integration:/balance:*" {
+		t.Errorf("Expected only the removed route's cache to be dropped once, got %v", got)
+	}
+}
+
+func TestInvalidationBus_IgnoresOwnEvents(t *testing.T) {
+	bus, source, client := newTestBus(t, versioned(1, "/balance", ""), versioned(2, "/users", ""))
+
+	if err := bus.PublishRouteRemoved(context.Background(), "/balance", http.MethodGet); err != nil {
+		t.Fatalf("Failed to publish: %v", err)
+	}
+	if len(client.published) != 1 || client.published[0].Origin != bus.InstanceID() || client.published[0].Type != EventRouteRemoved {
+		t.Fatalf("Expected a route_removed event stamped with this instance, got %+v", client.published)
+	}
+
+	source.set(versioned(2, "/users", ""))
+	own, _ := json.Marshal(client.published[0])
+	bus.handlePayload(context.Background(), own)
+	if _, err := matchVersion(t, bus.refresher.Registry(), "/balance", ""); err != nil {
+		t.Errorf("Expected the publishing instance's own event to be skipped, got %v", err)
+	}
+
+	other := client.published[0]
+	other.Origin = "other-instance"
+	payload, _ := json.Marshal(other)
+	bus.handlePayload(context.Background(), payload)
+	if _, err := matchVersion(t, bus.refresher.Registry(), "/balance", ""); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected another instance's event to be applied, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package routing
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"github.com/Payphone-Digital/gateway/pkg/schedule"
+)
+
+// routeMatch is a configured trie node reached by a request path with the params extracted on the way
+type routeMatch struct {
+	node   *TrieNode
+	params map[string]string
+}
+
+// collectMatches walks the trie depth-first and records every configured node reachable by segments
+// Alternatives are explored in specificity order: static > constrained parameter > parameter > wildcard > catch-all,
+// so a failing static branch falls back to its parameter and wildcard siblings
+// trace is optional and records every decision for the explain endpoint
+func collectMatches(node *TrieNode, segments []string, idx int, params map[string]string, matches *[]routeMatch, trace *matchTrace) {
+	if idx == len(segments) {
+		if len(node.configs) > 0 {
+			*matches = append(*matches, routeMatch{node: node, params: copyParams(params)})
+			trace.step(idx, "", node.segment, "end", "route found")
+		} else {
+			trace.step(idx, "", node.segment, "end", "no route configured at this node")
+		}
+		// A catch-all also matches an empty remainder
+		collectCatchAll(node, segments, idx, params, matches, trace)
+		return
+	}
+
+	segment := segments[idx]
+
+	// 1. Static child
+	if child, exists := node.children[segment]; exists {
+		trace.step(idx, segment, child.segment, "static", "matched")
+		collectMatches(child, segments, idx+1, params, matches, trace)
+	} else if len(node.children) > 0 {
+		trace.step(idx, segment, "", "static", "no static child")
+	}
+
+	// 2. Parameter children whose constraint accepts the segment
+	for _, child := range node.paramChildren {
+		if !child.MatchesParam(segment) {
+			trace.step(idx, segment, child.segment, "param", "rejected by constraint")
+			continue
+		}
+		trace.step(idx, segment, child.segment, "param", "matched")
+		restore := setParam(params, child.paramName, segment)
+		collectMatches(child, segments, idx+1, params, matches, trace)
+		restore()
+	}
+
+	// 3. Wildcard child
+	if node.wildcardChild != nil {
+		trace.step(idx, segment, "*", "wildcard", "matched")
+		restore := setParam(params, "wildcard", segment)
+		collectMatches(node.wildcardChild, segments, idx+1, params, matches, trace)
+		restore()
+	}
+
+	// 4. Catch-all child takes every remaining segment
+	collectCatchAll(node, segments, idx, params, matches, trace)
+}
+
+// collectCatchAll records node's catch-all child with the remaining segments joined as its param
+func collectCatchAll(node *TrieNode, segments []string, idx int, params map[string]string, matches *[]routeMatch, trace *matchTrace) {
+	child := node.catchAllChild
+	if child == nil || len(child.configs) == 0 {
+		return
+	}
+
+	rest := strings.Join(segments[idx:], "/")
+	trace.step(idx, rest, child.segment, "catch-all", "matched remaining path")
+	restore := setParam(params, child.paramName, rest)
+	*matches = append(*matches, routeMatch{node: child, params: copyParams(params)})
+	restore()
+}
+
+// selectConfig picks the config serving req among matches
+// Only configs whose match predicates accept req are candidates
+// Higher APIConfig.Priority wins; on a tie the more specific (earlier) match wins,
+// and within a node the exact method beats HEAD-from-GET and ANY, then the config with more predicates wins
+// Configs unavailable at now (schedule) lose to any available one; available is false when only those match
+// methodFound reports whether any match serves req.Method regardless of predicates
+func selectConfig(matches []routeMatch, req *RouteRequest, now time.Time, trace *matchTrace) (*dto.APIConfigResponse, map[string]string, bool, bool) {
+	var best, bestUnavailable *dto.APIConfigResponse
+	var bestParams, bestUnavailableParams map[string]string
+	methodFound := false
+
+	methods := methodCandidates(req.Method)
+	for _, m := range matches {
+		// Within a node the exact method is tried before derived ones (HEAD from GET) and ANY
+		for _, method := range methods {
+			for _, config := range m.node.configs[method] {
+				methodFound = true
+				predicatesMatched := matchesPredicates(config, req)
+				available := Availability(config, now).Available
+				trace.candidate(config, predicatesMatched, available)
+				if !predicatesMatched {
+					continue
+				}
+				if !available {
+					if bestUnavailable == nil || config.Priority > bestUnavailable.Priority {
+						bestUnavailable = config
+						bestUnavailableParams = m.params
+					}
+					continue
+				}
+				if best == nil || config.Priority > best.Priority {
+					best = config
+					bestParams = m.params
+				}
+			}
+		}
+	}
+
+	if best == nil && bestUnavailable != nil {
+		trace.selected(bestUnavailable)
+		return bestUnavailable, bestUnavailableParams, methodFound, false
+	}
+
+	trace.selected(best)
+	return best, bestParams, methodFound, true
+}
+
+// Availability reports whether config is served at now according to its active window and maintenance schedule
+func Availability(config *dto.APIConfigResponse, now time.Time) schedule.Status {
+	if config.ActiveFrom == nil && config.ActiveUntil == nil && len(config.MaintenanceSchedule) == 0 {
+		return schedule.Status{Available: true}
+	}
+	return schedule.Check(config.ActiveFrom, config.ActiveUntil, config.MaintenanceSchedule, now)
+}
+
+// matchedMethods returns the sorted union of methods configured on matches
+func matchedMethods(matches []routeMatch) []string {
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		for method := range m.node.methods {
+			seen[method] = true
+		}
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// setParam sets params[name] and returns a func that restores the previous state
+func setParam(params map[string]string, name, value string) func() {
+	previous, hadPrevious := params[name]
+	params[name] = value
+	return func() {
+		if hadPrevious {
+			params[name] = previous
+		} else {
+			delete(params, name)
+		}
+	}
+}
+
+// copyParams returns a shallow copy of params
+func copyParams(params map[string]string) map[string]string {
+	copied := make(map[string]string, len(params))
+	for k, v := range params {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,76 @@
+package routing
+
+import (
+	"net/http"
+	"sort"
+)
+
+// MethodAny is a config method that serves every HTTP method except OPTIONS,
+// which stays with the automatic Allow response (or an explicit OPTIONS config)
+const MethodAny = "ANY"
+
+// standardMethods are the methods an ANY config advertises in Allow
+var standardMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// methodCandidates returns the config methods able to serve method, most specific first
+// HEAD is derived from GET: HEAD requests matching a GET config are sent upstream as HEAD, answered without a body
+func methodCandidates(method string) []string {
+	switch method {
+	case http.MethodHead:
+		return []string{http.MethodHead, http.MethodGet, MethodAny}
+	case http.MethodOptions:
+		return []string{http.MethodOptions}
+	default:
+		return []string{method, MethodAny}
+	}
+}
+
+// AllowedMethods returns the methods a request path can be served with, for the Allow header
+// The path is tried as is and then with each prefix rewrite rule, like Resolve
+// Returns nil when no route matches the path
+func (r *RouteRegistry) AllowedMethods(path string) []string {
+	for _, candidate := range r.Rewrites(path) {
+		r.mu.RLock()
+		var matches []routeMatch
+		collectMatches(r.root, ParseURI(candidate), 0, make(map[string]string), &matches, nil)
+		r.mu.RUnlock()
+
+		if len(matches) > 0 {
+			return allowMethods(matchedMethods(matches))
+		}
+	}
+	return nil
+}
+
+// allowMethods expands configured methods into the methods actually served:
+// GET implies HEAD, ANY implies every standard method, OPTIONS is always answered
+func allowMethods(configured []string) []string {
+	seen := map[string]bool{http.MethodOptions: true}
+	for _, method := range configured {
+		switch method {
+		case MethodAny:
+			for _, standard := range standardMethods {
+				seen[standard] = true
+			}
+		case http.MethodGet:
+			seen[http.MethodGet] = true
+			seen[http.MethodHead] = true
+		default:
+			seen[method] = true
+		}
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+func TestRouteRegistry_MatchDerivedMethods(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	getItem := createTestConfig("get-item", "/v1/items/{id}", "GET")
+	deleteItem := createTestConfig("delete-item", "/v1/items/{id}", "DELETE")
+	anyItem := createTestConfig("any-item", "/v1/items/{id}", MethodAny)
+	proxy := createTestConfig("proxy", "/v1/proxy/{rest...}", MethodAny)
+	for _, config := range []*dto.APIConfigResponse{getItem, deleteItem, anyItem, proxy} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		method   string
+		expected *dto.APIConfigResponse
+		err      error
+	}{
+		{"exact method beats ANY", "/v1/items/1", "GET", getItem, nil},
+		{"exact method beats ANY for DELETE", "/v1/items/1", "DELETE", deleteItem, nil},
+		{"HEAD derived from GET", "/v1/items/1", "HEAD", getItem, nil},
+		{"ANY serves other methods", "/v1/items/1", "PATCH", anyItem, nil},
+		{"ANY serves HEAD without GET", "/v1/proxy/a/b", "HEAD", proxy, nil},
+		{"ANY does not serve OPTIONS", "/v1/proxy/a/b", "OPTIONS", nil, ErrMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _, err := registry.Match(tt.path, tt.method)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if config != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, config)
+			}
+		})
+	}
+}
+
+func TestRouteRegistry_AllowedMethods(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	for _, config := range []*dto.APIConfigResponse{
+		createTestConfig("get-user", "/v1/users/{id}", "GET"),
+		createTestConfig("put-user", "/v1/users/{id}", "PUT"),
+		createTestConfig("proxy", "/v1/proxy/{rest...}", MethodAny),
+	} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"/v1/users/1", []string{"GET", "HEAD", "OPTIONS", "PUT"}},
+		{"/api/v1/users/1", []string{"GET", "HEAD", "OPTIONS", "PUT"}},
+		{"/v1/proxy/x", []string{"DELETE", "GET", "HEAD", "OPTIONS", "PATCH", "POST", "PUT"}},
+		{"/v1/unknown", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if methods := registry.AllowedMethods(tt.path); !reflect.DeepEqual(methods, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, methods)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package routing
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+// RouteRequest carries the parts of an incoming request used for route matching
+type RouteRequest struct {
+	Path    string
+	Method  string
+	Host    string      // Request host, may include a port
+	Headers http.Header // Request headers
+	Query   url.Values  // Parsed query string
+}
+
+// hasPredicates reports whether config narrows its path+method by host, header or query
+func hasPredicates(config *dto.APIConfigResponse) bool {
+	return predicateCount(config) > 0
+}
+
+// predicateCount returns how many predicates config declares, more predicates = more specific
+func predicateCount(config *dto.APIConfigResponse) int {
+	count := len(config.MatchHeaders) + len(config.MatchQuery)
+	if config.MatchHost != "" {
+		count++
+	}
+	return count
+}
+
+// predicateKey builds a stable description of config's predicates for route keys
+// Example: host=api.partner.com;header:X-Api-Version=2;query:channel=mobile
+func predicateKey(config *dto.APIConfigResponse) string {
+	parts := make([]string, 0, predicateCount(config))
+	if config.MatchHost != "" {
+		parts = append(parts, "host="+strings.ToLower(config.MatchHost))
+	}
+
+	headers := make([]string, 0, len(config.MatchHeaders))
+	for name, value := range config.MatchHeaders {
+		headers = append(headers, "header:"+http.CanonicalHeaderKey(name)+"="+value)
+	}
+	sort.Strings(headers)
+
+	query := make([]string, 0, len(config.MatchQuery))
+	for name, value := range config.MatchQuery {
+		query = append(query, "query:"+name+"="+value)
+	}
+	sort.Strings(query)
+
+	parts = append(parts, headers...)
+	parts = append(parts, query...)
+	return strings.Join(parts, ";")
+}
+
+// matchesPredicates reports whether req satisfies every predicate declared on config
+func matchesPredicates(config *dto.APIConfigResponse, req *RouteRequest) bool {
+	if config.MatchHost != "" && !matchHost(config.MatchHost, req.Host) {
+		return false
+	}
+
+	for name, expected := range config.MatchHeaders {
+		if !matchValues(req.Headers.Values(name), expected) {
+			return false
+		}
+	}
+
+	for name, expected := range config.MatchQuery {
+		if !matchValues(req.Query[name], expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchHost compares a request host against an exact host or a "*.example.com" suffix pattern
+// The port is ignored and the comparison is case-insensitive
+func matchHost(pattern, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+
+	return host == pattern
+}
+
+// matchValues reports whether any value equals expected
+// An empty or "*" expected value only requires the header/param to be present
+func matchValues(values []string, expected string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	if expected == "" || expected == "*" {
+		return true
+	}
+
+	for _, value := range values {
+		if strings.TrimSpace(value) == expected {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		host     string
+		expected bool
+	}{
+		{"api.partner.com", "api.partner.com", true},
+		{"api.partner.com", "API.Partner.com:8443", true},
+		{"api.partner.com", "other.partner.com", false},
+		{"*.partner.com", "bca.partner.com", true},
+		{"*.partner.com", "a.b.partner.com", true},
+		{"*.partner.com", "partner.com", false},
+		{"*.partner.com", "evilpartner.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchHost(tt.pattern, tt.host); got != tt.expected {
+			t.Errorf("matchHost(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestRouteRegistry_MatchRequestPredicates(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	fallback := createTestConfig("fallback", "/v1/balance", "GET")
+
+	v2 := createTestConfig("v2", "/v1/balance", "GET")
+	v2.MatchHeaders = map[string]string{"X-API-Version": "2"}
+
+	partner := createTestConfig("partner", "/v1/balance", "GET")
+	partner.MatchHost = "*.partner.com"
+
+	partnerMobile := createTestConfig("partner-mobile", "/v1/balance", "GET")
+	partnerMobile.MatchHost = "*.partner.com"
+	partnerMobile.MatchQuery = map[string]string{"channel": "mobile"}
+
+	for _, config := range []*dto.APIConfigResponse{fallback, v2, partner, partnerMobile} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route %s: %v", config.Description, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		host     string
+		headers  http.Header
+		query    url.Values
+		expected string
+	}{
+		{"no predicates", "gateway.local", nil, nil, "fallback"},
+		{"header version", "gateway.local", http.Header{"X-Api-Version": {"2"}}, nil, "v2"},
+		{"other header version", "gateway.local", http.Header{"X-Api-Version": {"1"}}, nil, "fallback"},
+		{"partner host", "bca.partner.com", nil, nil, "partner"},
+		{"partner host with query", "bca.partner.com:443", nil, url.Values{"channel": {"mobile"}}, "partner-mobile"},
+		{"partner host other query", "bca.partner.com", nil, url.Values{"channel": {"web"}}, "partner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _, err := registry.MatchRequest(&RouteRequest{
+				Path:    "/v1/balance",
+				Method:  "GET",
+				Host:    tt.host,
+				Headers: tt.headers,
+				Query:   tt.query,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if config.Description != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, config.Description)
+			}
+		})
+	}
+
+	// Identical predicates on the same path+method are duplicates
+	duplicate := createTestConfig("duplicate", "/v1/balance", "GET")
+	duplicate.MatchHeaders = map[string]string{"x-api-version": "2"}
+	if err := registry.AddRoute(duplicate); !errors.Is(err, ErrRouteAlreadyExists) {
+		t.Errorf("Expected ErrRouteAlreadyExists, got %v", err)
+	}
+
+	// RemoveRoute drops every predicate variant
+	if err := registry.RemoveRoute("/v1/balance", "GET"); err != nil {
+		t.Fatalf("Failed to remove route: %v", err)
+	}
+	if registry.Count() != 0 {
+		t.Errorf("Expected count 0 after removal, got %d", registry.Count())
+	}
+}
+
+func TestRouteRegistry_MatchRequestPredicatesNotSatisfied(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	internal := createTestConfig("internal", "/v1/reports", "GET")
+	internal.MatchHeaders = map[string]string{"X-Internal": ""}
+	if err := registry.AddRoute(internal); err != nil {
+		t.Fatalf("Failed to add route: %v", err)
+	}
+
+	// Predicates fail: the route is treated as absent
+	_, _, err := registry.MatchRequest(&RouteRequest{Path: "/v1/reports", Method: "GET"})
+	if !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected ErrRouteNotFound, got %v", err)
+	}
+
+	// Presence-only predicate
+	config, _, err := registry.MatchRequest(&RouteRequest{
+		Path:    "/v1/reports",
+		Method:  "GET",
+		Headers: http.Header{"X-Internal": {"yes"}},
+	})
+	if err != nil || config.Description != "internal" {
+		t.Errorf("Expected internal route, got %v (%v)", config, err)
+	}
+
+	// Method mismatch still reports method not allowed
+	_, _, err = registry.MatchRequest(&RouteRequest{Path: "/v1/reports", Method: "POST"})
+	if !errors.Is(err, ErrMethodNotAllowed) {
+		t.Errorf("Expected ErrMethodNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+// DefaultReservedPrefixes are served by the gateway itself and never looked up in the registry
+var DefaultReservedPrefixes = []string{"/api/v1/", "/api/health", "/health"}
+
+// DefaultPrefixRules keeps /api/cek/object reaching a config registered as /cek/object
+var DefaultPrefixRules = []PrefixRule{{Strip: "/api"}}
+
+// PrefixRule rewrites the start of a request path before it is matched again
+// Strip only: "/api/x" -> "/x"; Add only: "/x" -> "/v1/x"; both: "/old/x" -> "/new/x"
+type PrefixRule struct {
+	Strip string `json:"strip,omitempty"`
+	Add   string `json:"add,omitempty"`
+}
+
+// Apply rewrites path, returning false when the rule does not apply
+func (p PrefixRule) Apply(path string) (string, bool) {
+	rest := path
+	if p.Strip != "" {
+		if !hasPathPrefix(path, p.Strip) {
+			return path, false
+		}
+		rest = strings.TrimPrefix(path, p.Strip)
+	}
+	if p.Add != "" && hasPathPrefix(rest, p.Add) && p.Strip == "" {
+		// Already carries the prefix, adding it again would never match
+		return path, false
+	}
+
+	rewritten := joinPath(p.Add, rest)
+	if rewritten == path {
+		return path, false
+	}
+	return rewritten, true
+}
+
+// String formats the rule the way ParsePrefixRules reads it
+func (p PrefixRule) String() string {
+	return p.Strip + "=>" + p.Add
+}
+
+// ParsePrefixRules parses a comma separated list of "strip=>add" rules
+// Example: "/api=>,=>/v1,/legacy=>/v2" strips /api, adds /v1 and renames /legacy to /v2
+func ParsePrefixRules(spec string) ([]PrefixRule, error) {
+	rules := make([]PrefixRule, 0)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		strip, add, found := strings.Cut(entry, "=>")
+		if !found {
+			return nil, fmt.Errorf("invalid prefix rule %q: expected strip=>add", entry)
+		}
+
+		rule := PrefixRule{Strip: NormalizeBasePath(strip), Add: NormalizeBasePath(add)}
+		if rule.Strip == "" && rule.Add == "" {
+			return nil, fmt.Errorf("invalid prefix rule %q: strip or add prefix is required", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// PathRules control which request paths the registry handles and how they are rewritten
+type PathRules struct {
+	Reserved []string     // Raw path prefixes left to the gateway's own routes
+	Rewrites []PrefixRule // Tried in order when the original path does not match
+}
+
+// SetPathRules replaces the reserved prefixes and rewrite rules, they survive Swap
+func (r *RouteRegistry) SetPathRules(rules PathRules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pathRules = rules
+}
+
+// IsReserved reports whether path belongs to the gateway's own routes
+func (r *RouteRegistry) IsReserved(path string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, prefix := range r.pathRules.Reserved {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rewrites returns the paths to try for a request path, the original first
+// followed by the result of every rewrite rule that applies, without duplicates
+func (r *RouteRegistry) Rewrites(path string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	paths := []string{path}
+	for _, rule := range r.pathRules.Rewrites {
+		rewritten, ok := rule.Apply(path)
+		if !ok || containsString(paths, rewritten) {
+			continue
+		}
+		paths = append(paths, rewritten)
+	}
+	return paths
+}
+
+// Resolve matches req against its original path, then each rewritten path
+// Returns the path that matched; an unavailable route (ErrRouteUnavailable) is returned with its config
+// unless a later path matches an available one; otherwise the error of the original path
+func (r *RouteRegistry) Resolve(req *RouteRequest) (*dto.APIConfigResponse, map[string]string, string, error) {
+	config, params, err := r.MatchRequest(req)
+	if err == nil {
+		return config, params, req.Path, nil
+	}
+
+	resolvedConfig, resolvedParams, resolvedPath, resolvedErr := config, params, req.Path, err
+	for _, path := range r.Rewrites(req.Path)[1:] {
+		rewritten := *req
+		rewritten.Path = path
+		rewrittenConfig, rewrittenParams, rewrittenErr := r.MatchRequest(&rewritten)
+		if rewrittenErr == nil {
+			return rewrittenConfig, rewrittenParams, path, nil
+		}
+		if errors.Is(rewrittenErr, ErrRouteUnavailable) && !errors.Is(resolvedErr, ErrRouteUnavailable) {
+			resolvedConfig, resolvedParams, resolvedPath, resolvedErr = rewrittenConfig, rewrittenParams, path, rewrittenErr
+		}
+	}
+
+	if !errors.Is(resolvedErr, ErrRouteUnavailable) {
+		return nil, nil, req.Path, resolvedErr
+	}
+	return resolvedConfig, resolvedParams, resolvedPath, resolvedErr
+}
+
+// MountPath returns the public path config is served on: its URL config base path plus its path
+// Example: base path "/pay" and path "/charge/{id}" -> "/pay/charge/{id}"
+func MountPath(config *dto.APIConfigResponse) string {
+	return joinPath(NormalizeBasePath(config.URLConfig.BasePath), config.Path)
+}
+
+// NormalizeBasePath returns prefix with a leading slash and no trailing slash, "" for the root
+func NormalizeBasePath(prefix string) string {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// joinPath prefixes path with a normalized prefix
+func joinPath(prefix, path string) string {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if prefix == "" {
+		return path
+	}
+	if path == "/" {
+		return prefix
+	}
+	return prefix + path
+}
+
+// hasPathPrefix reports whether path starts with prefix on a segment boundary
+// "/api/x" and "/api" have prefix "/api", "/apix" does not
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+func TestPrefixRule_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     PrefixRule
+		path     string
+		expected string
+		applied  bool
+	}{
+		{"strip", PrefixRule{Strip: "/api"}, "/api/cek/object", "/cek/object", true},
+		{"strip exact", PrefixRule{Strip: "/api"}, "/api", "/", true},
+		{"strip segment boundary", PrefixRule{Strip: "/api"}, "/apiv1/users", "/apiv1/users", false},
+		{"strip missing", PrefixRule{Strip: "/api"}, "/v1/users", "/v1/users", false},
+		{"add", PrefixRule{Add: "/v1"}, "/users", "/v1/users", true},
+		{"add already present", PrefixRule{Add: "/v1"}, "/v1/users", "/v1/users", false},
+		{"replace", PrefixRule{Strip: "/legacy", Add: "/v2"}, "/legacy/orders", "/v2/orders", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, applied := tt.rule.Apply(tt.path)
+			if path != tt.expected || applied != tt.applied {
+				t.Errorf("Apply(%q) = (%q, %v), expected (%q, %v)", tt.path, path, applied, tt.expected, tt.applied)
+			}
+		})
+	}
+}
+
+func TestParsePrefixRules(t *testing.T) {
+	rules, err := ParsePrefixRules(" /api=> , =>v1/ ,/legacy=>/v2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []PrefixRule{{Strip: "/api"}, {Add: "/v1"}, {Strip: "/legacy", Add: "/v2"}}
+	if len(rules) != len(expected) {
+		t.Fatalf("Expected %d rules, got %v", len(expected), rules)
+	}
+	for i := range expected {
+		if rules[i] != expected[i] {
+			t.Errorf("Rule %d: expected %v, got %v", i, expected[i], rules[i])
+		}
+	}
+
+	for _, spec := range []string{"/api", "=>", "/api=>,/"} {
+		if _, err := ParsePrefixRules(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestRouteRegistry_ResolveRewrites(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+	registry.SetPathRules(PathRules{
+		Reserved: []string{"/health"},
+		Rewrites: []PrefixRule{{Strip: "/api"}, {Add: "/v1"}},
+	})
+
+	for _, config := range []*dto.APIConfigResponse{
+		createTestConfig("object", "/cek/object", "GET"),
+		createTestConfig("users", "/v1/users/{id}", "GET"),
+		createTestConfig("api-native", "/api/native", "GET"),
+	} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	tests := []struct {
+		path        string
+		slug        string
+		matchedPath string
+	}{
+		{"/cek/object", "/cek/object", "/cek/object"},
+		{"/api/cek/object", "/cek/object", "/cek/object"},
+		{"/users/7", "/v1/users/{id}", "/v1/users/7"},
+		{"/api/native", "/api/native", "/api/native"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			config, _, matchedPath, err := registry.Resolve(&RouteRequest{Path: tt.path, Method: "GET"})
+			if err != nil {
+				t.Fatalf("Expected match, got %v", err)
+			}
+			if config.Path != tt.slug || matchedPath != tt.matchedPath {
+				t.Errorf("Expected %s via %s, got %s via %s", tt.slug, tt.matchedPath, config.Path, matchedPath)
+			}
+		})
+	}
+
+	if _, _, _, err := registry.Resolve(&RouteRequest{Path: "/cek/object", Method: "POST"}); !errors.Is(err, ErrMethodNotAllowed) {
+		t.Errorf("Expected the original path's error, got %v", err)
+	}
+	if !registry.IsReserved("/health/live") || registry.IsReserved("/api/v1/users") {
+		t.Error("Reserved prefixes not replaced by SetPathRules")
+	}
+}
+
+func TestRouteRegistry_BasePath(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+
+	pay := createTestConfig("pay", "/charge/{id}", "POST")
+	pay.URLConfig.BasePath = "/pay"
+	shop := createTestConfig("shop", "/charge/{id}", "POST")
+	shop.URLConfig.BasePath = "shop/"
+	for _, config := range []*dto.APIConfigResponse{pay, shop} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	config, params, err := registry.Match("/pay/charge/9", "POST")
+	if err != nil || config != pay || params["id"] != "9" {
+		t.Errorf("Expected pay route with id=9, got %v %v %v", config, params, err)
+	}
+	if config, _, err := registry.Match("/shop/charge/9", "POST"); err != nil || config != shop {
+		t.Errorf("Expected shop route, got %v %v", config, err)
+	}
+	if _, _, err := registry.Match("/charge/9", "POST"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected route to be reachable only under its base path, got %v", err)
+	}
+
+	// Removing by path drops both mounts
+	if err := registry.RemoveRoute("/charge/{id}", "POST"); err != nil {
+		t.Fatalf("Failed to remove route: %v", err)
+	}
+	if _, _, err := registry.Match("/pay/charge/9", "POST"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("Expected removed route, got %v", err)
+	}
+	if registry.Count() != 0 {
+		t.Errorf("Expected empty registry, got %d routes", registry.Count())
+	}
+}
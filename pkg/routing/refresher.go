@@ -4,22 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 
-	"github.com/Payphone-Digital/gateway/internal/service"
+	"github.com/Payphone-Digital/gateway/internal/dto"
 	"go.uber.org/zap"
 )
 
+// ConfigSource loads the configs routes are built from, implemented by *service.APIConfigService
+type ConfigSource interface {
+	LoadAllActiveConfigs(ctx context.Context) ([]*dto.APIConfigResponse, error)
+	GetAllByPathAndMethodConfig(ctx context.Context, path, method string) ([]*dto.APIConfigResponse, int, error)
+}
+
 // Refresher handles dynamic route updates
 type Refresher struct {
 	registry          *RouteRegistry
-	apiConfigService  *service.APIConfigService
+	apiConfigService  ConfigSource
 	logger            *zap.Logger
 }
 
 // NewRefresher creates a new route refresher
 func NewRefresher(
 	registry *RouteRegistry,
-	apiConfigService *service.APIConfigService,
+	apiConfigService ConfigSource,
 	logger *zap.Logger,
 ) *Refresher {
 	return &Refresher{
@@ -29,6 +36,11 @@ func NewRefresher(
 	}
 }
 
+// Registry returns the registry kept up to date by this refresher
+func (r *Refresher) Registry() *RouteRegistry {
+	return r.registry
+}
+
 // Refresh reloads all routes from the database
 func (r *Refresher) Refresh(ctx context.Context) error {
 	r.logger.Info("Starting route registry refresh")
@@ -49,17 +61,17 @@ func (r *Refresher) Refresh(ctx context.Context) error {
 		zap.Int("count", len(configs)),
 	)
 
-	// Clear existing registry
-	r.registry.Clear()
+	// Build the new trie off to the side so lookups keep using the current one meanwhile
+	staged := NewRouteRegistry(r.logger)
 
-	// Add all configs
 	successCount := 0
 	errorCount := 0
 
 	for _, config := range configs {
-		if err := r.registry.AddRoute(config); err != nil {
-			r.logger.Error("Failed to add route to registry",
+		if err := staged.AddRoute(config); err != nil {
+			r.logger.Error("Failed to add route to staged registry",
 				zap.String("slug", config.Path),
+				zap.String("method", config.Method),
 				zap.Error(err),
 			)
 			errorCount++
@@ -68,16 +80,37 @@ func (r *Refresher) Refresh(ctx context.Context) error {
 		successCount++
 	}
 
-	r.logger.Info("Route registry refresh completed",
+	r.logger.Info("Staged route registry built",
 		zap.Int("total", len(configs)),
 		zap.Int("success", successCount),
 		zap.Int("errors", errorCount),
 	)
 
 	if errorCount > 0 {
+		// Keep the last good version; only bootstrap from a partial set when there is none yet
+		if r.registry.Generation() > 0 {
+			r.logger.Warn("Staged registry failed validation, keeping last good version",
+				zap.Int("errors", errorCount),
+				zap.Int("active_routes", r.registry.Count()),
+			)
+			return fmt.Errorf("refresh rejected with %d invalid routes, kept last good version", errorCount)
+		}
+
+		r.logger.Warn("No previous registry version, installing valid routes only",
+			zap.Int("errors", errorCount),
+		)
+		r.registry.Swap(staged)
 		return fmt.Errorf("refresh completed with %d errors", errorCount)
 	}
 
+	// Replace the live registry in one step
+	r.registry.Swap(staged)
+
+	r.logger.Info("Route registry refresh completed",
+		zap.Int("total", len(configs)),
+		zap.Uint64("generation", r.registry.Generation()),
+	)
+
 	return nil
 }
 
@@ -85,8 +118,17 @@ func (r *Refresher) Refresh(ctx context.Context) error {
 func (r *Refresher) RefreshSingle(ctx context.Context, path, method string) error {
 	r.logger.Info("Refreshing single route", zap.String("path", path), zap.String("method", method))
 
-	// Get config from database using path+method to find correct config
-	config, _, err := r.apiConfigService.GetByPathAndMethodConfig(ctx, path, method)
+	// Get every config sharing path+method, they differ only by match predicates
+	configs, status, err := r.apiConfigService.GetAllByPathAndMethodConfig(ctx, path, method)
+	if status == http.StatusNotFound {
+		// The route no longer exists (deleted or moved), drop whatever is still registered
+		_ = r.registry.RemoveRoute(path, method)
+		r.logger.Info("Route no longer configured, removed from registry",
+			zap.String("path", path),
+			zap.String("method", method),
+		)
+		return nil
+	}
 	if err != nil {
 		r.logger.Error("Failed to get config from database",
 			zap.String("path", path),
@@ -96,11 +138,8 @@ func (r *Refresher) RefreshSingle(ctx context.Context, path, method string) erro
 		return fmt.Errorf("failed to get config: %w", err)
 	}
 
-	// Remove old version if exists
-	_ = r.registry.RemoveRoute(path, method) // Ignore error if doesn't exist
-
-	// Add new version
-	if err := r.registry.AddRoute(config); err != nil {
+	// Replace old versions with the new ones in a single step
+	if err := r.registry.ReplaceRoutes(path, method, configs); err != nil {
 		r.logger.Error("Failed to add route to registry",
 			zap.String("path", path),
 			zap.String("method", method),
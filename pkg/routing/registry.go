@@ -3,7 +3,9 @@ package routing
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
 	"go.uber.org/zap"
@@ -18,14 +20,21 @@ var (
 
 	// ErrRouteAlreadyExists is returned when trying to add a duplicate route
 	ErrRouteAlreadyExists = errors.New("route already exists")
+
+	// ErrRouteUnavailable is returned when the matching route is outside its active window or in maintenance
+	// The config is returned alongside so callers can answer with its maintenance response
+	ErrRouteUnavailable = errors.New("route unavailable")
 )
 
 // RouteRegistry maintains an in-memory trie of all routes
 type RouteRegistry struct {
-	mu     sync.RWMutex
-	root   *TrieNode
-	routes map[string]*dto.APIConfigResponse // slug -> config for quick lookup
-	logger *zap.Logger
+	mu         sync.RWMutex
+	root       *TrieNode
+	routes     map[string]*dto.APIConfigResponse // route key -> config for quick lookup
+	generation uint64                            // Incremented on every Swap
+	pathRules  PathRules                         // Reserved prefixes and rewrite rules, kept across Swap
+	now        func() time.Time                  // Clock for active windows and maintenance schedules
+	logger     *zap.Logger
 }
 
 // NewRouteRegistry creates a new route registry
@@ -33,6 +42,11 @@ func NewRouteRegistry(logger *zap.Logger) *RouteRegistry {
 	return &RouteRegistry{
 		root:   NewTrieNode(""),
 		routes: make(map[string]*dto.APIConfigResponse),
+		pathRules: PathRules{
+			Reserved: append([]string(nil), DefaultReservedPrefixes...),
+			Rewrites: append([]PrefixRule(nil), DefaultPrefixRules...),
+		},
+		now:    time.Now,
 		logger: logger,
 	}
 }
@@ -42,20 +56,38 @@ func (r *RouteRegistry) AddRoute(config *dto.APIConfigResponse) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Create unique key for path+method combination
-	routeKey := config.Path + ":" + config.Method
+	return r.addRouteLocked(config)
+}
+
+// addRouteLocked adds a route, the caller must hold the write lock
+func (r *RouteRegistry) addRouteLocked(config *dto.APIConfigResponse) error {
+	// Create unique key for path+method+predicates combination
+	routeKey := buildRouteKey(config)
 
-	// Check for duplicate path+method combination
+	// Check for duplicate path+method+predicates combination
 	if _, exists := r.routes[routeKey]; exists {
-		return fmt.Errorf("%w: path=%s, method=%s", ErrRouteAlreadyExists, config.Path, config.Method)
+		return fmt.Errorf("%w: path=%s, method=%s, predicates=%s",
+			ErrRouteAlreadyExists, config.Path, config.Method, predicateKey(config))
 	}
 
 	// Parse Path into segments (Path is the public URL exposed to clients like /v1/products/{id})
-	// URI is the backend target path which is used for forwarding, not for matching
-	segments := ParseURI(config.Path)
+	// mounted under the URL config's base path; URI is the backend target path used for forwarding
+	mountPath := MountPath(config)
+	segments := ParseURI(mountPath)
+
+	// Reject malformed parameter constraints and misplaced catch-alls before touching the trie
+	for i, segment := range segments {
+		if err := ValidateSegment(segment); err != nil {
+			return fmt.Errorf("invalid route path %s: %w", mountPath, err)
+		}
+		if IsCatchAllSegment(segment) && i != len(segments)-1 {
+			return fmt.Errorf("invalid route path %s: catch-all segment %q must be the last segment", mountPath, segment)
+		}
+	}
 
 	r.logger.Debug("Adding route to registry",
 		zap.String("path", config.Path),
+		zap.String("mount_path", mountPath),
 		zap.String("target_uri", config.URI),
 		zap.String("method", config.Method),
 		zap.Int("segments", len(segments)),
@@ -64,36 +96,46 @@ func (r *RouteRegistry) AddRoute(config *dto.APIConfigResponse) error {
 	// Navigate/create trie path
 	node := r.root
 	for _, segment := range segments {
+		if IsCatchAllSegment(segment) && node.catchAllChild != nil && node.catchAllChild.segment != segment {
+			return fmt.Errorf("%w: catch-all %s conflicts with %s under path=%s",
+				ErrRouteAlreadyExists, segment, node.catchAllChild.segment, mountPath)
+		}
 		node = node.AddChild(segment)
 	}
 
-	// Initialize configs map if nil
-	if node.configs == nil {
-		node.configs = make(map[string]*dto.APIConfigResponse)
-	}
-
 	// Set config for this specific method
-	node.configs[config.Method] = config
-	node.methods[config.Method] = true
+	node.addConfig(config)
 
-	// Store in routes map with path:method key for quick lookup
+	// Store in routes map with path:method[|predicates] key for quick lookup
 	r.routes[routeKey] = config
 
 	r.logger.Info("Route added successfully",
 		zap.String("path", config.Path),
 		zap.String("target_uri", config.URI),
 		zap.String("method", config.Method),
+		zap.String("predicates", predicateKey(config)),
 	)
 
 	return nil
 }
 
 // Match finds a matching route for the given path and method
+// Routes with host, header or query predicates never match here, use MatchRequest for those
 // Returns: (config, params, error)
 func (r *RouteRegistry) Match(path, method string) (*dto.APIConfigResponse, map[string]string, error) {
+	return r.MatchRequest(&RouteRequest{Path: path, Method: method})
+}
+
+// MatchRequest finds a matching route for the given request, honoring match predicates and schedules
+// Routes outside their active window or in maintenance are skipped in favor of available ones;
+// when only such routes match, the best of them is returned with ErrRouteUnavailable
+// Returns: (config, params, error)
+func (r *RouteRegistry) MatchRequest(req *RouteRequest) (*dto.APIConfigResponse, map[string]string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	path, method := req.Path, req.Method
+
 	// Parse request path
 	segments := ParseURI(path)
 	params := make(map[string]string)
@@ -104,42 +146,43 @@ func (r *RouteRegistry) Match(path, method string) (*dto.APIConfigResponse, map[
 		zap.Int("segments", len(segments)),
 	)
 
-	// Traverse trie
-	node := r.root
-	for i, segment := range segments {
-		child := node.FindChild(segment, params)
-		if child == nil {
-			r.logger.Debug("No matching child found",
-				zap.String("path", path),
-				zap.Int("segment_index", i),
-				zap.String("segment", segment),
-			)
-			return nil, nil, ErrRouteNotFound
-		}
-		node = child
+	// Collect every configured node the path can reach, backtracking over alternatives
+	var matches []routeMatch
+	collectMatches(r.root, segments, 0, params, &matches, nil)
+	if len(matches) == 0 {
+		r.logger.Debug("No matching route found",
+			zap.String("path", path),
+			zap.Int("segments", len(segments)),
+		)
+		return nil, nil, ErrRouteNotFound
 	}
 
-	// Check if we reached a configured endpoint
-	if node.configs == nil || len(node.configs) == 0 {
-		r.logger.Debug("Path matched but no configs found",
+	// Choose among the candidates that serve this method and accept the request
+	config, params, methodFound, available := selectConfig(matches, req, r.now(), nil)
+	if config == nil && methodFound {
+		r.logger.Debug("No route predicates matched",
 			zap.String("path", path),
+			zap.String("method", method),
+			zap.String("host", req.Host),
 		)
 		return nil, nil, ErrRouteNotFound
 	}
+	if config == nil {
+		r.logger.Debug("Method not allowed",
+			zap.String("path", path),
+			zap.String("method", method),
+			zap.Any("available_methods", matchedMethods(matches)),
+		)
+		return nil, nil, ErrMethodNotAllowed
+	}
 
-	// Get config for specific method
-	config, exists := node.configs[method]
-	if !exists {
-		// Check if any method exists (for better error message)
-		if len(node.configs) > 0 {
-			r.logger.Debug("Method not allowed",
-				zap.String("path", path),
-				zap.String("method", method),
-				zap.Any("available_methods", getKeys(node.configs)),
-			)
-			return nil, nil, ErrMethodNotAllowed
-		}
-		return nil, nil, ErrRouteNotFound
+	if !available {
+		r.logger.Info("Route matched but unavailable",
+			zap.String("slug", config.Path),
+			zap.String("path", path),
+			zap.String("method", method),
+		)
+		return config, params, ErrRouteUnavailable
 	}
 
 	r.logger.Info("Route matched successfully",
@@ -153,15 +196,6 @@ func (r *RouteRegistry) Match(path, method string) (*dto.APIConfigResponse, map[
 	return config, params, nil
 }
 
-// Helper function to get map keys
-func getKeys(m map[string]*dto.APIConfigResponse) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
-}
-
 // GetBySlug retrieves a route configuration by slug
 func (r *RouteRegistry) GetBySlug(slug string) (*dto.APIConfigResponse, bool) {
 	r.mu.RLock()
@@ -171,39 +205,94 @@ func (r *RouteRegistry) GetBySlug(slug string) (*dto.APIConfigResponse, bool) {
 	return config, exists
 }
 
-// RemoveRoute removes a route from the registry by path and method
+// RemoveRoute removes every route registered for path and method, whatever their predicates
 func (r *RouteRegistry) RemoveRoute(path, method string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	routeKey := path + ":" + method
-	config, exists := r.routes[routeKey]
-	if !exists {
-		return fmt.Errorf("route not found: path=%s, method=%s", path, method)
+	return r.removeRouteLocked(path, method)
+}
+
+// ReplaceRoutes swaps every route for path and method with configs in a single critical section,
+// so concurrent lookups see either the old or the new routes, never a gap
+// configs are validated first; on error the existing routes are left untouched
+func (r *RouteRegistry) ReplaceRoutes(path, method string, configs []*dto.APIConfigResponse) error {
+	// Validate against an empty scratch registry so a bad config never reaches the live trie
+	scratch := NewRouteRegistry(r.logger)
+	for _, config := range configs {
+		if err := scratch.AddRoute(config); err != nil {
+			return err
+		}
 	}
 
-	// Remove from routes map
-	delete(r.routes, routeKey)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Also remove from trie node's configs map
-	segments := ParseURI(path)
-	node := r.root
-	for _, segment := range segments {
-		child := node.FindChild(segment, nil)
-		if child == nil {
-			break
+	// Keep a copy of the routes being replaced so a conflict with another path can be rolled back
+	baseKey := path + ":" + method
+	previous := make([]*dto.APIConfigResponse, 0)
+	for key, config := range r.routes {
+		if key == baseKey || strings.HasPrefix(key, baseKey+"|") {
+			previous = append(previous, config)
+		}
+	}
+
+	if len(previous) > 0 {
+		_ = r.removeRouteLocked(path, method)
+	}
+
+	for i, config := range configs {
+		if err := r.addRouteLocked(config); err != nil {
+			// Roll back to the previous routes
+			for _, added := range configs[:i] {
+				_ = r.removeRouteLocked(added.Path, added.Method)
+			}
+			for _, old := range previous {
+				_ = r.addRouteLocked(old)
+			}
+			return err
 		}
-		node = child
 	}
-	if node.configs != nil {
-		delete(node.configs, method)
-		delete(node.methods, method)
+
+	return nil
+}
+
+// removeRouteLocked removes every route for path and method, the caller must hold the write lock
+func (r *RouteRegistry) removeRouteLocked(path, method string) error {
+	baseKey := path + ":" + method
+	removed := 0
+	mountPaths := make(map[string]bool)
+	for key, config := range r.routes {
+		if key == baseKey || strings.HasPrefix(key, baseKey+"|") {
+			delete(r.routes, key)
+			mountPaths[MountPath(config)] = true
+			removed++
+		}
+	}
+	if removed == 0 {
+		return fmt.Errorf("route not found: path=%s, method=%s", path, method)
+	}
+
+	// Also remove from the trie nodes the routes were mounted on
+	for mountPath := range mountPaths {
+		node := r.root
+		for _, segment := range ParseURI(mountPath) {
+			child := node.GetChild(segment)
+			if child == nil {
+				node = nil
+				break
+			}
+			node = child
+		}
+		if node != nil {
+			node.removeConfigs(path, method)
+		}
 	}
 
 	r.logger.Info("Route removed successfully",
 		zap.String("path", path),
 		zap.String("method", method),
-		zap.String("uri", config.URI),
+		zap.Int("removed", removed),
 	)
 
 	return nil
@@ -230,6 +319,49 @@ func (r *RouteRegistry) List() []string {
 	return slugs
 }
 
+// buildRouteKey returns the routes map key for config
+// Example: "/v1/products:GET", "/v1/products:GET|base=/shop" or "/v1/products:GET|header:X-Api-Version=2"
+func buildRouteKey(config *dto.APIConfigResponse) string {
+	key := config.Path + ":" + config.Method
+	if base := NormalizeBasePath(config.URLConfig.BasePath); base != "" {
+		key += "|base=" + base
+	}
+	if hasPredicates(config) {
+		key += "|" + predicateKey(config)
+	}
+	return key
+}
+
+// Swap atomically replaces the registry contents with the trie and routes built in staged
+// Lookups running concurrently see either the complete old or the complete new registry
+// staged must not be modified after the swap
+func (r *RouteRegistry) Swap(staged *RouteRegistry) {
+	staged.mu.RLock()
+	root, routes := staged.root, staged.routes
+	staged.mu.RUnlock()
+
+	r.mu.Lock()
+	r.root = root
+	r.routes = routes
+	r.generation++
+	generation := r.generation
+	r.mu.Unlock()
+
+	r.logger.Info("Registry swapped",
+		zap.Int("routes", len(routes)),
+		zap.Uint64("generation", generation),
+	)
+}
+
+// Generation returns how many times the registry contents were swapped
+// Zero means no complete route set has been installed yet
+func (r *RouteRegistry) Generation() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.generation
+}
+
 // Clear removes all routes from the registry
 func (r *RouteRegistry) Clear() {
 	r.mu.Lock()
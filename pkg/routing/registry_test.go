@@ -1,7 +1,11 @@
 package routing
 
 import (
+	"errors"
+	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
 	"go.uber.org/zap"
@@ -12,11 +16,12 @@ func setupTestLogger() *zap.Logger {
 	return zap.NewNop()
 }
 
-func createTestConfig(slug, uri, method string) *dto.APIConfigResponse {
+// createTestConfig builds a config whose Description carries a readable name for assertions
+func createTestConfig(name, path, method string) *dto.APIConfigResponse {
 	return &dto.APIConfigResponse{
-		Slug:   slug,
-		URI:    uri,
-		Method: method,
+		Description: name,
+		Path:        path,
+		Method:      method,
 	}
 }
 
@@ -53,8 +58,13 @@ func TestRouteRegistry_AddRoute(t *testing.T) {
 			shouldError: false,
 		},
 		{
-			name:        "Add duplicate slug",
-			config:      createTestConfig("get-users", "/users", "POST"),
+			name:        "Add same path with different method",
+			config:      createTestConfig("create-user", "/users", "POST"),
+			shouldError: false,
+		},
+		{
+			name:        "Add duplicate path and method",
+			config:      createTestConfig("get-users-again", "/users", "GET"),
 			shouldError: true,
 		},
 	}
@@ -165,8 +175,8 @@ func TestRouteRegistry_Match(t *testing.T) {
 					return
 				}
 
-				if config.Slug != tt.expectedSlug {
-					t.Errorf("Expected slug %s, got %s", tt.expectedSlug, config.Slug)
+				if config.Description != tt.expectedSlug {
+					t.Errorf("Expected config %s, got %s", tt.expectedSlug, config.Description)
 				}
 
 				if tt.expectedParams != nil {
@@ -185,6 +195,93 @@ func TestRouteRegistry_Match(t *testing.T) {
 	}
 }
 
+func TestRouteRegistry_MatchConstrainedParams(t *testing.T) {
+	logger := setupTestLogger()
+	registry := NewRouteRegistry(logger)
+
+	registry.AddRoute(createTestConfig("order-by-id", "/orders/{id:int}", "GET"))
+	registry.AddRoute(createTestConfig("order-by-uuid", "/orders/{uuid:uuid}", "GET"))
+	registry.AddRoute(createTestConfig("order-by-code", "/orders/{code:[A-Z]{3}}/items", "GET"))
+	registry.AddRoute(createTestConfig("order-by-ref", "/orders/{ref}/items", "GET"))
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedConfig string
+		expectedParams map[string]string
+		expectedError  error
+	}{
+		{
+			name:           "Integer constraint",
+			path:           "/orders/42",
+			expectedConfig: "order-by-id",
+			expectedParams: map[string]string{"id": "42"},
+		},
+		{
+			name:           "UUID constraint",
+			path:           "/orders/6f1c2a8e-3b4d-4c5e-9f70-112233445566",
+			expectedConfig: "order-by-uuid",
+			expectedParams: map[string]string{"uuid": "6f1c2a8e-3b4d-4c5e-9f70-112233445566"},
+		},
+		{
+			name:          "No constraint accepts segment",
+			path:          "/orders/abc",
+			expectedError: ErrRouteNotFound,
+		},
+		{
+			name:           "Regex constraint",
+			path:           "/orders/IDR/items",
+			expectedConfig: "order-by-code",
+			expectedParams: map[string]string{"code": "IDR"},
+		},
+		{
+			name:           "Backtrack from integer to unconstrained",
+			path:           "/orders/42/items",
+			expectedConfig: "order-by-ref",
+			expectedParams: map[string]string{"ref": "42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, params, err := registry.Match(tt.path, "GET")
+
+			if tt.expectedError != nil {
+				if err != tt.expectedError {
+					t.Errorf("Expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected match, got error: %v", err)
+			}
+			if config.Description != tt.expectedConfig {
+				t.Errorf("Expected config %s, got %s", tt.expectedConfig, config.Description)
+			}
+			if len(params) != len(tt.expectedParams) {
+				t.Errorf("Expected params %v, got %v", tt.expectedParams, params)
+			}
+			for key, val := range tt.expectedParams {
+				if params[key] != val {
+					t.Errorf("Expected param[%s] = %s, got %s", key, val, params[key])
+				}
+			}
+		})
+	}
+}
+
+func TestRouteRegistry_AddRouteInvalidConstraint(t *testing.T) {
+	logger := setupTestLogger()
+	registry := NewRouteRegistry(logger)
+
+	if err := registry.AddRoute(createTestConfig("bad", "/orders/{id:[}", "GET")); err == nil {
+		t.Error("Expected error for invalid constraint, got nil")
+	}
+	if registry.Count() != 0 {
+		t.Errorf("Expected count 0, got %d", registry.Count())
+	}
+}
+
 func TestRouteRegistry_GetBySlug(t *testing.T) {
 	logger := setupTestLogger()
 	registry := NewRouteRegistry(logger)
@@ -192,13 +289,13 @@ func TestRouteRegistry_GetBySlug(t *testing.T) {
 	testConfig := createTestConfig("test-route", "/test", "GET")
 	registry.AddRoute(testConfig)
 
-	// Test existing slug
-	config, exists := registry.GetBySlug("test-route")
+	// Routes are keyed by path:method
+	config, exists := registry.GetBySlug("/test:GET")
 	if !exists {
-		t.Error("Expected to find route")
+		t.Fatal("Expected to find route")
 	}
-	if config.Slug != "test-route" {
-		t.Errorf("Expected slug 'test-route', got %s", config.Slug)
+	if config.Description != "test-route" {
+		t.Errorf("Expected config 'test-route', got %s", config.Description)
 	}
 
 	// Test non-existing slug
@@ -220,7 +317,7 @@ func TestRouteRegistry_RemoveRoute(t *testing.T) {
 	}
 
 	// Remove route
-	err := registry.RemoveRoute("test-route")
+	err := registry.RemoveRoute("/test", "GET")
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -230,12 +327,164 @@ func TestRouteRegistry_RemoveRoute(t *testing.T) {
 	}
 
 	// Remove non-existing
-	err = registry.RemoveRoute("non-existent")
+	err = registry.RemoveRoute("/non-existent", "GET")
 	if err == nil {
 		t.Error("Expected error for non-existent route")
 	}
 }
 
+func TestRouteRegistry_ReplaceRoutes(t *testing.T) {
+	logger := setupTestLogger()
+	registry := NewRouteRegistry(logger)
+
+	registry.AddRoute(createTestConfig("old", "/orders/{id}", "GET"))
+
+	// Valid replacement
+	if err := registry.ReplaceRoutes("/orders/{id}", "GET", []*dto.APIConfigResponse{
+		createTestConfig("new", "/orders/{id}", "GET"),
+	}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	config, _, err := registry.Match("/orders/1", "GET")
+	if err != nil || config.Description != "new" {
+		t.Fatalf("Expected new route, got %v (%v)", config, err)
+	}
+
+	// Invalid replacement keeps the current route
+	if err := registry.ReplaceRoutes("/orders/{id}", "GET", []*dto.APIConfigResponse{
+		createTestConfig("broken", "/orders/{id:[}", "GET"),
+	}); err == nil {
+		t.Fatal("Expected error for invalid replacement")
+	}
+
+	config, _, err = registry.Match("/orders/1", "GET")
+	if err != nil || config.Description != "new" {
+		t.Errorf("Expected route to survive a failed replacement, got %v (%v)", config, err)
+	}
+}
+
+func TestRouteRegistry_Swap(t *testing.T) {
+	logger := setupTestLogger()
+	registry := NewRouteRegistry(logger)
+
+	if registry.Generation() != 0 {
+		t.Errorf("Expected generation 0, got %d", registry.Generation())
+	}
+
+	staged := NewRouteRegistry(logger)
+	staged.AddRoute(createTestConfig("v1", "/products/{id}", "GET"))
+	registry.Swap(staged)
+
+	if registry.Generation() != 1 {
+		t.Errorf("Expected generation 1, got %d", registry.Generation())
+	}
+
+	// Lookups racing with swaps must always see a complete registry
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	failures := make(chan error, 1)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, _, err := registry.Match("/products/42", "GET"); err != nil {
+				select {
+				case failures <- err:
+				default:
+				}
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		next := NewRouteRegistry(logger)
+		next.AddRoute(createTestConfig("next", "/products/{id}", "GET"))
+		next.AddRoute(createTestConfig("list", "/products", "GET"))
+		registry.Swap(next)
+	}
+	close(stop)
+	wg.Wait()
+
+	select {
+	case err := <-failures:
+		t.Errorf("Lookup failed during swap: %v", err)
+	default:
+	}
+
+	if registry.Count() != 2 {
+		t.Errorf("Expected count 2 after swaps, got %d", registry.Count())
+	}
+}
+
+func TestRouteRegistry_MatchSchedule(t *testing.T) {
+	registry := NewRouteRegistry(setupTestLogger())
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	registry.now = func() time.Time { return now }
+
+	cutoffStart := now.Add(-30 * time.Minute)
+	transfer := createTestConfig("transfer", "/v1/transfer", "POST")
+	transfer.MaintenanceSchedule = []dto.MaintenanceWindow{{Start: "11:30", End: "12:30"}}
+	// Served instead of transfer while it is in maintenance
+	queued := createTestConfig("queued", "/v1/transfer", "POST")
+	queued.MatchHeaders = map[string]string{"X-Queue": ""}
+	queued.ActiveFrom = &cutoffStart
+
+	future := now.Add(time.Hour)
+	promo := createTestConfig("promo", "/v1/promo", "GET")
+	promo.ActiveFrom = &future
+
+	past := now.Add(-time.Hour)
+	legacy := createTestConfig("legacy", "/v1/legacy", "GET")
+	legacy.ActiveUntil = &past
+
+	for _, config := range []*dto.APIConfigResponse{transfer, queued, promo, legacy} {
+		if err := registry.AddRoute(config); err != nil {
+			t.Fatalf("Failed to add route: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		req      *RouteRequest
+		expected *dto.APIConfigResponse
+		err      error
+	}{
+		{"maintenance returns config", &RouteRequest{Path: "/v1/transfer", Method: "POST"}, transfer, ErrRouteUnavailable},
+		{"available config wins over maintenance", &RouteRequest{Path: "/v1/transfer", Method: "POST", Headers: http.Header{"X-Queue": {"1"}}}, queued, nil},
+		{"not yet active", &RouteRequest{Path: "/v1/promo", Method: "GET"}, promo, ErrRouteUnavailable},
+		{"expired", &RouteRequest{Path: "/v1/legacy", Method: "GET"}, legacy, ErrRouteUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _, err := registry.MatchRequest(tt.req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if config != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, config)
+			}
+		})
+	}
+
+	// Once the window is over the route is served again without any config change
+	now = now.Add(time.Hour)
+	if config, _, err := registry.MatchRequest(&RouteRequest{Path: "/v1/transfer", Method: "POST"}); err != nil || config != transfer {
+		t.Errorf("Expected transfer after maintenance, got %v %v", config, err)
+	}
+	if status := Availability(promo, now); !status.Available {
+		t.Errorf("Expected promo to be active, got %+v", status)
+	}
+}
+
 func TestRouteRegistry_Clear(t *testing.T) {
 	logger := setupTestLogger()
 	registry := NewRouteRegistry(logger)
@@ -275,10 +524,10 @@ func TestRouteRegistry_List(t *testing.T) {
 	hasRoute1 := false
 	hasRoute2 := false
 	for _, slug := range slugs {
-		if slug == "route1" {
+		if slug == "/route1:GET" {
 			hasRoute1 = true
 		}
-		if slug == "route2" {
+		if slug == "/route2:POST" {
 			hasRoute2 = true
 		}
 	}
@@ -1,21 +1,36 @@
 package routing
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/Payphone-Digital/gateway/internal/dto"
 )
 
+// Named parameter constraints usable as {name:constraint}
+// Anything that is not a named constraint is treated as a regular expression
+var namedConstraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+}
+
 // TrieNode represents a node in the route trie
 type TrieNode struct {
-	segment       string                            // Path segment ("products", "{id}", etc.)
-	isParam       bool                              // True if this segment is a parameter
-	paramName     string                            // Parameter name ("id" if segment is "{id}")
-	children      map[string]*TrieNode              // Static children (exact matches)
-	paramChild    *TrieNode                         // Dynamic parameter child ("{id}", "{slug}", etc.)
-	wildcardChild *TrieNode                         // Wildcard child ("*")
-	configs       map[string]*dto.APIConfigResponse // Method -> Config mapping
-	methods       map[string]bool                   // Allowed HTTP methods at this node
+	segment       string                              // Path segment ("products", "{id}", "{id:int}", etc.)
+	isParam       bool                                // True if this segment is a parameter
+	paramName     string                              // Parameter name ("id" if segment is "{id}" or "{id:int}")
+	constraint    *regexp.Regexp                      // Compiled constraint, nil for unconstrained parameters
+	children      map[string]*TrieNode                // Static children (exact matches)
+	paramChildren []*TrieNode                         // Dynamic parameter children, constrained ones first
+	wildcardChild *TrieNode                           // Wildcard child ("*")
+	catchAllChild *TrieNode                           // Terminal catch-all child ("**", "{rest...}")
+	isCatchAll    bool                                // True if this segment captures the remaining path
+	configs       map[string][]*dto.APIConfigResponse // Method -> Configs, most match predicates first
+	methods       map[string]bool                     // Allowed HTTP methods at this node
 }
 
 // NewTrieNode creates a new trie node
@@ -26,10 +41,22 @@ func NewTrieNode(segment string) *TrieNode {
 		methods:  make(map[string]bool),
 	}
 
+	// Catch-all captures the rest of the path under paramName
+	if IsCatchAllSegment(segment) {
+		node.isCatchAll = true
+		node.paramName = catchAllName(segment)
+		return node
+	}
+
 	// Check if segment is a parameter
-	if len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' {
+	if IsParameterSegment(segment) {
+		name, expr := splitParamSegment(segment)
 		node.isParam = true
-		node.paramName = segment[1 : len(segment)-1] // Extract param name without {}
+		node.paramName = name
+		if expr != "" {
+			// Invalid expressions are rejected by ValidateSegment before insertion
+			node.constraint, _ = compileConstraint(expr)
+		}
 	}
 
 	return node
@@ -45,12 +72,38 @@ func (n *TrieNode) AddChild(segment string) *TrieNode {
 		return n.wildcardChild
 	}
 
-	// Parameter (e.g., "{id}")
-	if len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' {
-		if n.paramChild == nil {
-			n.paramChild = NewTrieNode(segment)
+	// Catch-all (e.g., "**", "{rest...}")
+	if IsCatchAllSegment(segment) {
+		if n.catchAllChild == nil {
+			n.catchAllChild = NewTrieNode(segment)
 		}
-		return n.paramChild
+		return n.catchAllChild
+	}
+
+	// Parameter (e.g., "{id}", "{id:int}")
+	if IsParameterSegment(segment) {
+		for _, child := range n.paramChildren {
+			if child.segment == segment {
+				return child
+			}
+		}
+
+		child := NewTrieNode(segment)
+		if child.constraint == nil {
+			// Unconstrained parameters match anything, so they are tried last
+			n.paramChildren = append(n.paramChildren, child)
+			return child
+		}
+
+		// Insert after the last constrained child to keep registration order among constraints
+		idx := 0
+		for idx < len(n.paramChildren) && n.paramChildren[idx].constraint != nil {
+			idx++
+		}
+		n.paramChildren = append(n.paramChildren, nil)
+		copy(n.paramChildren[idx+1:], n.paramChildren[idx:])
+		n.paramChildren[idx] = child
+		return child
 	}
 
 	// Static segment
@@ -63,8 +116,34 @@ func (n *TrieNode) AddChild(segment string) *TrieNode {
 	return child
 }
 
+// GetChild returns the child registered for an exact pattern segment without matching
+// Example: GetChild("{id:int}") returns the "{id:int}" node, never the "{id}" node
+func (n *TrieNode) GetChild(segment string) *TrieNode {
+	if segment == "*" {
+		return n.wildcardChild
+	}
+
+	if IsCatchAllSegment(segment) {
+		if n.catchAllChild != nil && n.catchAllChild.segment == segment {
+			return n.catchAllChild
+		}
+		return nil
+	}
+
+	if IsParameterSegment(segment) {
+		for _, child := range n.paramChildren {
+			if child.segment == segment {
+				return child
+			}
+		}
+		return nil
+	}
+
+	return n.children[segment]
+}
+
 // FindChild finds the best matching child for a segment
-// Priority: exact match > parameter > wildcard
+// Priority: exact match > constrained parameter > parameter > wildcard > catch-all
 func (n *TrieNode) FindChild(segment string, params map[string]string) *TrieNode {
 	// 1. Try exact match first
 	if child, exists := n.children[segment]; exists {
@@ -72,11 +151,14 @@ func (n *TrieNode) FindChild(segment string, params map[string]string) *TrieNode
 	}
 
 	// 2. Try parameter match
-	if n.paramChild != nil {
+	for _, child := range n.paramChildren {
+		if !child.MatchesParam(segment) {
+			continue
+		}
 		if params != nil {
-			params[n.paramChild.paramName] = segment
+			params[child.paramName] = segment
 		}
-		return n.paramChild
+		return child
 	}
 
 	// 3. Try wildcard match
@@ -87,9 +169,68 @@ func (n *TrieNode) FindChild(segment string, params map[string]string) *TrieNode
 		return n.wildcardChild
 	}
 
+	// 4. Try catch-all match
+	if n.catchAllChild != nil {
+		if params != nil {
+			params[n.catchAllChild.paramName] = segment
+		}
+		return n.catchAllChild
+	}
+
 	return nil
 }
 
+// addConfig registers config for its method, keeping configs with more match predicates first
+func (n *TrieNode) addConfig(config *dto.APIConfigResponse) {
+	if n.configs == nil {
+		n.configs = make(map[string][]*dto.APIConfigResponse)
+	}
+
+	configs := n.configs[config.Method]
+	idx := len(configs)
+	for i, existing := range configs {
+		if predicateCount(config) > predicateCount(existing) {
+			idx = i
+			break
+		}
+	}
+	configs = append(configs, nil)
+	copy(configs[idx+1:], configs[idx:])
+	configs[idx] = config
+
+	n.configs[config.Method] = configs
+	n.methods[config.Method] = true
+}
+
+// removeConfigs drops the configs for method that were registered with path
+// Other configs mounted on the same node (e.g. through a base path) are kept
+func (n *TrieNode) removeConfigs(path, method string) {
+	kept := n.configs[method][:0]
+	for _, config := range n.configs[method] {
+		if config.Path != path {
+			kept = append(kept, config)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(n.configs, method)
+		delete(n.methods, method)
+		return
+	}
+	n.configs[method] = kept
+}
+
+// MatchesParam reports whether a request segment satisfies this parameter node's constraint
+func (n *TrieNode) MatchesParam(segment string) bool {
+	if !n.isParam {
+		return false
+	}
+	if n.constraint == nil {
+		return true
+	}
+	return n.constraint.MatchString(segment)
+}
+
 // ParseURI splits a URI path into segments
 // Example: "/api/users/123" -> ["api", "users", "123"]
 func ParseURI(uri string) []string {
@@ -114,10 +255,76 @@ func BuildURIPattern(segments []string) string {
 
 // IsParameterSegment checks if a segment is a parameter
 func IsParameterSegment(segment string) bool {
-	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}'
+	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' && !IsCatchAllSegment(segment)
+}
+
+// IsCatchAllSegment checks if a segment captures the remaining path
+// Example: "**" and "{rest...}"
+func IsCatchAllSegment(segment string) bool {
+	if segment == "**" {
+		return true
+	}
+	return len(segment) >= 5 && segment[0] == '{' && strings.HasSuffix(segment, "...}")
 }
 
 // IsWildcardSegment checks if a segment is a wildcard
 func IsWildcardSegment(segment string) bool {
 	return segment == "*"
 }
+
+// ValidateSegment checks that a route pattern segment is well formed
+// Example: "{id:int}" and "{code:[A-Z]{3}}" are valid, "{:int}" and "{id:[}" are not
+func ValidateSegment(segment string) error {
+	if IsCatchAllSegment(segment) {
+		if catchAllName(segment) == "" {
+			return fmt.Errorf("catch-all segment %q has no name", segment)
+		}
+		return nil
+	}
+
+	if !IsParameterSegment(segment) {
+		return nil
+	}
+
+	name, expr := splitParamSegment(segment)
+	if name == "" {
+		return fmt.Errorf("parameter segment %q has no name", segment)
+	}
+	if strings.Contains(segment, ":") && expr == "" {
+		return fmt.Errorf("parameter segment %q has an empty constraint", segment)
+	}
+	if expr != "" {
+		if _, err := compileConstraint(expr); err != nil {
+			return fmt.Errorf("parameter segment %q has an invalid constraint: %w", segment, err)
+		}
+	}
+
+	return nil
+}
+
+// catchAllName returns the param name a catch-all stores the remaining path under
+// "**" uses "wildcard" like "*", "{rest...}" uses "rest"
+func catchAllName(segment string) string {
+	if segment == "**" {
+		return "wildcard"
+	}
+	return segment[1 : len(segment)-4]
+}
+
+// splitParamSegment splits "{name:constraint}" into its name and constraint
+// Only the first colon separates the two, so regular expressions may contain colons
+func splitParamSegment(segment string) (string, string) {
+	inner := segment[1 : len(segment)-1]
+	if idx := strings.Index(inner, ":"); idx >= 0 {
+		return inner[:idx], inner[idx+1:]
+	}
+	return inner, ""
+}
+
+// compileConstraint compiles a named constraint or raw expression anchored to the whole segment
+func compileConstraint(expr string) (*regexp.Regexp, error) {
+	if named, ok := namedConstraints[expr]; ok {
+		expr = named
+	}
+	return regexp.Compile("^(?:" + expr + ")$")
+}
@@ -2,6 +2,9 @@ package routing
 
 import (
 	"testing"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+	"go.uber.org/zap"
 )
 
 func TestNewTrieNode(t *testing.T) {
@@ -235,3 +238,338 @@ func TestIsWildcardSegment(t *testing.T) {
 		})
 	}
 }
+
+func TestTrieNode_ConstrainedParamChildren(t *testing.T) {
+	root := NewTrieNode("")
+
+	plain := root.AddChild("{ref}")
+	intChild := root.AddChild("{id:int}")
+	codeChild := root.AddChild("{code:[A-Z]{3}}")
+
+	if intChild.paramName != "id" {
+		t.Errorf("Expected paramName 'id', got %s", intChild.paramName)
+	}
+	if len(root.paramChildren) != 3 {
+		t.Fatalf("Expected 3 parameter children, got %d", len(root.paramChildren))
+	}
+	if root.paramChildren[2] != plain {
+		t.Error("Expected unconstrained parameter to be tried last")
+	}
+	if root.AddChild("{id:int}") != intChild {
+		t.Error("Expected same child instance for duplicate constrained add")
+	}
+
+	tests := []struct {
+		segment       string
+		expectedNode  *TrieNode
+		expectedParam string
+	}{
+		{"123", intChild, "id"},
+		{"IDR", codeChild, "code"},
+		{"abc", plain, "ref"},
+		{"IDRX", plain, "ref"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			params := make(map[string]string)
+			result := root.FindChild(tt.segment, params)
+			if result != tt.expectedNode {
+				t.Fatalf("Expected node %s, got %v", tt.expectedNode.segment, result)
+			}
+			if params[tt.expectedParam] != tt.segment {
+				t.Errorf("Expected param[%s] = %s, got %s", tt.expectedParam, tt.segment, params[tt.expectedParam])
+			}
+		})
+	}
+}
+
+func TestValidateSegment(t *testing.T) {
+	tests := []struct {
+		segment     string
+		shouldError bool
+	}{
+		{"users", false},
+		{"{id}", false},
+		{"{id:int}", false},
+		{"{uuid:uuid}", false},
+		{"{code:[A-Z]{3}}", false},
+		{"{:int}", true},
+		{"{id:}", true},
+		{"{id:[}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			err := ValidateSegment(tt.segment)
+			if tt.shouldError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.shouldError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func newPriorityConfig(name, path, method string, priority int) *dto.APIConfigResponse {
+	return &dto.APIConfigResponse{
+		Description: name,
+		Path:        path,
+		Method:      method,
+		Priority:    priority,
+	}
+}
+
+func TestRouteRegistry_MatchAmbiguousOverlaps(t *testing.T) {
+	tests := []struct {
+		name           string
+		routes         []*dto.APIConfigResponse
+		path           string
+		method         string
+		expectedConfig string
+		expectedParams map[string]string
+		expectedError  error
+	}{
+		{
+			name: "Static dead end falls back to parameter",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("me", "/users/me", "GET", 0),
+				newPriorityConfig("user-orders", "/users/{id}/orders", "GET", 0),
+			},
+			path:           "/users/me/orders",
+			method:         "GET",
+			expectedConfig: "user-orders",
+			expectedParams: map[string]string{"id": "me"},
+		},
+		{
+			name: "Static wins on equal priority",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("user", "/users/{id}", "GET", 0),
+				newPriorityConfig("me", "/users/me", "GET", 0),
+			},
+			path:           "/users/me",
+			method:         "GET",
+			expectedConfig: "me",
+			expectedParams: map[string]string{},
+		},
+		{
+			name: "Higher priority parameter beats static",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("user", "/users/{id}", "GET", 10),
+				newPriorityConfig("me", "/users/me", "GET", 0),
+			},
+			path:           "/users/me",
+			method:         "GET",
+			expectedConfig: "user",
+			expectedParams: map[string]string{"id": "me"},
+		},
+		{
+			name: "Parameter preferred over wildcard",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("any-file", "/files/*", "GET", 0),
+				newPriorityConfig("file", "/files/{name}", "GET", 0),
+			},
+			path:           "/files/report.csv",
+			method:         "GET",
+			expectedConfig: "file",
+			expectedParams: map[string]string{"name": "report.csv"},
+		},
+		{
+			name: "Higher priority wildcard beats parameter",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("any-file", "/files/*", "GET", 5),
+				newPriorityConfig("file", "/files/{name}", "GET", 0),
+			},
+			path:           "/files/report.csv",
+			method:         "GET",
+			expectedConfig: "any-file",
+			expectedParams: map[string]string{"wildcard": "report.csv"},
+		},
+		{
+			name: "Method missing on static falls back to parameter",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("update-me", "/users/me", "POST", 0),
+				newPriorityConfig("user", "/users/{id}", "GET", 0),
+			},
+			path:           "/users/me",
+			method:         "GET",
+			expectedConfig: "user",
+			expectedParams: map[string]string{"id": "me"},
+		},
+		{
+			name: "Method missing on every candidate",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("update-me", "/users/me", "POST", 0),
+				newPriorityConfig("user", "/users/{id}", "GET", 0),
+			},
+			path:          "/users/me",
+			method:        "DELETE",
+			expectedError: ErrMethodNotAllowed,
+		},
+		{
+			name: "Deep backtracking across parameter levels",
+			routes: []*dto.APIConfigResponse{
+				newPriorityConfig("a", "/v1/{a}/x/y", "GET", 0),
+				newPriorityConfig("b", "/v1/static/{b}/z", "GET", 0),
+			},
+			path:           "/v1/static/x/y",
+			method:         "GET",
+			expectedConfig: "a",
+			expectedParams: map[string]string{"a": "static"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRouteRegistry(zap.NewNop())
+			for _, route := range tt.routes {
+				if err := registry.AddRoute(route); err != nil {
+					t.Fatalf("Failed to add route %s: %v", route.Path, err)
+				}
+			}
+
+			config, params, err := registry.Match(tt.path, tt.method)
+			if tt.expectedError != nil {
+				if err != tt.expectedError {
+					t.Errorf("Expected error %v, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected match, got error: %v", err)
+			}
+			if config.Description != tt.expectedConfig {
+				t.Errorf("Expected config %s, got %s", tt.expectedConfig, config.Description)
+			}
+			if len(params) != len(tt.expectedParams) {
+				t.Errorf("Expected params %v, got %v", tt.expectedParams, params)
+			}
+			for key, val := range tt.expectedParams {
+				if params[key] != val {
+					t.Errorf("Expected param[%s] = %s, got %s", key, val, params[key])
+				}
+			}
+		})
+	}
+}
+
+func TestIsCatchAllSegment(t *testing.T) {
+	tests := []struct {
+		segment  string
+		expected bool
+	}{
+		{"**", true},
+		{"{rest...}", true},
+		{"{...}", true},
+		{"*", false},
+		{"{rest}", false},
+		{"rest...", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			if result := IsCatchAllSegment(tt.segment); result != tt.expected {
+				t.Errorf("Expected %v, got %v for segment %s", tt.expected, result, tt.segment)
+			}
+		})
+	}
+
+	if err := ValidateSegment("{...}"); err == nil {
+		t.Error("Expected error for unnamed catch-all")
+	}
+}
+
+func TestRouteRegistry_MatchCatchAll(t *testing.T) {
+	registry := NewRouteRegistry(zap.NewNop())
+	registry.AddRoute(newPriorityConfig("bca", "/partner/bca/{rest...}", "GET", 0))
+	registry.AddRoute(newPriorityConfig("bca-health", "/partner/bca/health", "GET", 0))
+	registry.AddRoute(newPriorityConfig("mandiri", "/partner/mandiri/**", "POST", 0))
+	registry.AddRoute(newPriorityConfig("bni-item", "/partner/bni/{id}", "GET", 0))
+	registry.AddRoute(newPriorityConfig("bni", "/partner/bni/{rest...}", "GET", 0))
+
+	tests := []struct {
+		name           string
+		path           string
+		method         string
+		expectedConfig string
+		expectedParams map[string]string
+	}{
+		{
+			name:           "Captures remaining segments",
+			path:           "/partner/bca/v1/transfer/123",
+			method:         "GET",
+			expectedConfig: "bca",
+			expectedParams: map[string]string{"rest": "v1/transfer/123"},
+		},
+		{
+			name:           "Captures empty remainder",
+			path:           "/partner/bca",
+			method:         "GET",
+			expectedConfig: "bca",
+			expectedParams: map[string]string{"rest": ""},
+		},
+		{
+			name:           "Static sibling wins",
+			path:           "/partner/bca/health",
+			method:         "GET",
+			expectedConfig: "bca-health",
+			expectedParams: map[string]string{},
+		},
+		{
+			name:           "Double star stores wildcard",
+			path:           "/partner/mandiri/a/b",
+			method:         "POST",
+			expectedConfig: "mandiri",
+			expectedParams: map[string]string{"wildcard": "a/b"},
+		},
+		{
+			name:           "Parameter preferred for single segment",
+			path:           "/partner/bni/7",
+			method:         "GET",
+			expectedConfig: "bni-item",
+			expectedParams: map[string]string{"id": "7"},
+		},
+		{
+			name:           "Catch-all after parameter dead end",
+			path:           "/partner/bni/7/history",
+			method:         "GET",
+			expectedConfig: "bni",
+			expectedParams: map[string]string{"rest": "7/history"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, params, err := registry.Match(tt.path, tt.method)
+			if err != nil {
+				t.Fatalf("Expected match, got error: %v", err)
+			}
+			if config.Description != tt.expectedConfig {
+				t.Errorf("Expected config %s, got %s", tt.expectedConfig, config.Description)
+			}
+			if len(params) != len(tt.expectedParams) {
+				t.Errorf("Expected params %v, got %v", tt.expectedParams, params)
+			}
+			for key, val := range tt.expectedParams {
+				if params[key] != val {
+					t.Errorf("Expected param[%s] = %s, got %s", key, val, params[key])
+				}
+			}
+		})
+	}
+}
+
+func TestRouteRegistry_AddRouteCatchAllPlacement(t *testing.T) {
+	registry := NewRouteRegistry(zap.NewNop())
+
+	if err := registry.AddRoute(newPriorityConfig("bad", "/partner/{rest...}/x", "GET", 0)); err == nil {
+		t.Error("Expected error for non-terminal catch-all")
+	}
+	if err := registry.AddRoute(newPriorityConfig("first", "/partner/{rest...}", "GET", 0)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := registry.AddRoute(newPriorityConfig("second", "/partner/{path...}", "POST", 0)); err == nil {
+		t.Error("Expected error for conflicting catch-all names")
+	}
+}
@@ -0,0 +1,205 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Time zones resolve in minimal images without a zone database
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+// Reasons a route is unavailable
+const (
+	ReasonNotYetActive = "not yet active"
+	ReasonExpired      = "expired"
+	ReasonMaintenance  = "maintenance"
+)
+
+// maxChainedWindows bounds how many back-to-back windows are followed when computing Retry-After
+const maxChainedWindows = 8
+
+// Status describes whether a route is served at a given instant
+type Status struct {
+	Available  bool
+	Reason     string    // Empty when available
+	RetryAfter time.Time // When the route is expected back, zero when unknown or never
+}
+
+// dayNames maps the accepted day spellings to weekdays
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// locations caches loaded time zones, time.LoadLocation reads the zone database on every call
+var locations sync.Map
+
+// Check reports whether a route with the given activation window and maintenance schedule is served at now
+func Check(activeFrom, activeUntil *time.Time, windows []dto.MaintenanceWindow, now time.Time) Status {
+	if activeUntil != nil && !now.Before(*activeUntil) {
+		return Status{Reason: ReasonExpired}
+	}
+	if activeFrom != nil && now.Before(*activeFrom) {
+		return Status{Reason: ReasonNotYetActive, RetryAfter: *activeFrom}
+	}
+
+	if end, ok := maintenanceEnd(windows, now); ok {
+		return Status{Reason: ReasonMaintenance, RetryAfter: end}
+	}
+	return Status{Available: true}
+}
+
+// Validate checks windows for unknown days, malformed times and unknown time zones
+func Validate(windows []dto.MaintenanceWindow) error {
+	for i, w := range windows {
+		if _, err := parseDays(w.Days); err != nil {
+			return fmt.Errorf("maintenance window %d: %w", i, err)
+		}
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return fmt.Errorf("maintenance window %d: start: %w", i, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return fmt.Errorf("maintenance window %d: end: %w", i, err)
+		}
+		if start == end {
+			return fmt.Errorf("maintenance window %d: start and end are equal", i)
+		}
+		if _, err := location(w.Timezone); err != nil {
+			return fmt.Errorf("maintenance window %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// maintenanceEnd returns when the maintenance covering now ends, following back-to-back windows
+func maintenanceEnd(windows []dto.MaintenanceWindow, now time.Time) (time.Time, bool) {
+	end, inMaintenance := now, false
+	for i := 0; i < maxChainedWindows; i++ {
+		next, ok := latestWindowEnd(windows, end)
+		if !ok {
+			break
+		}
+		end, inMaintenance = next, true
+	}
+	return end, inMaintenance
+}
+
+// latestWindowEnd returns the latest end among the windows containing t
+func latestWindowEnd(windows []dto.MaintenanceWindow, t time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for _, w := range windows {
+		if end, ok := windowEnd(w, t); ok && (!found || end.After(latest)) {
+			latest, found = end, true
+		}
+	}
+	return latest, found
+}
+
+// windowEnd returns the end of the occurrence of w containing t
+// Invalid windows never match, they are rejected when the config is saved
+func windowEnd(w dto.MaintenanceWindow, t time.Time) (time.Time, bool) {
+	days, err := parseDays(w.Days)
+	if err != nil {
+		return time.Time{}, false
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(w.End)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+	loc, err := location(w.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := t.In(loc)
+
+	// The occurrence starting today, or the one that started yesterday and spans midnight
+	for _, offset := range []int{0, -1} {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		if days != nil && !days[day.Weekday()] {
+			continue
+		}
+
+		from := time.Date(day.Year(), day.Month(), day.Day(), start/60, start%60, 0, 0, loc)
+		to := time.Date(day.Year(), day.Month(), day.Day(), end/60, end%60, 0, 0, loc)
+		if end < start {
+			to = to.AddDate(0, 0, 1)
+		}
+
+		if !local.Before(from) && local.Before(to) {
+			return to, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseDays converts day names to a set, nil means every day
+func parseDays(names []string) (map[time.Weekday]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	days := make(map[time.Weekday]bool, len(names))
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if len(key) > 3 {
+			key = key[:3]
+		}
+		day, ok := dayNames[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", name)
+		}
+		days[day] = true
+	}
+	return days, nil
+}
+
+// parseClock converts "HH:MM" to minutes after midnight, "24:00" is accepted as the end of the day
+func parseClock(value string) (int, error) {
+	hours, minutes, found := strings.Cut(strings.TrimSpace(value), ":")
+	if !found {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil || m < 0 || m > 59 || h < 0 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return h*60 + m, nil
+}
+
+// location loads a time zone by IANA name, empty means UTC
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", name)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
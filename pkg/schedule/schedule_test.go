@@ -0,0 +1,106 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Payphone-Digital/gateway/internal/dto"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("Invalid time %q: %v", value, err)
+	}
+	return parsed
+}
+
+func TestCheck_ActiveWindow(t *testing.T) {
+	from := mustTime(t, "2026-03-01T00:00:00Z")
+	until := mustTime(t, "2026-04-01T00:00:00Z")
+
+	tests := []struct {
+		name       string
+		now        string
+		available  bool
+		reason     string
+		retryAfter time.Time
+	}{
+		{"before", "2026-02-28T23:59:59Z", false, ReasonNotYetActive, from},
+		{"at start", "2026-03-01T00:00:00Z", true, "", time.Time{}},
+		{"inside", "2026-03-15T12:00:00Z", true, "", time.Time{}},
+		{"at end", "2026-04-01T00:00:00Z", false, ReasonExpired, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := Check(&from, &until, nil, mustTime(t, tt.now))
+			if status.Available != tt.available || status.Reason != tt.reason || !status.RetryAfter.Equal(tt.retryAfter) {
+				t.Errorf("Expected (%v, %q, %v), got %+v", tt.available, tt.reason, tt.retryAfter, status)
+			}
+		})
+	}
+}
+
+func TestCheck_MaintenanceSchedule(t *testing.T) {
+	windows := []dto.MaintenanceWindow{
+		// Nightly bank cut-off in Jakarta (UTC+7), spanning midnight
+		{Start: "23:00", End: "01:00", Timezone: "Asia/Jakarta"},
+		// Sunday morning maintenance, followed directly by a second window
+		{Days: []string{"sunday"}, Start: "06:00", End: "07:00"},
+		{Days: []string{"sun"}, Start: "07:00", End: "07:30"},
+	}
+
+	tests := []struct {
+		name       string
+		now        string
+		available  bool
+		retryAfter string
+	}{
+		{"before cut-off", "2026-03-10T15:59:00Z", true, ""},
+		{"cut-off starts", "2026-03-10T16:00:00Z", false, "2026-03-10T18:00:00Z"},
+		{"after midnight local", "2026-03-10T17:30:00Z", false, "2026-03-10T18:00:00Z"},
+		{"cut-off ends", "2026-03-10T18:00:00Z", true, ""},
+		{"sunday chained windows", "2026-03-15T06:30:00Z", false, "2026-03-15T07:30:00Z"},
+		{"monday morning", "2026-03-16T06:30:00Z", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := Check(nil, nil, windows, mustTime(t, tt.now))
+			if status.Available != tt.available {
+				t.Fatalf("Expected available=%v, got %+v", tt.available, status)
+			}
+			if tt.retryAfter != "" && !status.RetryAfter.Equal(mustTime(t, tt.retryAfter)) {
+				t.Errorf("Expected retry after %s, got %v", tt.retryAfter, status.RetryAfter)
+			}
+			if !tt.available && status.Reason != ReasonMaintenance {
+				t.Errorf("Expected maintenance reason, got %q", status.Reason)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []dto.MaintenanceWindow{
+		{Days: []string{"Mon", "friday"}, Start: "22:00", End: "24:00", Timezone: "Asia/Jakarta"},
+		{Start: "00:00", End: "00:30"},
+	}
+	if err := Validate(valid); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	invalid := []dto.MaintenanceWindow{
+		{Days: []string{"someday"}, Start: "01:00", End: "02:00"},
+		{Start: "1am", End: "02:00"},
+		{Start: "01:00", End: "25:00"},
+		{Start: "01:00", End: "01:00"},
+		{Start: "01:00", End: "02:00", Timezone: "Mars/Olympus"},
+	}
+	for _, w := range invalid {
+		if err := Validate([]dto.MaintenanceWindow{w}); err == nil {
+			t.Errorf("Expected error for %+v", w)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package traffic
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+)
+
+// Primary names the route's own upstream, it receives whatever weight the variants leave
+const Primary = "primary"
+
+// DefaultOverrideHeader forces a variant by name when a route doesn't configure its own header,
+// responses carry the variant that served them in the same header
+const DefaultOverrideHeader = "X-Route-Variant"
+
+// Variant is an alternative upstream receiving Weight percent of a route's traffic
+type Variant struct {
+	Name   string
+	Weight int // 0-100, the weights of a route add up to at most 100
+}
+
+// Pick returns the variant serving a request, Primary when none does
+//   - override naming a variant (or Primary) wins regardless of weights
+//   - a non-empty key always lands in the same bucket for a given seed, so a client keeps its
+//     variant; raising a weight only moves primary buckets over, never back
+//   - an empty key picks at random
+func Pick(variants []Variant, seed, key, override string) string {
+	if override != "" {
+		if override == Primary {
+			return Primary
+		}
+		for _, v := range variants {
+			if v.Name == override {
+				return v.Name
+			}
+		}
+	}
+
+	bucket := Bucket(seed, key)
+	cumulative := 0
+	for _, v := range variants {
+		cumulative += v.Weight
+		if bucket < cumulative {
+			return v.Name
+		}
+	}
+	return Primary
+}
+
+// WithoutPrimary returns variants sharing all the traffic among them in proportion to their weights,
+// for routes whose own upstream can't take any. Variants without weight split it evenly when none has one
+func WithoutPrimary(variants []Variant) []Variant {
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+
+	scaled := make([]Variant, len(variants))
+	assigned := 0
+	for i, v := range variants {
+		weight := 100 / len(variants)
+		if total > 0 {
+			weight = v.Weight * 100 / total
+		}
+		scaled[i] = Variant{Name: v.Name, Weight: weight}
+		assigned += weight
+	}
+	if len(scaled) > 0 {
+		// Rounding leftovers go to the last variant, so no bucket falls through to the primary
+		scaled[len(scaled)-1].Weight += 100 - assigned
+	}
+	return scaled
+}
+
+// Bucket maps key to 0-99, randomly when key is empty
+func Bucket(seed, key string) int {
+	if key == "" {
+		return rand.Intn(100)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
+
+// Seed returns the bucket seed for a route, so routes split independently of each other
+func Seed(routeID uint) string {
+	return strconv.FormatUint(uint64(routeID), 10)
+}
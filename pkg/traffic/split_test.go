@@ -0,0 +1,111 @@
+package traffic
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPick_Override(t *testing.T) {
+	variants := []Variant{{Name: "v2", Weight: 0}}
+
+	tests := []struct {
+		override string
+		expected string
+	}{
+		{"v2", "v2"},
+		{Primary, Primary},
+		{"unknown", Primary}, // Falls back to the weights, v2 has none
+		{"", Primary},
+	}
+
+	for _, tt := range tests {
+		if got := Pick(variants, "1", "user-1", tt.override); got != tt.expected {
+			t.Errorf("Pick(override=%q) = %q, expected %q", tt.override, got, tt.expected)
+		}
+	}
+}
+
+func TestPick_StickyKey(t *testing.T) {
+	variants := []Variant{{Name: "v2", Weight: 50}}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		first := Pick(variants, "1", key, "")
+		for j := 0; j < 5; j++ {
+			if got := Pick(variants, "1", key, ""); got != first {
+				t.Fatalf("Key %s moved from %s to %s", key, first, got)
+			}
+		}
+	}
+}
+
+func TestPick_RampOnlyMovesPrimary(t *testing.T) {
+	// Clients already on v2 must stay there when its weight grows
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		before := Pick([]Variant{{Name: "v2", Weight: 5}}, "7", key, "")
+		after := Pick([]Variant{{Name: "v2", Weight: 25}}, "7", key, "")
+		if before == "v2" && after != "v2" {
+			t.Fatalf("Key %s moved back from v2 to %s after ramp up", key, after)
+		}
+	}
+}
+
+func TestPick_Weights(t *testing.T) {
+	variants := []Variant{{Name: "v2", Weight: 10}, {Name: "v3", Weight: 20}}
+
+	counts := make(map[string]int)
+	const total = 10000
+	for i := 0; i < total; i++ {
+		counts[Pick(variants, "1", fmt.Sprintf("user-%d", i), "")]++
+	}
+
+	expected := map[string]float64{"v2": 0.10, "v3": 0.20, Primary: 0.70}
+	for name, share := range expected {
+		got := float64(counts[name]) / total
+		if got < share-0.03 || got > share+0.03 {
+			t.Errorf("Variant %s got %.3f of traffic, expected about %.2f", name, got, share)
+		}
+	}
+}
+
+func TestWithoutPrimary(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []Variant
+		expected []int
+	}{
+		{"proportional", []Variant{{Name: "v2", Weight: 10}, {Name: "v3", Weight: 30}}, []int{25, 75}},
+		{"rounding", []Variant{{Name: "v2", Weight: 1}, {Name: "v3", Weight: 1}, {Name: "v4", Weight: 1}}, []int{33, 33, 34}},
+		{"no weights", []Variant{{Name: "v2"}, {Name: "v3"}}, []int{50, 50}},
+	}
+
+	for _, tt := range tests {
+		scaled := WithoutPrimary(tt.variants)
+		for i, v := range scaled {
+			if v.Name != tt.variants[i].Name || v.Weight != tt.expected[i] {
+				t.Errorf("%s: variant %d = %s/%d, expected %s/%d", tt.name, i, v.Name, v.Weight, tt.variants[i].Name, tt.expected[i])
+			}
+		}
+		for i := 0; i < 100; i++ {
+			if got := Pick(scaled, "1", fmt.Sprintf("user-%d", i), ""); got == Primary {
+				t.Fatalf("%s: expected no traffic for the primary", tt.name)
+			}
+		}
+	}
+}
+
+func TestPick_SeedSeparatesRoutes(t *testing.T) {
+	variants := []Variant{{Name: "v2", Weight: 50}}
+
+	differs := 0
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		if Pick(variants, "1", key, "") != Pick(variants, "2", key, "") {
+			differs++
+		}
+	}
+	if differs == 0 {
+		t.Error("Expected routes with different seeds to split clients differently")
+	}
+}
@@ -0,0 +1,142 @@
+package traffic
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is how many recent latencies are kept per variant for percentiles
+const latencySamples = 1024
+
+// Snapshot summarizes the requests a variant served since its stats were last reset
+type Snapshot struct {
+	Requests   int64
+	Errors     int64 // 5xx responses and requests that got no response
+	ErrorRate  float64
+	Statuses   map[int]int64 // 0 counts requests that got no response
+	LatencyAvg time.Duration
+	LatencyP50 time.Duration // Percentiles over the most recent samples
+	LatencyP95 time.Duration
+	LatencyP99 time.Duration
+	LatencyMax time.Duration
+	Since      time.Time
+}
+
+// Stats records per-variant status and latency for every split route, in memory per instance
+type Stats struct {
+	mu       sync.Mutex
+	variants map[uint]map[string]*variantStats
+	now      func() time.Time
+}
+
+type variantStats struct {
+	since    time.Time
+	requests int64
+	errors   int64
+	statuses map[int]int64
+	total    time.Duration
+	max      time.Duration
+	samples  []time.Duration // Ring buffer, next write at requests % latencySamples
+}
+
+// NewStats creates an empty recorder
+func NewStats() *Stats {
+	return &Stats{
+		variants: make(map[uint]map[string]*variantStats),
+		now:      time.Now,
+	}
+}
+
+// Record adds one request served by variant of route routeID
+// status 0 means the upstream gave no response (timeout, connection error)
+func (s *Stats) Record(routeID uint, variant string, status int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route, ok := s.variants[routeID]
+	if !ok {
+		route = make(map[string]*variantStats)
+		s.variants[routeID] = route
+	}
+	v, ok := route[variant]
+	if !ok {
+		v = &variantStats{since: s.now(), statuses: make(map[int]int64)}
+		route[variant] = v
+	}
+
+	if len(v.samples) < latencySamples {
+		v.samples = append(v.samples, latency)
+	} else {
+		v.samples[v.requests%latencySamples] = latency
+	}
+	v.requests++
+	v.statuses[status]++
+	if status == 0 || status >= 500 {
+		v.errors++
+	}
+	v.total += latency
+	if latency > v.max {
+		v.max = latency
+	}
+}
+
+// Route returns a snapshot per variant that served route routeID
+func (s *Stats) Route(routeID uint) map[string]Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshots := make(map[string]Snapshot, len(s.variants[routeID]))
+	for name, v := range s.variants[routeID] {
+		snapshots[name] = v.snapshot()
+	}
+	return snapshots
+}
+
+// Reset drops the stats of route routeID, e.g. after a promotion or rollback
+func (s *Stats) Reset(routeID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.variants, routeID)
+}
+
+// snapshot summarizes v (must hold lock)
+func (v *variantStats) snapshot() Snapshot {
+	snap := Snapshot{
+		Requests:   v.requests,
+		Errors:     v.errors,
+		Statuses:   make(map[int]int64, len(v.statuses)),
+		LatencyMax: v.max,
+		Since:      v.since,
+	}
+	for status, count := range v.statuses {
+		snap.Statuses[status] = count
+	}
+	if v.requests == 0 {
+		return snap
+	}
+
+	snap.ErrorRate = float64(v.errors) / float64(v.requests)
+	snap.LatencyAvg = v.total / time.Duration(v.requests)
+
+	sorted := make([]time.Duration, len(v.samples))
+	copy(sorted, v.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	snap.LatencyP50 = percentile(sorted, 50)
+	snap.LatencyP95 = percentile(sorted, 95)
+	snap.LatencyP99 = percentile(sorted, 99)
+	return snap
+}
+
+// percentile returns the nearest-rank percentile p of sorted
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,70 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats_Record(t *testing.T) {
+	stats := NewStats()
+
+	for i := 1; i <= 100; i++ {
+		stats.Record(1, Primary, 200, time.Duration(i)*time.Millisecond)
+	}
+	stats.Record(1, "v2", 200, 10*time.Millisecond)
+	stats.Record(1, "v2", 502, 30*time.Millisecond)
+	stats.Record(1, "v2", 0, 50*time.Millisecond)
+	stats.Record(2, Primary, 200, time.Millisecond)
+
+	route := stats.Route(1)
+	if len(route) != 2 {
+		t.Fatalf("Expected 2 variants, got %d", len(route))
+	}
+
+	primary := route[Primary]
+	if primary.Requests != 100 || primary.Errors != 0 {
+		t.Errorf("Unexpected primary counts: %+v", primary)
+	}
+	if primary.LatencyP50 != 50*time.Millisecond || primary.LatencyP95 != 95*time.Millisecond || primary.LatencyMax != 100*time.Millisecond {
+		t.Errorf("Unexpected primary latency: p50=%v p95=%v max=%v", primary.LatencyP50, primary.LatencyP95, primary.LatencyMax)
+	}
+
+	v2 := route["v2"]
+	if v2.Requests != 3 || v2.Errors != 2 {
+		t.Errorf("Unexpected v2 counts: %+v", v2)
+	}
+	if v2.Statuses[502] != 1 || v2.Statuses[0] != 1 {
+		t.Errorf("Unexpected v2 statuses: %v", v2.Statuses)
+	}
+	if v2.LatencyAvg != 30*time.Millisecond {
+		t.Errorf("Expected v2 average latency 30ms, got %v", v2.LatencyAvg)
+	}
+
+	stats.Reset(1)
+	if len(stats.Route(1)) != 0 {
+		t.Error("Expected route 1 stats to be reset")
+	}
+	if stats.Route(2)[Primary].Requests != 1 {
+		t.Error("Expected route 2 stats to survive resetting route 1")
+	}
+}
+
+func TestStats_SampleWindow(t *testing.T) {
+	stats := NewStats()
+
+	// Old slow samples are pushed out of the percentile window, max keeps them
+	for i := 0; i < latencySamples; i++ {
+		stats.Record(1, Primary, 200, time.Second)
+	}
+	for i := 0; i < latencySamples; i++ {
+		stats.Record(1, Primary, 200, time.Millisecond)
+	}
+
+	snap := stats.Route(1)[Primary]
+	if snap.LatencyP99 != time.Millisecond {
+		t.Errorf("Expected p99 over recent samples to be 1ms, got %v", snap.LatencyP99)
+	}
+	if snap.LatencyMax != time.Second {
+		t.Errorf("Expected max 1s, got %v", snap.LatencyMax)
+	}
+}